@@ -0,0 +1,46 @@
+package tagparser
+
+// altSep separates the alternatives of an item, see WithAlternatives.
+const altSep = '|'
+
+// WithAlternatives makes '|' separate alternatives within an item, as in
+// the `required|isdefault` of validator tags, where any one of the
+// alternatives may pass. ParseAlternatives returns the alternatives of each
+// item together, while Parse and the other functions see every alternative
+// as an item of its own. A '|' in a key or value must be quoted or escaped.
+//
+// Since '|' no longer separates list elements, declare another list
+// separator with WithListSeparator for list values. New panics if a
+// separator of the Parser is '|'.
+func WithAlternatives() Option {
+	return func(p *Parser) {
+		p.alternatives = true
+	}
+}
+
+// isSep reports whether c ends an item: the separator, or '|' with
+// alternatives.
+func (p *Parser) isSep(c byte) bool {
+	return c == p.sep || c == altSep && p.alternatives
+}
+
+// isSepRune is isSep for strings.IndexFunc.
+func (p *Parser) isSepRune(r rune) bool {
+	return r < 0x80 && p.isSep(byte(r))
+}
+
+// ParseAlternatives parses a tag treating every item as a rule, like
+// ParseRules, and returns the items in order with the alternatives of each
+// item together:
+//
+//	p := tagparser.New(tagparser.WithAlternatives())
+//	alts, _ := p.ParseAlternatives(`required|isdefault,min=1`)
+//	// [][]tagparser.Rule{
+//	//     {{Name: "required", Pos: 0}, {Name: "isdefault", Pos: 9, Alt: true}},
+//	//     {{Name: "min", Params: []string{"1"}, Pos: 19}},
+//	// }
+//
+// Without WithAlternatives every item has a single alternative.
+func (p *Parser) ParseAlternatives(tag string) ([][]Rule, error) {
+	return p.parseRules(tag)
+}
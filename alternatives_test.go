@@ -0,0 +1,66 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAlternatives(t *testing.T) {
+	p := New(WithAlternatives(), WithGroups())
+
+	alts, err := p.ParseAlternatives(`required|isdefault,min=1, eq='a|b'|ne=c\|d,in(x|y)`)
+	require.NoError(t, err)
+	assert.Equal(t, [][]Rule{
+		{{Name: "required", Pos: 0}, {Name: "isdefault", Pos: 9, Alt: true}},
+		{{Name: "min", Params: []string{"1"}, Pos: 19}},
+		{{Name: "eq", Params: []string{"a|b"}, Pos: 26}, {Name: "ne", Params: []string{"c|d"}, Pos: 35, Alt: true}},
+		{{Name: "in", Params: []string{"x|y"}, Pos: 43}},
+	}, alts)
+
+	rules, err := p.ParseRules(`a|b,c`)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{{Name: "a"}, {Name: "b", Pos: 2, Alt: true}, {Name: "c", Pos: 4}}, rules)
+
+	tag, err := p.Parse(`a|b=1`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"a": "", "b": "1"}, tag.Options)
+
+	// Without the option '|' is an ordinary character
+	rules, err = ParseRules(`a|b`)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{{Name: "a|b"}}, rules)
+
+	assert.Panics(t, func() { New(WithAlternatives(), WithListSeparator('|')) })
+}
+
+func TestWithAlternatives_Syntax(t *testing.T) {
+	p := New(WithAlternatives())
+
+	tokens := lexAll(t, p.Lexer(`a|b,c`))
+	assert.Equal(t, []Token{
+		{Kind: TokenKey, Text: "a", Raw: "a", Pos: 0},
+		{Kind: TokenSeparator, Text: "|", Raw: "|", Pos: 1},
+		{Kind: TokenKey, Text: "b", Raw: "b", Pos: 2},
+		{Kind: TokenSeparator, Text: ",", Raw: ",", Pos: 3},
+		{Kind: TokenKey, Text: "c", Raw: "c", Pos: 4},
+	}, tokens)
+
+	cst, err := p.ParseCST(`a | b,,c|`)
+	require.NoError(t, err)
+	assert.Equal(t, `a | b,,c|`, cst.Render())
+	require.Len(t, cst.Items, 5)
+	assert.True(t, cst.Items[1].Alt)
+	assert.False(t, cst.Items[2].Alt)
+	assert.True(t, cst.Items[4].Alt)
+
+	cst.Delete(0)
+	assert.Equal(t, `b,,c|`, cst.Render())
+	require.NoError(t, cst.SetValue(0, "x|y"))
+	assert.Equal(t, `b=x\|y,,c|`, cst.Render())
+
+	tag, err := p.Parse(cst.Render())
+	require.NoError(t, err)
+	assert.Equal(t, M{"b": "x|y", "c": ""}, tag.Options)
+}
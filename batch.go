@@ -0,0 +1,72 @@
+package tagparser
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// batchChunk is the number of tags a ParseBatch worker claims at a time.
+const batchChunk = 64
+
+// ParseBatch parses many tags concurrently, treating all items as options,
+// like Parse. See Parser.ParseBatch.
+func ParseBatch(tags []string) ([]Tag, []error) {
+	return defaultParser.ParseBatch(tags)
+}
+
+// ParseBatchWithName is like ParseBatch but treats the first item of each
+// tag as a name, like ParseWithName.
+func ParseBatchWithName(tags []string) ([]Tag, []error) {
+	return defaultParser.ParseBatchWithName(tags)
+}
+
+// ParseBatch parses tags concurrently with up to GOMAXPROCS workers, for
+// programs parsing many tags at startup. The i-th Tag and error are those
+// Parse returns for tags[i]: a malformed tag leaves a zero Tag, unless p is
+// lenient. The error slice is nil if every tag parsed.
+func (p *Parser) ParseBatch(tags []string) ([]Tag, []error) {
+	return p.parseBatch(tags, false)
+}
+
+// ParseBatchWithName is like ParseBatch but treats the first item of each
+// tag as a name, like ParseWithName.
+func (p *Parser) ParseBatchWithName(tags []string) ([]Tag, []error) {
+	return p.parseBatch(tags, true)
+}
+
+func (p *Parser) parseBatch(tags []string, withName bool) ([]Tag, []error) {
+	out := make([]Tag, len(tags))
+	errs := make([]error, len(tags))
+	var next atomic.Int64 // first tag of the next chunk
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	workers := min(runtime.GOMAXPROCS(0), (len(tags)+batchChunk-1)/batchChunk)
+	for range workers {
+		wg.Go(func() {
+			for {
+				start := int(next.Add(batchChunk)) - batchChunk
+				if start >= len(tags) {
+					return
+				}
+				for i := start; i < min(start+batchChunk, len(tags)); i++ {
+					tag, err := p.result(p.parseTag(tags[i], withName))
+					if tag != nil {
+						out[i] = *tag
+					}
+					if err != nil {
+						errs[i] = err
+						failed.Store(true)
+					}
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	if !failed.Load() {
+		return out, nil
+	}
+
+	return out, errs
+}
@@ -0,0 +1,43 @@
+package tagparser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBatch(t *testing.T) {
+	tags := make([]string, 1000)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("id%d,min=%d", i, i)
+	}
+
+	out, errs := ParseBatchWithName(tags)
+	require.Nil(t, errs)
+	require.Len(t, out, len(tags))
+	for i, tag := range out {
+		assert.Equal(t, fmt.Sprintf("id%d", i), tag.Name)
+		assert.Equal(t, M{"min": fmt.Sprint(i)}, tag.Options)
+	}
+
+	tags[500] = `a,=b`
+	out, errs = ParseBatch(tags)
+	require.Len(t, errs, len(tags))
+	var parseErr *Error
+	require.ErrorAs(t, errs[500], &parseErr)
+	assert.Equal(t, CodeEmptyKey, parseErr.Code)
+	assert.Nil(t, errs[499])
+	assert.Equal(t, Tag{}, out[500])
+	assert.Equal(t, M{"id499": "", "min": "499"}, out[499].Options)
+
+	// Lenient parsers keep what they could parse
+	out, errs = New(WithLenient()).ParseBatch([]string{`a,=b`})
+	require.Error(t, errs[0])
+	assert.Equal(t, M{"a": ""}, out[0].Options)
+
+	out, errs = ParseBatch(nil)
+	assert.Empty(t, out)
+	assert.Nil(t, errs)
+}
@@ -0,0 +1,99 @@
+package tagparser
+
+import (
+	"errors"
+	"strings"
+	"unsafe"
+)
+
+// ParseBytes is like Parse but takes the tag as a byte slice, as read from
+// go/ast or a file. The returned Tag does not share memory with b.
+func ParseBytes(b []byte) (*Tag, error) {
+	return defaultParser.ParseBytes(b)
+}
+
+// ParseWithNameBytes is like ParseWithName but takes the tag as a byte slice.
+// The returned Tag does not share memory with b.
+func ParseWithNameBytes(b []byte) (*Tag, error) {
+	return defaultParser.ParseWithNameBytes(b)
+}
+
+// ParseFuncBytes is like ParseFunc but takes the tag as a byte slice without
+// copying it, so it does not allocate on well-formed input.
+//
+// The key and value passed to callback may share memory with b: they are
+// only valid while b is unchanged, so clone them (strings.Clone) to keep
+// them beyond that. Returned errors never share memory with b.
+func ParseFuncBytes(b []byte, callback func(key, value string) error) error {
+	return defaultParser.ParseFuncBytes(b, callback)
+}
+
+// ParseFuncWithNameBytes is like ParseFuncWithName but takes the tag as a
+// byte slice without copying it. The same sharing rules as for
+// ParseFuncBytes apply to the strings passed to callback.
+func ParseFuncWithNameBytes(b []byte, callback func(key, value string) error) error {
+	return defaultParser.ParseFuncWithNameBytes(b, callback)
+}
+
+// ParseBytes is like Parse but takes the tag as a byte slice.
+func (p *Parser) ParseBytes(b []byte) (*Tag, error) {
+	return p.Parse(string(b))
+}
+
+// ParseWithNameBytes is like ParseWithName but takes the tag as a byte slice.
+func (p *Parser) ParseWithNameBytes(b []byte) (*Tag, error) {
+	return p.ParseWithName(string(b))
+}
+
+// ParseFuncBytes is like ParseFunc but takes the tag as a byte slice without
+// copying it.
+func (p *Parser) ParseFuncBytes(b []byte, callback func(key, value string) error) error {
+	return detachError(p.ParseFunc(bytesView(b), callback))
+}
+
+// ParseFuncWithNameBytes is like ParseFuncWithName but takes the tag as a byte
+// slice without copying it.
+func (p *Parser) ParseFuncWithNameBytes(b []byte, callback func(key, value string) error) error {
+	return detachError(p.ParseFuncWithName(bytesView(b), callback))
+}
+
+// bytesView returns a string sharing memory with b. The string must not
+// outlive any modification of b.
+func bytesView(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+
+	return unsafe.String(unsafe.SliceData(b), len(b)) //nolint:gosec // callers never retain the view
+}
+
+// detachError copies the tag text held by parse errors so that they stay
+// valid after the byte slice they were parsed from changes.
+func detachError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var errs *Errors
+	if errors.As(err, &errs) {
+		errs.Tag = strings.Clone(errs.Tag)
+		for _, e := range errs.List {
+			detachParseError(e)
+		}
+
+		return err
+	}
+
+	var parseErr *Error
+	if errors.As(err, &parseErr) {
+		detachParseError(parseErr)
+	}
+
+	return err
+}
+
+func detachParseError(e *Error) {
+	e.Tag = strings.Clone(e.Tag)
+	e.Msg = strings.Clone(e.Msg)
+	e.Segment = strings.Clone(e.Segment)
+}
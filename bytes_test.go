@@ -0,0 +1,81 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBytes(t *testing.T) {
+	b := []byte(`alfa,bravo=charlie`)
+	tag, err := ParseBytes(b)
+	require.NoError(t, err)
+	copy(b, "XXXXXXXXXXXXXXXXXX")
+	assert.Equal(t, M{"alfa": "", "bravo": "charlie"}, tag.Options)
+
+	b = []byte(`"alfa,bravo=charlie"`)
+	tag, err = ParseWithNameBytes(b)
+	require.NoError(t, err)
+	copy(b, "XXXXXXXXXXXXXXXXXXXX")
+	assert.Equal(t, "alfa", tag.Name)
+	assert.Equal(t, M{"bravo": "charlie"}, tag.Options)
+}
+
+func TestParseFuncBytes(t *testing.T) {
+	opts := make(M)
+	err := ParseFuncBytes([]byte(`alfa,bravo='charlie, delta'`), func(key, value string) error {
+		opts[key] = value
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, M{"alfa": "", "bravo": "charlie, delta"}, opts)
+
+	var name string
+	err = ParseFuncWithNameBytes([]byte(`alfa,bravo`), func(key, value string) error {
+		if key == "" {
+			name = value
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alfa", name)
+
+	require.NoError(t, ParseFuncBytes(nil, func(key, value string) error {
+		t.Errorf("unexpected item %q=%q", key, value)
+
+		return nil
+	}))
+}
+
+func TestParseFuncBytes_ZeroAlloc(t *testing.T) {
+	b := []byte(benchTagSimple)
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = ParseFuncBytes(b, func(key, value string) error { return nil })
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestParseFuncBytes_ErrorDetached(t *testing.T) {
+	b := []byte(`alfa,bra\vo`)
+	err := ParseFuncBytes(b, func(key, value string) error { return nil })
+	copy(b, "XXXXXXXXXXX")
+
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, `alfa,bra\vo`, parseErr.Tag)
+	assert.Equal(t, `bra\vo`, parseErr.Segment)
+
+	b = []byte(`alfa,=bravo,charlie=\x`)
+	err = New(WithLenient()).ParseFuncBytes(b, func(key, value string) error { return nil })
+	copy(b, "XXXXXXXXXXXXXXXXXXXXXX")
+
+	var errs *Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(t, `alfa,=bravo,charlie=\x`, errs.Tag)
+	require.Len(t, errs.List, 2)
+	assert.Equal(t, `=bravo`, errs.List[0].Segment)
+	assert.Equal(t, `charlie=\x`, errs.List[1].Segment)
+}
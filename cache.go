@@ -0,0 +1,102 @@
+package tagparser
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CachedParser memoizes parse results for repeated tag strings.
+//
+// Reflection-heavy code tends to parse the same handful of tags over and
+// over; a CachedParser keeps the most recently used results in an LRU cache
+// keyed by the input string, so each distinct tag is parsed once.
+//
+// Returned Tags are shared between all callers asking for the same input and
+// must be treated as read-only. Errors are cached as well. A CachedParser is
+// safe for concurrent use.
+type CachedParser struct {
+	parser *Parser
+	size   int
+
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	lru     *list.List // front is most recently used
+}
+
+type cacheKey struct {
+	tag      string
+	withName bool
+}
+
+type cacheEntry struct {
+	key cacheKey
+	tag *Tag
+	err error
+}
+
+// NewCachedParser creates a CachedParser holding up to size results, parsing
+// with a Parser configured by opts. A size below 1 is treated as 1.
+func NewCachedParser(size int, opts ...Option) *CachedParser {
+	size = max(size, 1)
+
+	return &CachedParser{
+		parser:  New(opts...),
+		size:    size,
+		entries: make(map[cacheKey]*list.Element, size),
+		lru:     list.New(),
+	}
+}
+
+// Parse is like Parser.Parse but returns a cached result for inputs seen
+// before. The returned Tag must not be modified.
+func (c *CachedParser) Parse(tag string) (*Tag, error) {
+	return c.get(cacheKey{tag: tag}, c.parser.Parse)
+}
+
+// ParseWithName is like Parser.ParseWithName but returns a cached result for
+// inputs seen before. The returned Tag must not be modified.
+func (c *CachedParser) ParseWithName(tag string) (*Tag, error) {
+	return c.get(cacheKey{tag: tag, withName: true}, c.parser.ParseWithName)
+}
+
+// Len returns the number of cached results.
+func (c *CachedParser) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+func (c *CachedParser) get(key cacheKey, parse func(string) (*Tag, error)) (*Tag, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*cacheEntry) //nolint:forcetypeassert // only *cacheEntry is stored
+		c.mu.Unlock()
+
+		return entry.tag, entry.err
+	}
+	c.mu.Unlock()
+
+	// Parse outside the lock; concurrent misses for the same input may both
+	// parse, and the first one to finish wins.
+	tag, err := parse(key.tag)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry) //nolint:forcetypeassert // only *cacheEntry is stored
+
+		return entry.tag, entry.err
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, tag: tag, err: err})
+	if c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key) //nolint:forcetypeassert // only *cacheEntry is stored
+	}
+
+	return tag, err
+}
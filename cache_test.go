@@ -0,0 +1,75 @@
+package tagparser
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedParser(t *testing.T) {
+	c := NewCachedParser(2)
+
+	tag1, err := c.ParseWithName(`alfa,bravo=charlie`)
+	require.NoError(t, err)
+	assert.Equal(t, "alfa", tag1.Name)
+	assert.Equal(t, M{"bravo": "charlie"}, tag1.Options)
+
+	tag2, err := c.ParseWithName(`alfa,bravo=charlie`)
+	require.NoError(t, err)
+	assert.Same(t, tag1, tag2, "repeated input should return the cached tag")
+
+	// The same input parsed in options mode is cached separately
+	tag3, err := c.Parse(`alfa,bravo=charlie`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"alfa": "", "bravo": "charlie"}, tag3.Options)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestCachedParser_Eviction(t *testing.T) {
+	c := NewCachedParser(2)
+
+	a, _ := c.Parse(`a`)
+	_, _ = c.Parse(`b`)
+	_, _ = c.Parse(`a`) // a becomes most recently used
+	_, _ = c.Parse(`c`) // evicts b
+	assert.Equal(t, 2, c.Len())
+
+	a2, _ := c.Parse(`a`)
+	assert.Same(t, a, a2)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestCachedParser_Errors(t *testing.T) {
+	c := NewCachedParser(4, WithPreserveWhitespace())
+
+	_, err1 := c.Parse(`alfa,=bravo`)
+	_, err2 := c.Parse(`alfa,=bravo`)
+	require.Error(t, err1)
+	assert.Same(t, err1, err2)
+
+	tag, err := c.Parse(` alfa `)
+	require.NoError(t, err)
+	assert.Equal(t, M{" alfa ": ""}, tag.Options)
+}
+
+func TestCachedParser_Concurrent(t *testing.T) {
+	c := NewCachedParser(8)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				tag, err := c.Parse(fmt.Sprintf("key%d=%d", (i+j)%16, j%3))
+				assert.NoError(t, err)
+				assert.Len(t, tag.Options, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, c.Len(), 8)
+}
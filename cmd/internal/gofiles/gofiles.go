@@ -0,0 +1,50 @@
+// Package gofiles lists the Go files named on the command line of the
+// tagparser commands.
+package gofiles
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// List returns the Go files named by args: files are returned as they are
+// and directories are walked recursively, skipping testdata, vendor and
+// hidden directories like the go command. A trailing /... is accepted and
+// ignored, and "-" is returned as is for standard input.
+func List(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		if arg == "-" {
+			files = append(files, arg)
+
+			continue
+		}
+		arg = strings.TrimSuffix(arg, "/...")
+		if arg == "" {
+			arg = "."
+		}
+		err := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				return err
+			case d.IsDir() && path != arg && skipDir(d.Name()):
+				return filepath.SkipDir
+			case !d.IsDir() && (path == arg || strings.HasSuffix(path, ".go")):
+				files = append(files, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// skipDir reports whether a directory is ignored like by the go command.
+func skipDir(name string) bool {
+	return name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
@@ -0,0 +1,17 @@
+// Command tagcheck reports malformed struct tags.
+//
+// Run it directly or through go vet:
+//
+//	go install github.com/talav/tagparser/cmd/tagcheck@latest
+//	go vet -vettool=$(which tagcheck) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/talav/tagparser/tagcheck"
+)
+
+func main() {
+	singlechecker.Main(tagcheck.Analyzer)
+}
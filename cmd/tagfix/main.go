@@ -0,0 +1,337 @@
+// Command tagfix rewrites the struct tags of Go files in place.
+//
+// It rewrites the value of one struct tag key in the Go files and
+// directories given as arguments, the current directory by default, with
+// the lossless tagparser.CST so that only what must change does:
+//
+//	tagfix -key json -quotes -sort ./...
+//	tagfix -key validate -rename renames.json -diff ./internal
+//
+// The rewrites are selected with flags and applied in this order:
+//
+//	-rename file  rename keys per a JSON object such as {"min_len": "minlen"}
+//	-quotes       rewrite every item with the minimal quotes and escapes
+//	-sort         sort the options by key, keeping the name first
+//
+// Values are parsed with the dialect given by -dialect. Files are written
+// back unless -diff is set, in which case the changes are printed as a
+// unified diff instead. Malformed tags are reported on standard error and
+// left unchanged, and the exit status is then 1.
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/talav/tagparser"
+	"github.com/talav/tagparser/cmd/internal/gofiles"
+)
+
+var (
+	errUsage     = errors.New("usage: tagfix -key name [-dialect name] [-rename file.json] [-quotes] [-sort] [-diff] [file.go | dir]...")
+	errMalformed = errors.New("malformed struct tags")
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	if err != nil {
+		if !errors.Is(err, errMalformed) {
+			fmt.Fprintln(os.Stderr, "tagfix:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// fixer rewrites the values of a struct tag key.
+type fixer struct {
+	key     string
+	dialect tagparser.Dialect
+	renames map[string]string
+	quotes  bool
+	sort    bool
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("tagfix", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	key := flags.String("key", "", "struct tag key to rewrite, such as json")
+	dialect := flags.String("dialect", "default", "dialect of the values: "+strings.Join(tagparser.Dialects(), ", "))
+	renameFile := flags.String("rename", "", "JSON file mapping old keys to new keys")
+	quotes := flags.Bool("quotes", false, "rewrite items with minimal quotes and escapes")
+	sortKeys := flags.Bool("sort", false, "sort options by key")
+	diff := flags.Bool("diff", false, "print a diff instead of writing files")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *key == "" || *renameFile == "" && !*quotes && !*sortKeys {
+		return errUsage
+	}
+
+	f := &fixer{key: *key, quotes: *quotes, sort: *sortKeys}
+	var ok bool
+	if f.dialect, ok = tagparser.LookupDialect(*dialect); !ok {
+		return fmt.Errorf("%w %q", tagparser.ErrUnknownDialect, *dialect)
+	}
+	if *renameFile != "" {
+		data, err := os.ReadFile(*renameFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &f.renames); err != nil {
+			return fmt.Errorf("%s: %w", *renameFile, err)
+		}
+	}
+
+	paths := []string{"."}
+	if flags.NArg() > 0 {
+		paths = flags.Args()
+	}
+	files, err := gofiles.List(paths)
+	if err != nil {
+		return err
+	}
+
+	malformed := false
+	for _, name := range files {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		out, errs, err := f.fixFile(name, src)
+		if err != nil {
+			return err
+		}
+		for _, e := range errs {
+			malformed = true
+			fmt.Fprintln(stderr, e)
+		}
+		if bytes.Equal(src, out) {
+			continue
+		}
+
+		if *diff {
+			writeDiff(stdout, name, src, out)
+
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, out, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if malformed {
+		return errMalformed
+	}
+
+	return nil
+}
+
+// fixFile returns src with its struct tags rewritten, along with the
+// malformed tags left unchanged.
+func (f *fixer) fixFile(name string, src []byte) ([]byte, []error, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lits []*ast.BasicLit
+	ast.Inspect(file, func(n ast.Node) bool {
+		if field, ok := n.(*ast.Field); ok && field.Tag != nil {
+			lits = append(lits, field.Tag)
+		}
+
+		return true
+	})
+
+	// Replace the literals from the end, so that offsets stay valid
+	out := slices.Clone(src)
+	var errs []error
+	for _, lit := range slices.Backward(lits) {
+		fixed, err := f.fixLit(lit.Value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", fset.Position(lit.Pos()), err))
+
+			continue
+		}
+		start := fset.Position(lit.Pos()).Offset
+		out = slices.Concat(out[:start], []byte(fixed), out[start+len(lit.Value):])
+	}
+	slices.Reverse(errs)
+
+	return out, errs, nil
+}
+
+// fixLit returns the struct tag literal lit with the value of the key
+// rewritten, quoted like lit when possible.
+func (f *fixer) fixLit(lit string) (string, error) {
+	tag, err := strconv.Unquote(lit)
+	if err != nil {
+		return lit, nil //nolint:nilerr // not valid Go, reported by the compiler
+	}
+
+	start, end := -1, -1
+	var value string
+	err = tagparser.SplitStructTag(tag, func(key, v string, valuePos int) error {
+		if key != f.key || start >= 0 {
+			return nil
+		}
+		quoted, err := strconv.QuotedPrefix(tag[valuePos:])
+		if err != nil {
+			return err
+		}
+		start, end, value = valuePos, valuePos+len(quoted), v
+
+		return nil
+	})
+	if err != nil || start < 0 {
+		return lit, err
+	}
+
+	fixed, err := f.fix(value)
+	if err != nil {
+		return lit, fmt.Errorf("%s tag: %w", f.key, err)
+	}
+	if fixed == value {
+		return lit, nil
+	}
+
+	tag = tag[:start] + strconv.Quote(fixed) + tag[end:]
+	if lit[0] == '`' && strconv.CanBackquote(tag) {
+		return "`" + tag + "`", nil
+	}
+
+	return strconv.Quote(tag), nil
+}
+
+// fix returns value rewritten.
+func (f *fixer) fix(value string) (string, error) {
+	p := f.dialect.Parser()
+	var cst *tagparser.CST
+	var err error
+	if f.dialect.HasName() {
+		cst, err = p.ParseCSTWithName(value)
+	} else {
+		cst, err = p.ParseCST(value)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Option items: the name, if any, has no key
+	var opts []int
+	for i, it := range cst.Items {
+		if it.RawKey != "" {
+			opts = append(opts, i)
+		}
+	}
+
+	for _, i := range opts {
+		if to, ok := f.renames[cst.Items[i].Key]; ok {
+			if err := cst.SetKey(i, to); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if f.quotes {
+		for _, i := range opts {
+			// Keys written as decoded keep their case, even when the
+			// dialect folds it
+			if strings.EqualFold(cst.Items[i].RawKey, cst.Items[i].Key) {
+				continue
+			}
+			if err := cst.SetKey(i, cst.Items[i].Key); err != nil {
+				return "", err
+			}
+		}
+		for i, it := range cst.Items {
+			if it.RawValue == "" {
+				continue
+			}
+			if err := cst.SetValue(i, it.Value); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if f.sort {
+		// Sort the options, keeping the whitespace around them in place
+		sorted := make([]tagparser.CSTItem, len(opts))
+		for j, i := range opts {
+			sorted[j] = cst.Items[i]
+		}
+		slices.SortStableFunc(sorted, func(a, b tagparser.CSTItem) int {
+			return cmp.Compare(a.Key, b.Key)
+		})
+		for j, i := range opts {
+			it := &cst.Items[i]
+			it.Key, it.Value, it.RawKey, it.Assign, it.RawValue = sorted[j].Key, sorted[j].Value, sorted[j].RawKey, sorted[j].Assign, sorted[j].RawValue
+		}
+		if first := &cst.Items[0]; f.dialect.HasName() && first.RawKey != "" && first.Assign == "" {
+			// A flag sorted first would be read as the name: keep an
+			// empty name before it
+			cst.Items = slices.Insert(cst.Items, 0, tagparser.CSTItem{Leading: first.Leading})
+			cst.Items[1].Leading = ""
+		}
+	}
+
+	return cst.Render(), nil
+}
+
+// writeDiff writes the changes from src to out as a unified diff with one
+// hunk per changed line. Rewriting tags does not add or remove lines, except
+// for tags spanning lines, which are shown as a change of the whole file.
+func writeDiff(w io.Writer, name string, src, out []byte) {
+	name = strings.TrimPrefix(filepath.ToSlash(name), "/")
+	fmt.Fprintf(w, "--- a/%s\n+++ b/%s\n", name, name)
+
+	before := strings.SplitAfter(string(src), "\n")
+	after := strings.SplitAfter(string(out), "\n")
+	if len(before) != len(after) {
+		fmt.Fprintf(w, "@@ -1,%d +1,%d @@\n", len(before), len(after))
+		for _, line := range before {
+			writeLine(w, '-', line)
+		}
+		for _, line := range after {
+			writeLine(w, '+', line)
+		}
+
+		return
+	}
+
+	for i := range before {
+		if before[i] != after[i] {
+			fmt.Fprintf(w, "@@ -%d +%d @@\n", i+1, i+1)
+			writeLine(w, '-', before[i])
+			writeLine(w, '+', after[i])
+		}
+	}
+}
+
+// writeLine writes line with the diff prefix op, ending it with a newline.
+func writeLine(w io.Writer, op byte, line string) {
+	if line == "" {
+		return
+	}
+	fmt.Fprintf(w, "%c%s", op, line)
+	if !strings.HasSuffix(line, "\n") {
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talav/tagparser"
+)
+
+const src = "package a\n\ntype User struct {\n" +
+	"\tID   int    `json:\"id\" validate:\"required, min_len='5', max=9\"`\n" +
+	"\tName string \"validate:\\\"'name', 'x'\\\"\"\n" +
+	"\tBad  string `validate:\"a,=x\"`\n" +
+	"\tNone string `json:\"none\"`\n" +
+	"}\n"
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixer   fixer
+		in      string
+		want    string
+		dialect string
+	}{
+		{name: "rename", fixer: fixer{renames: map[string]string{"min_len": "min"}}, in: `x, min_len=5`, want: `x, min=5`},
+		{name: "quotes", fixer: fixer{quotes: true}, in: ` 'x' , a='5',b=' c' ,d=e\,f`, want: ` x , a=5,b=' c' ,d='e,f'`},
+		{name: "sort", fixer: fixer{sort: true}, in: `x, c=1 ,b, a = 2`, want: `x, a = 2 ,b, c=1`},
+		{name: "leading option", fixer: fixer{renames: map[string]string{"min_len": "minlen"}}, in: `min_len=3,max=5`, want: `minlen=3,max=5`},
+		{name: "leading option sort", fixer: fixer{sort: true}, in: `min_len=3,b,a=1`, want: `a=1,b,min_len=3`},
+		{name: "leading flag sort", fixer: fixer{sort: true}, in: `c=3,b`, want: `,b,c=3`},
+		{name: "no name", fixer: fixer{sort: true}, in: `b;a`, want: `a;b`, dialect: "gorm"},
+		{name: "folded keys", fixer: fixer{quotes: true}, in: `NOT NULL;column:a`, want: `NOT NULL;column:a`, dialect: "gorm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ok bool
+			tt.fixer.dialect, ok = tagparser.LookupDialect(cmp.Or(tt.dialect, "default"))
+			require.True(t, ok)
+			got, err := tt.fixer.fix(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+	renames := filepath.Join(dir, "renames.json")
+	require.NoError(t, os.WriteFile(renames, []byte(`{"min_len": "min"}`), 0o600))
+
+	// Preview
+	var stdout, stderr bytes.Buffer
+	args := []string{"-key", "validate", "-rename", renames, "-quotes", "-sort", "-diff", dir}
+	require.ErrorIs(t, run(args, &stdout, &stderr), errMalformed)
+	diffName := strings.TrimPrefix(filepath.ToSlash(file), "/")
+	assert.Equal(t, "--- a/"+diffName+"\n+++ b/"+diffName+"\n"+
+		"@@ -4 +4 @@\n"+
+		"-\tID   int    `json:\"id\" validate:\"required, min_len='5', max=9\"`\n"+
+		"+\tID   int    `json:\"id\" validate:\"required, max=9, min=5\"`\n"+
+		"@@ -5 +5 @@\n"+
+		"-\tName string \"validate:\\\"'name', 'x'\\\"\"\n"+
+		"+\tName string \"validate:\\\"name, x\\\"\"\n", stdout.String())
+	assert.Contains(t, stderr.String(), file+":6:14: validate tag: empty key")
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, src, string(data))
+
+	// Write
+	stdout.Reset()
+	require.ErrorIs(t, run(append(args[:len(args)-2:len(args)-2], dir), &stdout, &stderr), errMalformed)
+	assert.Empty(t, stdout.String())
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "`json:\"id\" validate:\"required, max=9, min=5\"`")
+
+	// Usage errors
+	require.ErrorIs(t, run([]string{"-key", "json"}, &stdout, &stderr), errUsage)
+	require.ErrorIs(t, run([]string{"-key", "json", "-sort", "-dialect", "nope"}, &stdout, &stderr), tagparser.ErrUnknownDialect)
+	require.Error(t, run([]string{"-key", "json", "-rename", filepath.Join(dir, "missing.json")}, &stdout, &stderr))
+}
@@ -0,0 +1,218 @@
+// Command tagparse prints the parsed struct tags of Go files.
+//
+// It reads the Go files and directories given as arguments, or a Go file
+// from standard input, and prints the value of one struct tag key for every
+// field, parsed with a registered tagparser dialect:
+//
+//	tagparse -key json ./...
+//	tagparse -key gorm -dialect gorm -format json models.go
+//	tagparse -key validate -check ./internal
+//
+// Directories are walked recursively, skipping testdata, vendor and hidden
+// directories; a trailing /... is accepted and ignored. The table format
+// prints one line per field with its position, its type and name, and the
+// parsed name and options; the json format prints an array of objects.
+//
+// Malformed tags are reported on standard error, one per line, as JSON
+// objects in the json format. With -check nothing else is printed and the
+// exit status is 1 if any tag is malformed, for CI.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/talav/tagparser"
+	"github.com/talav/tagparser/cmd/internal/gofiles"
+	"github.com/talav/tagparser/source"
+)
+
+var (
+	errUsage     = errors.New("usage: tagparse -key name [-dialect name] [-format table|json] [-check] [file.go | dir]...")
+	errMalformed = errors.New("malformed struct tags")
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		if !errors.Is(err, errMalformed) {
+			fmt.Fprintln(os.Stderr, "tagparse:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// field is a parsed tag as printed in the json format.
+type field struct {
+	Pos     string            `json:"pos"`
+	Type    string            `json:"type,omitempty"`
+	Field   string            `json:"field"`
+	Value   string            `json:"value"`
+	Name    string            `json:"name"`
+	Options map[string]string `json:"options"`
+}
+
+// malformedTag is a malformed tag as reported in the json format.
+type malformedTag struct {
+	Pos     string           `json:"pos"`
+	Field   string           `json:"field"`
+	Message string           `json:"message"`
+	Error   *tagparser.Error `json:"error,omitempty"`
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("tagparse", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	key := flags.String("key", "", "struct tag key to parse, such as json")
+	dialect := flags.String("dialect", "default", "dialect of the values: "+strings.Join(tagparser.Dialects(), ", "))
+	format := flags.String("format", "table", "output format: table or json")
+	check := flags.Bool("check", false, "only report malformed tags, exiting with status 1 if any")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *key == "" || *format != "table" && *format != "json" {
+		return errUsage
+	}
+	if _, ok := tagparser.LookupDialect(*dialect); !ok {
+		return fmt.Errorf("%w %q", tagparser.ErrUnknownDialect, *dialect)
+	}
+
+	files := []string{"-"}
+	if flags.NArg() > 0 {
+		var err error
+		if files, err = gofiles.List(flags.Args()); err != nil {
+			return err
+		}
+	}
+
+	fields := []field{}
+	malformed := false
+	for _, name := range files {
+		found, err := parseFile(name, stdin, *key, *dialect)
+		var srcErr *source.Error
+		if errors.As(err, &srcErr) {
+			malformed = true
+			if err := report(stderr, err, *format == "json"); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+		fields = append(fields, found...)
+	}
+
+	switch {
+	case *check:
+	case *format == "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(fields); err != nil {
+			return err
+		}
+	default:
+		if err := printTable(stdout, fields); err != nil {
+			return err
+		}
+	}
+	if malformed && *check {
+		return errMalformed
+	}
+
+	return nil
+}
+
+// parseFile returns the tags of the file name, "-" for r. Malformed tags
+// are reported in a joined error of *source.Error.
+func parseFile(name string, r io.Reader, key, dialect string) ([]field, error) {
+	var src any
+	if name == "-" {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		name, src = "<stdin>", data
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := source.ParseFileDialect(fset, file, key, dialect)
+	fields := make([]field, len(found))
+	for i, f := range found {
+		fields[i] = field{
+			Pos:     fset.Position(f.Pos).String(),
+			Type:    f.Type,
+			Field:   f.Name,
+			Value:   f.Value,
+			Name:    f.Tag.Name,
+			Options: f.Tag.Options,
+		}
+	}
+
+	return fields, err
+}
+
+// report writes the malformed tags joined in err, as JSON objects if
+// asJSON is set.
+func report(w io.Writer, err error, asJSON bool) error {
+	if !asJSON {
+		_, err := fmt.Fprintln(w, err)
+
+		return err
+	}
+
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	enc := json.NewEncoder(w)
+	for _, err := range errs {
+		var srcErr *source.Error
+		if !errors.As(err, &srcErr) {
+			continue
+		}
+		out := malformedTag{Pos: srcErr.Pos.String(), Field: srcErr.Field, Message: srcErr.Err.Error()}
+		errors.As(srcErr.Err, &out.Error)
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printTable prints fields aligned in columns, with options sorted by key.
+func printTable(w io.Writer, fields []field) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "POSITION\tFIELD\tNAME\tOPTIONS")
+	for _, f := range fields {
+		name := f.Field
+		if f.Type != "" {
+			name = f.Type + "." + name
+		}
+		var opts []string
+		for _, k := range slices.Sorted(maps.Keys(f.Options)) {
+			if v := f.Options[k]; v != "" {
+				opts = append(opts, k+"="+v)
+			} else {
+				opts = append(opts, k)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.Pos, name, f.Name, strings.Join(opts, " "))
+	}
+
+	return tw.Flush()
+}
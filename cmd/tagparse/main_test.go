@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talav/tagparser"
+)
+
+const src = "package a\n\ntype User struct {\n" +
+	"\tID   int    `json:\"id\" db:\"user_id,pk\"`\n" +
+	"\tName string `json:\"name,omitempty,max=5\"`\n" +
+	"\tBad  string `json:\"bad,=x\"`\n" +
+	"}\n"
+
+// writeFile writes the test source as a.go in a new directory.
+func writeFile(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "testdata"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "testdata", "b.go"), []byte(src), 0o600))
+
+	return dir
+}
+
+func TestRun_Table(t *testing.T) {
+	t.Chdir(writeFile(t))
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, run([]string{"-key", "json", "./..."}, nil, &stdout, &stderr))
+
+	assert.Equal(t, strings.Join([]string{
+		"POSITION   FIELD      NAME  OPTIONS",
+		"a.go:4:21  User.ID    id    ",
+		"a.go:5:21  User.Name  name  max=5 omitempty",
+		"",
+	}, "\n"), stdout.String())
+	assert.Equal(t, "a.go:6:25: Bad: json tag: empty key (at 5)\n", stderr.String())
+}
+
+func TestRun_JSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"-key", "db", "-dialect", "default", "-format", "json"}
+	require.NoError(t, run(args, strings.NewReader(src), &stdout, &stderr))
+
+	var got []field
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &got))
+	assert.Equal(t, []field{{
+		Pos:     "<stdin>:4:29",
+		Type:    "User",
+		Field:   "ID",
+		Value:   "user_id,pk",
+		Name:    "user_id",
+		Options: map[string]string{"pk": ""},
+	}}, got)
+	assert.Empty(t, stderr.String())
+}
+
+func TestRun_JSONErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"-key", "json", "-format", "json"}
+	require.NoError(t, run(args, strings.NewReader(src), &stdout, &stderr))
+
+	var got struct {
+		Pos, Field, Message string
+		Error               struct{ Code string }
+	}
+	require.NoError(t, json.Unmarshal(stderr.Bytes(), &got))
+	assert.Equal(t, "<stdin>:6:25", got.Pos)
+	assert.Equal(t, "Bad", got.Field)
+	assert.Equal(t, "json tag: empty key (at 5)", got.Message)
+	assert.Equal(t, "EmptyKey", got.Error.Code)
+	assert.Equal(t, 1, strings.Count(stderr.String(), "\n"))
+}
+
+func TestRun_Check(t *testing.T) {
+	dir := writeFile(t)
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"-key", "json", "-check", filepath.Join(dir, "a.go")}, nil, &stdout, &stderr)
+	require.ErrorIs(t, err, errMalformed)
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "empty key")
+
+	// Well-formed tags pass
+	stderr.Reset()
+	require.NoError(t, run([]string{"-key", "db", "-check", dir}, nil, &stdout, &stderr))
+	assert.Empty(t, stderr.String())
+}
+
+func TestRun_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	require.ErrorIs(t, run(nil, nil, &stdout, &stderr), errUsage)
+	require.ErrorIs(t, run([]string{"-key", "json", "-format", "xml"}, nil, &stdout, &stderr), errUsage)
+	require.ErrorIs(t, run([]string{"-key", "json", "-dialect", "nope"}, nil, &stdout, &stderr), tagparser.ErrUnknownDialect)
+	require.Error(t, run([]string{"-key", "json", "missing.go"}, nil, &stdout, &stderr))
+	require.Error(t, run([]string{"-key", "json"}, strings.NewReader("not go"), &stdout, &stderr))
+}
@@ -0,0 +1,5 @@
+// Package example holds accessors generated by tagparsergen from
+// validate.json, used to test the generator.
+package example
+
+//go:generate go run github.com/talav/tagparser/cmd/tagparsergen -schema validate.json -type ValidateOptions
@@ -0,0 +1,42 @@
+package example
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talav/tagparser"
+)
+
+func TestParseValidateOptions(t *testing.T) {
+	opts, err := ParseValidateOptions(`email,required,format=email,min_len=3,ratio=0.5,timeout=2s,trim`)
+	require.NoError(t, err)
+	assert.Equal(t, ValidateOptions{
+		Name:     "email",
+		Format:   "email",
+		MinLen:   3,
+		Ratio:    0.5,
+		Required: true,
+		Timeout:  2 * time.Second,
+		Trim:     true,
+	}, opts)
+
+	opts, err = ParseValidateOptions(`email,format=url`)
+	require.NoError(t, err)
+	assert.Equal(t, ValidateOptions{Name: "email", Format: "url", Timeout: 30 * time.Second}, opts)
+
+	opts, err = ParseValidateOptions("`,format=url,min_len=3`")
+	require.NoError(t, err)
+	assert.Equal(t, ValidateOptions{Format: "url", MinLen: 3, Timeout: 30 * time.Second}, opts)
+
+	opts, err = ParseValidateOptions(`email,format=url,minlen=3`)
+	require.NoError(t, err)
+	assert.Equal(t, ValidateOptions{Name: "email", Format: "url", MinLen: 3, Timeout: 30 * time.Second}, opts)
+
+	_, err = ParseValidateOptions(`email,format=utf16,min_len=x`)
+	var errs *tagparser.Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs.List, 2)
+}
@@ -0,0 +1,371 @@
+// Command tagparsergen generates typed accessors for a tag dialect described
+// by a tagparser.Schema.
+//
+// The schema is read from a JSON file holding a tagparser.Schema:
+//
+//	{
+//	    "withName": true,
+//	    "keys": {
+//	        "required": {"flag": true},
+//	        "min":      {"type": "int"},
+//	        "format":   {"type": "enum", "enum": ["email", "url"]}
+//	    }
+//	}
+//
+// and the generated file declares a struct with one field per key, the
+// schema itself and a function that validates a tag and decodes it:
+//
+//	//go:generate go run github.com/talav/tagparser/cmd/tagparsergen -schema validate.json -type ValidateOptions
+//
+// produces validateoptions_gen.go with
+//
+//	type ValidateOptions struct { Name string; Format string; Min int; Required bool }
+//	var ValidateOptionsSchema = tagparser.Schema{...}
+//	func ParseValidateOptions(tag string) (ValidateOptions, error)
+//
+// Flags and bool keys become bool fields, int keys int, float keys float64,
+// duration keys time.Duration and the others string. Keys absent from a tag
+// take their Default, as added by tagparser.Schema.ApplyDefaults, and leave
+// their field at the zero value if it is empty. The description of a key, if
+// any, becomes the comment of its field. If the schema has Migrations, the
+// keys they renamed are accepted too: the function first rewrites the tag
+// with the current keys, with a Parser using tagparser.WithSchemaMigration.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/talav/tagparser"
+)
+
+var errUsage = errors.New("usage: tagparsergen -schema file.json -type Name [-o file.go] [-pkg name]")
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "tagparsergen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("tagparsergen", flag.ContinueOnError)
+	schemaFile := fs.String("schema", "", "JSON file holding the tagparser.Schema")
+	typeName := fs.String("type", "", "name of the generated options type")
+	output := fs.String("o", "", "output file (default <type>_gen.go, lowercased)")
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "package of the generated file (default $GOPACKAGE)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *schemaFile == "" || *typeName == "" || *pkg == "" {
+		return errUsage
+	}
+
+	data, err := os.ReadFile(*schemaFile)
+	if err != nil {
+		return err
+	}
+	var schema tagparser.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return fmt.Errorf("%s: %w", *schemaFile, err)
+	}
+
+	src, err := generate(*pkg, *typeName, &schema)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		*output = strings.ToLower(*typeName) + "_gen.go"
+	}
+
+	return os.WriteFile(*output, src, 0o644) //nolint:gosec // generated source is world-readable
+}
+
+type genField struct {
+	Name string // Go field name
+	Key  string // Option key, empty for the tag name
+	Type string // Go type
+	Doc  string // Description of the key
+}
+
+type genData struct {
+	Package  string
+	Type     string
+	Schema   string // Go expression of the schema
+	Parser   string // Variable of the Parser rewriting old keys, empty without migrations
+	Fields   []genField
+	WithName bool // Whether the first item is a name
+	Defaults bool // Whether a key has a default, which imports strconv
+	Duration bool // Whether time is imported
+}
+
+// generate returns the formatted source of the accessors for schema.
+func generate(pkg, typeName string, schema *tagparser.Schema) ([]byte, error) {
+	if !isExported(typeName) {
+		return nil, fmt.Errorf("type name %q is not an exported identifier", typeName)
+	}
+
+	data := genData{Package: pkg, Type: typeName, Schema: schemaLiteral(schema), WithName: schema.WithName}
+	if schema.Migrations != nil {
+		data.Parser = lowerFirst(typeName) + "Parser"
+	}
+	seen := make(map[string]string)
+	if schema.WithName {
+		data.Fields = append(data.Fields, genField{Name: "Name", Type: "string"})
+		seen["Name"] = ""
+	}
+	for _, key := range slices.Sorted(maps.Keys(schema.Keys)) {
+		spec := schema.Keys[key]
+		field := genField{Name: fieldName(key), Key: key, Type: goType(spec), Doc: strings.Join(strings.Fields(spec.Description), " ")}
+		if field.Name == "" {
+			return nil, fmt.Errorf("key %q has no letters to name a field after", key)
+		}
+		if other, dup := seen[field.Name]; dup {
+			return nil, fmt.Errorf("keys %q and %q both map to field %s", other, key, field.Name)
+		}
+		seen[field.Name] = key
+		data.Fields = append(data.Fields, field)
+		data.Defaults = data.Defaults || spec.Default != ""
+		data.Duration = data.Duration || field.Type == "time.Duration"
+	}
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by tagparsergen; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- if .Defaults}}
+	"strconv"
+{{- end}}
+{{- if .Duration}}
+	"time"
+{{- end}}
+{{if or .Defaults .Duration}}
+{{end}}
+	"github.com/talav/tagparser"
+)
+
+// {{.Type}} holds the options of a tag.
+type {{.Type}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`" + `tagparser:"{{if .Key}}{{.Key}}{{else}},name{{end}}"` + "`" + `{{with .Doc}} // {{.}}{{end}}
+{{- end}}
+}
+
+// {{.Type}}Schema is the schema {{.Type}} is generated from.
+var {{.Type}}Schema = {{.Schema}}
+
+{{- with .Parser}}
+
+// {{.}} rewrites the keys renamed by the migrations of {{$.Type}}Schema.
+var {{.}} = tagparser.New(tagparser.WithSchemaMigration(&{{$.Type}}Schema))
+{{- end}}
+
+// Parse{{.Type}} validates tag against {{.Type}}Schema and decodes it{{if .Defaults}},
+// with the defaults of {{.Type}}Schema for the keys tag lacks{{end}}.
+{{- if .Parser}}
+// Keys renamed by the migrations of the schema are accepted: tag is first
+// rewritten with the current keys, which errors then refer to.
+{{- end}}
+// Errors are reported as by tagparser.Schema.Validate and tagparser.Decode.
+func Parse{{.Type}}(tag string) ({{.Type}}, error) {
+{{- with .Parser}}
+	tag, err := {{.}}.Minify(tag)
+	if err != nil {
+		return {{$.Type}}{}, err
+	}
+{{end}}
+	if err := {{.Type}}Schema.Validate(tag); err != nil {
+		return {{.Type}}{}, err
+	}
+{{- if .Defaults}}
+
+	// Absent keys are appended to tag with the value ApplyDefaults gives them
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		tag = unquoted
+	}
+	t, err := tagparser.Parse{{if .WithName}}WithName{{end}}(tag)
+	if err != nil {
+		return {{.Type}}{}, err
+	}
+	cst, err := tagparser.ParseCST{{if .WithName}}WithName{{end}}(tag)
+	if err != nil {
+		return {{.Type}}{}, err
+	}
+	for key, value := range {{.Type}}Schema.ApplyDefaults(*t).Options {
+		if _, ok := t.Options[key]; !ok {
+			if err := cst.Append(key, value); err != nil {
+				return {{.Type}}{}, err
+			}
+		}
+	}
+	tag = cst.Render()
+{{- end}}
+
+	return tagparser.Decode[{{.Type}}](tag)
+}
+`))
+
+// schemaLiteral returns schema as a Go composite literal.
+func schemaLiteral(schema *tagparser.Schema) string {
+	var b strings.Builder
+	b.WriteString("tagparser.Schema{\n")
+	if schema.WithName {
+		b.WriteString("WithName: true,\n")
+	}
+	if schema.AllowUnknown {
+		b.WriteString("AllowUnknown: true,\n")
+	}
+	if schema.Version != 0 {
+		fmt.Fprintf(&b, "Version: %d,\n", schema.Version)
+	}
+	if schema.Migrations != nil {
+		b.WriteString("Migrations: []tagparser.Migration{\n")
+		for _, m := range schema.Migrations {
+			fmt.Fprintf(&b, "{Version: %d, Renames: map[string]string{", m.Version)
+			for i, old := range slices.Sorted(maps.Keys(m.Renames)) {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				fmt.Fprintf(&b, "%s: %s", strconv.Quote(old), strconv.Quote(m.Renames[old]))
+			}
+			b.WriteString("}},\n")
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("Keys: map[string]tagparser.KeySpec{\n")
+	for _, key := range slices.Sorted(maps.Keys(schema.Keys)) {
+		spec := schema.Keys[key]
+		var parts []string
+		if spec.Required {
+			parts = append(parts, "Required: true")
+		}
+		if spec.Flag {
+			parts = append(parts, "Flag: true")
+		}
+		if spec.Type != tagparser.TypeString {
+			parts = append(parts, "Type: tagparser.Type"+upperFirst(spec.Type.String()))
+		}
+		if spec.Enum != nil {
+			parts = append(parts, "Enum: "+stringsLiteral(spec.Enum))
+		}
+		if spec.Default != "" {
+			parts = append(parts, "Default: "+strconv.Quote(spec.Default))
+		}
+		if spec.RequiredWith != nil {
+			parts = append(parts, "RequiredWith: "+stringsLiteral(spec.RequiredWith))
+		}
+		if spec.Conflicts != nil {
+			parts = append(parts, "Conflicts: "+stringsLiteral(spec.Conflicts))
+		}
+		if spec.AtMost != "" {
+			parts = append(parts, "AtMost: "+strconv.Quote(spec.AtMost))
+		}
+		if spec.Description != "" {
+			parts = append(parts, "Description: "+strconv.Quote(spec.Description))
+		}
+		if spec.Deprecated != "" {
+			parts = append(parts, "Deprecated: "+strconv.Quote(spec.Deprecated))
+		}
+		fmt.Fprintf(&b, "%s: {%s},\n", strconv.Quote(key), strings.Join(parts, ", "))
+	}
+	b.WriteString("},\n}")
+
+	return b.String()
+}
+
+// stringsLiteral returns values as a Go []string literal.
+func stringsLiteral(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+// goType returns the Go type of the field holding a key.
+func goType(spec tagparser.KeySpec) string {
+	if spec.Flag {
+		return "bool"
+	}
+
+	switch spec.Type {
+	case tagparser.TypeBool:
+		return "bool"
+	case tagparser.TypeInt:
+		return "int"
+	case tagparser.TypeFloat:
+		return "float64"
+	case tagparser.TypeDuration:
+		return "time.Duration"
+	case tagparser.TypeString, tagparser.TypeEnum:
+		return "string"
+	default:
+		return "string"
+	}
+}
+
+// fieldName turns a key such as "min_len" into an exported Go identifier
+// such as "MinLen".
+func fieldName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		b.WriteString(upperFirst(w))
+	}
+	name := b.String()
+	if name != "" && !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+
+	return name
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+
+	return string(r)
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+
+	return string(r)
+}
+
+func isExported(name string) bool {
+	r := []rune(name)
+
+	return len(r) > 0 && unicode.IsUpper(r[0]) && fieldName(name) == name
+}
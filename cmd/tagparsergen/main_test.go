@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talav/tagparser"
+)
+
+// TestGenerate_Example checks that the generated example is up to date; run
+// go generate ./... after changing the generator.
+func TestGenerate_Example(t *testing.T) {
+	dir := filepath.Join("internal", "example")
+	data, err := os.ReadFile(filepath.Join(dir, "validate.json"))
+	require.NoError(t, err)
+	var schema tagparser.Schema
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	want, err := os.ReadFile(filepath.Join(dir, "validateoptions_gen.go"))
+	require.NoError(t, err)
+
+	got, err := generate("example", "ValidateOptions", &schema)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(schemaFile, []byte(`{"keys": {"omitempty": {"flag": true}}}`), 0o600))
+	output := filepath.Join(dir, "out.go")
+
+	require.NoError(t, run([]string{"-schema", schemaFile, "-type", "JSONOptions", "-pkg", "p", "-o", output}))
+	src, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "Omitempty bool `tagparser:\"omitempty\"`")
+	assert.NotContains(t, string(src), `"time"`)
+	assert.NotContains(t, string(src), "ApplyDefaults")
+	assert.NotContains(t, string(src), "WithSchemaMigration")
+
+	require.ErrorIs(t, run([]string{"-schema", schemaFile, "-pkg", "p"}), errUsage)
+	require.Error(t, run([]string{"-schema", filepath.Join(dir, "missing.json"), "-type", "T", "-pkg", "p"}))
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	_, err := generate("p", "options", &tagparser.Schema{})
+	require.Error(t, err)
+
+	_, err = generate("p", "Options", &tagparser.Schema{Keys: map[string]tagparser.KeySpec{"min-len": {}, "min_len": {}}})
+	require.EqualError(t, err, `keys "min-len" and "min_len" both map to field MinLen`)
+
+	_, err = generate("p", "Options", &tagparser.Schema{WithName: true, Keys: map[string]tagparser.KeySpec{"name": {}}})
+	require.Error(t, err)
+
+	_, err = generate("p", "Options", &tagparser.Schema{Keys: map[string]tagparser.KeySpec{"--": {}}})
+	require.Error(t, err)
+}
+
+func TestFieldName(t *testing.T) {
+	tests := map[string]string{
+		"omitempty": "Omitempty",
+		"min_len":   "MinLen",
+		"max-len":   "MaxLen",
+		"a.b.c":     "ABC",
+		"2fa":       "X2fa",
+	}
+	for key, want := range tests {
+		assert.Equal(t, want, fieldName(key), key)
+	}
+}
@@ -0,0 +1,429 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrNameItem is returned when an edit of a CST that only applies to options
+// targets the name.
+var ErrNameItem = errors.New("not valid for the name")
+
+// CST is a concrete syntax tree of a tag: its items with the exact text they
+// were written with, whitespace, quotes and escapes included, so that
+// formatters can change only what they must. Render reproduces the tag
+// byte for byte:
+//
+//	cst, _ := tagparser.ParseCST(`min = 5, msg='a, b'`)
+//	// cst.Items[1].Value == "a, b", cst.Items[1].RawValue == "'a, b'"
+//	// cst.Render() == `min = 5, msg='a, b'`
+//
+// Edits through its methods write new text with the syntax of the parser
+// that built the tree and leave the other items untouched.
+type CST struct {
+	// Items in order, one more than there are separators. Empty items, as
+	// in `a,,b`, have empty keys and values.
+	Items []CSTItem
+
+	p        *Parser
+	withName bool
+}
+
+// CSTItem is an item of a CST. Its text is Leading, RawKey, Assign,
+// RawValue and Trailing in that order. The name, like an empty item, has an
+// empty Key and RawKey.
+type CSTItem struct {
+	Key      string // Decoded key
+	Value    string // Decoded value, or the name
+	Leading  string // Whitespace before the item
+	RawKey   string // Key as written
+	Assign   string // Key/value separator with the whitespace around it, empty for flags and the name
+	RawValue string // Value or name as written, with quotes and escapes
+	Trailing string // Whitespace after the item
+	Alt      bool   // The item follows a '|' rather than the separator, see WithAlternatives
+}
+
+// ParseCST parses a tag treating all items as options and returns its
+// concrete syntax tree.
+func ParseCST(tag string) (*CST, error) {
+	return defaultParser.ParseCST(tag)
+}
+
+// ParseCSTWithName is like ParseCST but treats the first item as a name.
+func ParseCSTWithName(tag string) (*CST, error) {
+	return defaultParser.ParseCSTWithName(tag)
+}
+
+// ParseCST returns the concrete syntax tree of a tag with the syntax of p,
+// like the package-level ParseCST. Malformed tags are rejected even if p is
+// lenient, since their items cannot be told apart.
+func (p *Parser) ParseCST(tag string) (*CST, error) {
+	return p.parseCST(tag, false)
+}
+
+// ParseCSTWithName is like ParseCST but treats the first item as a name.
+func (p *Parser) ParseCSTWithName(tag string) (*CST, error) {
+	return p.parseCST(tag, true)
+}
+
+func (p *Parser) parseCST(tag string, withName bool) (*CST, error) {
+	cfg := p.syntax()
+	ps := parser{cfg: &cfg, tag: tag, treatFirstAsName: withName}
+	if err := ps.checkLength(); err != nil {
+		return nil, err
+	}
+
+	cst := &CST{p: p, withName: withName}
+	pos := 0        // start of the next item
+	pending := true // an item starts at pos
+	alt := false    // the item at pos follows '|'
+	for {
+		key, value, ok, err := ps.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		// Empty items skipped by the scanner
+		for {
+			i := strings.IndexFunc(tag[pos:ps.itemStart], p.isSepRune)
+			if i < 0 {
+				break
+			}
+			cst.Items = append(cst.Items, CSTItem{Leading: tag[pos : pos+i], Alt: alt})
+			pos += i
+			alt = tag[pos] == altSep && p.alternatives
+			pos++
+		}
+
+		it := ps.cstItem(key, value)
+		it.Alt = alt
+		cst.Items = append(cst.Items, it)
+		pos, pending = ps.pos, ps.pos < len(tag)
+		if pending {
+			alt = tag[pos] == altSep && p.alternatives
+			pos++
+		}
+	}
+	for pending {
+		i := strings.IndexFunc(tag[pos:], p.isSepRune)
+		if i < 0 {
+			cst.Items = append(cst.Items, CSTItem{Leading: tag[pos:], Alt: alt})
+
+			break
+		}
+		cst.Items = append(cst.Items, CSTItem{Leading: tag[pos : pos+i], Alt: alt})
+		pos += i
+		alt = tag[pos] == altSep && p.alternatives
+		pos++
+	}
+
+	return cst, nil
+}
+
+// cstItem describes the item last returned by next.
+func (p *parser) cstItem(key, value string) CSTItem {
+	it := p.item(key, value)
+	c := CSTItem{Key: key, Value: value, RawKey: it.RawKey, RawValue: it.RawValue}
+
+	start, end := it.ValuePos, it.ValuePos+len(it.RawValue)
+	switch {
+	case key == "":
+	case p.inValue:
+		start = it.KeyPos
+		c.Assign = p.tag[it.KeyPos+len(it.RawKey) : it.ValuePos]
+	default:
+		start, end = it.KeyPos, it.KeyPos+len(it.RawKey)
+	}
+	c.Leading = p.tag[p.itemStart:start]
+	c.Trailing = p.tag[end:p.pos]
+
+	return c
+}
+
+// Render returns the tag the tree describes.
+func (c *CST) Render() string {
+	var b strings.Builder
+	for i := range c.Items {
+		switch {
+		case i == 0:
+		case c.Items[i].Alt:
+			b.WriteByte(altSep)
+		default:
+			b.WriteByte(c.p.sep)
+		}
+		c.Items[i].render(&b)
+	}
+
+	return b.String()
+}
+
+// String returns the text of the item as written.
+func (it CSTItem) String() string {
+	var b strings.Builder
+	it.render(&b)
+
+	return b.String()
+}
+
+func (it *CSTItem) render(b *strings.Builder) {
+	b.WriteString(it.Leading)
+	b.WriteString(it.RawKey)
+	b.WriteString(it.Assign)
+	b.WriteString(it.RawValue)
+	b.WriteString(it.Trailing)
+}
+
+// Index returns the index of the option with the given key, or -1. The name
+// is never matched.
+func (c *CST) Index(key string) int {
+	if c.p.foldKeys {
+		key = strings.ToLower(key)
+	}
+	for i := range c.Items {
+		if c.Items[i].Key == key && key != "" && !c.isName(i) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// SetKey replaces the key of item i, written with the syntax of the tree,
+// and keeps the rest of the item as it is.
+func (c *CST) SetKey(i int, key string) error {
+	if c.isName(i) {
+		return fmt.Errorf("key of item %d: %w", i, ErrNameItem)
+	}
+	old := c.Items[i]
+	if err := c.setKey(i, key); err != nil {
+		return err
+	}
+	if err := c.unlikeGoLiteral(); err != nil {
+		c.Items[i] = old
+
+		return err
+	}
+
+	return nil
+}
+
+// setKey is SetKey without the checks of the edited tree.
+func (c *CST) setKey(i int, key string) error {
+	raw, err := c.p.quoteItem(key, true, nil)
+	if err != nil {
+		return err
+	}
+	if c.p.foldKeys {
+		key = strings.ToLower(key)
+	}
+	c.Items[i].Key, c.Items[i].RawKey = key, raw
+
+	return nil
+}
+
+// SetValue replaces the value of item i, or the name, written with the
+// syntax of the tree. An empty value turns an option into a flag, and a flag
+// is given the key/value separator of the other options. In a tree with a
+// name, an option written first cannot become a flag, which would be read
+// as the name.
+func (c *CST) SetValue(i int, value string) error {
+	if c.withName && i == 0 && value == "" && !c.isName(i) {
+		return fmt.Errorf("value of item %d: %w", i, ErrNameItem)
+	}
+	old := c.Items[i]
+	if err := c.setValue(i, value); err != nil {
+		return err
+	}
+	if err := c.unlikeGoLiteral(); err != nil {
+		c.Items[i] = old
+
+		return err
+	}
+
+	return nil
+}
+
+// setValue is SetValue without the checks of the edited tree.
+func (c *CST) setValue(i int, value string) error {
+	it := &c.Items[i]
+	if c.isName(i) {
+		raw, err := c.p.quoteItem(value, true, nil)
+		if err != nil {
+			return err
+		}
+		it.Value, it.RawValue = value, raw
+
+		return nil
+	}
+
+	if c.isGroup(it) {
+		// A group becomes an ordinary option
+		it.Assign = ""
+		it.Trailing = it.Trailing[strings.IndexByte(it.Trailing, ')')+1:]
+	}
+	if value == "" {
+		it.Value, it.Assign, it.RawValue = "", "", ""
+
+		return nil
+	}
+	if c.p.kvSep == 0 {
+		return fmt.Errorf("value of %q: %w in a dialect without values", it.Key, ErrNotRepresentable)
+	}
+	raw, err := c.p.quoteItem(value, false, nil)
+	if err != nil {
+		return err
+	}
+	if it.Assign == "" {
+		_, it.Assign = c.style()
+	}
+	it.Value, it.RawValue = value, raw
+
+	return nil
+}
+
+// Insert inserts an option before item i, with the spacing of the other
+// options, so that `a, b` becomes `a, c, b`. The value may be empty for a
+// flag. Nothing can be inserted before the name, nor a flag first in a tree
+// with a name, since it would be read as the name.
+func (c *CST) Insert(i int, key, value string) error {
+	if c.isName(i) || c.withName && i == 0 && value == "" {
+		return fmt.Errorf("insert before item %d: %w", i, ErrNameItem)
+	}
+
+	leading, assign := c.style()
+	it := CSTItem{Leading: leading, Assign: assign}
+	tmp := CST{Items: []CSTItem{it}, p: c.p}
+	if err := tmp.setKey(0, key); err != nil {
+		return err
+	}
+	if err := tmp.setValue(0, value); err != nil {
+		return err
+	}
+
+	it = tmp.Items[0]
+	if i == 0 && len(c.Items) > 0 {
+		// The new first item takes the whitespace before the tag
+		it.Leading, c.Items[0].Leading = c.Items[0].Leading, leading
+	}
+	c.Items = slices.Insert(c.Items, i, it)
+	if err := c.unlikeGoLiteral(); err != nil {
+		c.Items = slices.Delete(c.Items, i, i+1)
+		if i == 0 && len(c.Items) > 0 {
+			c.Items[0].Leading = it.Leading
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Append adds an option after the last item, in place of a trailing empty
+// item such as the one of `a,`.
+func (c *CST) Append(key, value string) error {
+	if last := len(c.Items) - 1; last >= 0 && !c.isName(last) && c.Items[last].String() == "" {
+		if err := c.Insert(last, key, value); err != nil {
+			return err
+		}
+		c.Items = c.Items[:last+1]
+
+		return nil
+	}
+
+	return c.Insert(len(c.Items), key, value)
+}
+
+// Delete removes item i and its separator. Deleting the name leaves it
+// empty, and the first item keeps the whitespace before the tag. In a tree
+// with a name, deleting the first option leaves an empty name in its place
+// if a flag would otherwise be read as the name.
+func (c *CST) Delete(i int) {
+	if c.isName(i) {
+		c.Items[i] = CSTItem{}
+
+		return
+	}
+
+	leading := c.Items[i].Leading
+	c.Items = slices.Delete(c.Items, i, i+1)
+	if i > 0 || len(c.Items) == 0 {
+		return
+	}
+	if c.withName && c.Items[0].RawKey != "" && c.Items[0].Assign == "" {
+		c.Items = slices.Insert(c.Items, 0, CSTItem{Leading: leading})
+
+		return
+	}
+	c.Items[0].Leading, c.Items[0].Alt = leading, false
+	// Without escapes, the first item cannot be written otherwise and the
+	// tag is left as it is
+	_ = c.unlikeGoLiteral()
+}
+
+// isName reports whether item i is the name. In a tree with a name, the
+// first item is the name unless it is an option, as in `min=1,max=2`.
+func (c *CST) isName(i int) bool {
+	return c.withName && i == 0 && len(c.Items) > 0 && c.Items[0].RawKey == ""
+}
+
+// unlikeGoLiteral rewrites the first item with escapes if the parser of the
+// tree would read the rendered tag as a Go string literal, like
+// Parser.unlikeGoLiteral. The tree is left unchanged if it cannot.
+func (c *CST) unlikeGoLiteral() error {
+	tag := c.Render()
+	if c.p.unquoteGo(tag) == tag {
+		return nil
+	}
+
+	first := c.Items[0]
+	raw, text := &first.RawKey, first.Key
+	if first.RawKey == "" {
+		raw, text = &first.RawValue, first.Value
+	}
+	escaped, err := c.p.writeItem(text, true, nil, true)
+	if err != nil {
+		return err
+	}
+	*raw = escaped
+	prev := c.Items[0]
+	c.Items[0] = first
+	if tag = c.Render(); c.p.unquoteGo(tag) != tag {
+		c.Items[0] = prev
+
+		return fmt.Errorf("%q: %w, read as a Go string literal", tag, ErrNotRepresentable)
+	}
+
+	return nil
+}
+
+// style returns the whitespace before the options of the tree and the
+// key/value separator they are written with, for new items.
+func (c *CST) style() (leading, assign string) {
+	leadingSet := false
+	for i, it := range c.Items {
+		if it.RawKey == "" {
+			continue
+		}
+		if i > 0 && !leadingSet {
+			leading, leadingSet = it.Leading, true
+		}
+		if assign == "" && !c.isGroup(&it) {
+			assign = it.Assign
+		}
+	}
+	if assign == "" && c.p.kvSep != 0 {
+		assign = string(rune(c.p.kvSep))
+	}
+
+	return leading, assign
+}
+
+// isGroup reports whether it is a group, see WithGroups.
+func (c *CST) isGroup(it *CSTItem) bool {
+	return c.p.groups && strings.IndexByte(it.Assign, '(') >= 0
+}
@@ -0,0 +1,146 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCST(t *testing.T) {
+	tag := `name , min = 5,,msg='a, b' ,k=,x\,y`
+	cst, err := ParseCSTWithName(tag)
+	require.NoError(t, err)
+	assert.Equal(t, []CSTItem{
+		{Value: "name", RawValue: "name", Trailing: " "},
+		{Key: "min", Value: "5", Leading: " ", RawKey: "min", Assign: " = ", RawValue: "5"},
+		{},
+		{Key: "msg", Value: "a, b", RawKey: "msg", Assign: "=", RawValue: "'a, b'", Trailing: " "},
+		{Key: "k", RawKey: "k", Assign: "="},
+		{Key: "x,y", RawKey: `x\,y`},
+	}, cst.Items)
+	assert.Equal(t, tag, cst.Render())
+	assert.Equal(t, " min = 5", cst.Items[1].String())
+}
+
+func TestParseCST_Render(t *testing.T) {
+	tests := []string{``, `,`, `a,`, `,a`, `a,,,b`, ` a , b `, `'q' = ' v '`}
+	for _, tag := range tests {
+		t.Run(tag, func(t *testing.T) {
+			cst, err := ParseCST(tag)
+			require.NoError(t, err)
+			assert.Equal(t, tag, cst.Render())
+		})
+	}
+
+	// Custom syntax
+	cst, err := gormParser.ParseCST(`column: id ;NOT NULL;`)
+	require.NoError(t, err)
+	assert.Len(t, cst.Items, 3)
+	assert.Equal(t, ": ", cst.Items[0].Assign)
+	assert.Equal(t, `column: id ;NOT NULL;`, cst.Render())
+}
+
+func TestParseCST_Errors(t *testing.T) {
+	_, err := New(WithLenient()).ParseCST(`a='b`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeUnterminatedQuote, parseErr.Code)
+}
+
+func TestCST_Rewrite(t *testing.T) {
+	// Add omitempty where missing
+	for tag, want := range map[string]string{
+		`json`:              `json,omitempty`,
+		`json, string`:      `json, string, omitempty`,
+		`json,omitempty`:    `json,omitempty`,
+		`json,`:             `json,omitempty`,
+		``:                  `,omitempty`,
+		`,min = 5 , string`: `,min = 5 , string,omitempty`,
+	} {
+		cst, err := ParseCSTWithName(tag)
+		require.NoError(t, err)
+		if cst.Index("omitempty") < 0 {
+			require.NoError(t, cst.Append("omitempty", ""))
+		}
+		assert.Equal(t, want, cst.Render(), tag)
+	}
+}
+
+func TestCST_Edit(t *testing.T) {
+	cst, err := ParseCSTWithName(` name , min = 5,  flag ,max='9'`)
+	require.NoError(t, err)
+
+	require.NoError(t, cst.SetValue(cst.Index("flag"), "a, b"))
+	require.NoError(t, cst.SetKey(cst.Index("min"), "lo,w"))
+	require.NoError(t, cst.SetValue(cst.Index("max"), ""))
+	require.NoError(t, cst.SetValue(0, "x=y"))
+	assert.Equal(t, ` x\=y , 'lo,w' = 5,  flag = 'a, b' ,max`, cst.Render())
+
+	require.NoError(t, cst.Insert(1, "first", "1"))
+	cst.Delete(cst.Index("max"))
+	assert.Equal(t, ` x\=y , first = 1, 'lo,w' = 5,  flag = 'a, b' `, cst.Render())
+
+	cst.Delete(0)
+	assert.Equal(t, `, first = 1, 'lo,w' = 5,  flag = 'a, b' `, cst.Render())
+
+	assert.ErrorIs(t, cst.SetKey(0, "k"), ErrNameItem)
+	assert.ErrorIs(t, cst.Insert(0, "k", ""), ErrNameItem)
+
+	// Edits are parsed back unchanged
+	tag, err := ParseWithName(cst.Render())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"first": "1", "lo,w": "5", "flag": "a, b"}, tag.Options)
+}
+
+func TestCST_EditFirstOption(t *testing.T) {
+	// Without a leading name, the first item is an option
+	cst, err := ParseCSTWithName(`min=1,max=2`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cst.Index("min"))
+	require.NoError(t, cst.SetKey(0, "lo"))
+	assert.Equal(t, `lo=1,max=2`, cst.Render())
+	assert.ErrorIs(t, cst.SetValue(0, ""), ErrNameItem)
+	assert.ErrorIs(t, cst.Insert(0, "k", ""), ErrNameItem)
+	require.NoError(t, cst.Insert(0, "k", "v"))
+	assert.Equal(t, `k=v,lo=1,max=2`, cst.Render())
+	cst.Delete(0)
+	cst.Delete(0)
+	assert.Equal(t, `max=2`, cst.Render())
+	cst.Delete(0)
+	require.NoError(t, cst.Append("k", "v"))
+	assert.Equal(t, `k=v`, cst.Render())
+
+	// A flag moving first would be read as the name
+	cst, err = ParseCSTWithName(`min=1, omitempty`)
+	require.NoError(t, err)
+	cst.Delete(0)
+	assert.Equal(t, `, omitempty`, cst.Render())
+	tag, err := ParseWithName(cst.Render())
+	require.NoError(t, err)
+	assert.Empty(t, tag.Name)
+	assert.Equal(t, M{"omitempty": ""}, tag.Options)
+}
+
+func TestCST_EditOptions(t *testing.T) {
+	cst, err := ParseCST(` a , b`)
+	require.NoError(t, err)
+	require.NoError(t, cst.Insert(0, "c", ""))
+	assert.Equal(t, ` c, a , b`, cst.Render())
+	cst.Delete(0)
+	cst.Delete(0)
+	assert.Equal(t, ` b`, cst.Render())
+
+	cst, err = ParseCST(`a`)
+	require.NoError(t, err)
+	require.NoError(t, cst.SetKey(0, " "))
+	assert.Equal(t, `\ `, cst.Render())
+	tag, err := Parse(cst.Render())
+	require.NoError(t, err)
+	assert.Equal(t, M{" ": ""}, tag.Options)
+
+	cst, err = jsonParser.ParseCST(`a`)
+	require.NoError(t, err)
+	assert.ErrorIs(t, cst.SetValue(0, "v"), ErrNotRepresentable)
+	assert.Equal(t, -1, cst.Index(""))
+}
@@ -0,0 +1,108 @@
+package tagparser
+
+// Item is an item of a tag with both its decoded and its source text, as
+// returned by ParseDetailed.
+type Item struct {
+	Key      string // Decoded key, empty for the name
+	Value    string // Decoded value, or the name
+	Raw      string // Whole item as written, between separators
+	Offset   int    // 0-based offset of Raw in the tag
+	RawKey   string // Key as written, without surrounding whitespace
+	RawValue string // Value or name as written, without surrounding whitespace, with quotes and escapes
+	KeyPos   int    // 0-based offset of RawKey, -1 for the name
+	ValuePos int    // 0-based offset of RawValue, -1 for flags
+	Quoted   bool   // Whether RawValue is enclosed in quotes
+}
+
+// ParseDetailed parses a tag treating all items as options, like ParseFunc,
+// and returns its items in order with the source text of their keys and
+// values, for tools that rewrite tags and need to know exactly what was
+// written:
+//
+//	items, _ := tagparser.ParseDetailed(`min=5, msg='a, b'`)
+//	// items[1].Value == "a, b", items[1].RawValue == "'a, b'",
+//	// items[1].Raw == " msg='a, b'", items[1].Quoted == true
+//
+// Raw includes the whitespace around the item, while RawKey and RawValue
+// do not unless whitespace is preserved. Empty items are skipped.
+func ParseDetailed(tag string) ([]Item, error) {
+	return defaultParser.ParseDetailed(tag)
+}
+
+// ParseDetailedWithName is like ParseDetailed but treats the first item as
+// a name, like ParseFuncWithName. The name is returned as an Item with an
+// empty Key.
+func ParseDetailedWithName(tag string) ([]Item, error) {
+	return defaultParser.ParseDetailedWithName(tag)
+}
+
+// ParseDetailed returns the items of a tag with their source text, like the
+// package-level ParseDetailed. A lenient Parser returns the well-formed items
+// along with the errors.
+func (p *Parser) ParseDetailed(tag string) ([]Item, error) {
+	return p.parseDetailed(tag, false)
+}
+
+// ParseDetailedWithName returns the items of a tag with their source text
+// treating the first item as a name, like the package-level
+// ParseDetailedWithName.
+func (p *Parser) ParseDetailedWithName(tag string) ([]Item, error) {
+	return p.parseDetailed(tag, true)
+}
+
+func (p *Parser) parseDetailed(tag string, withName bool) ([]Item, error) {
+	ps := parser{cfg: p, tag: tag, treatFirstAsName: withName}
+	var items []Item
+	err := ps.check(func(key, value string) *Error {
+		items = append(items, ps.item(key, value))
+
+		return nil
+	})
+	if err == nil {
+		err = ps.err()
+	}
+	if err != nil && !p.lenient {
+		return nil, err
+	}
+
+	return items, err
+}
+
+// item describes the item last returned by next.
+func (p *parser) item(key, value string) Item {
+	keyPos, valPos := p.positions(key)
+	it := Item{
+		Key:      key,
+		Value:    value,
+		Raw:      p.tag[p.itemStart:p.pos],
+		Offset:   p.itemStart,
+		KeyPos:   keyPos,
+		ValuePos: valPos,
+	}
+
+	switch {
+	case key == "":
+		it.RawValue = p.raw(p.start, p.pos)
+	case p.inValue:
+		it.RawKey = p.raw(p.keyStart, p.start-1)
+		it.RawValue = p.raw(p.start, p.valueEnd())
+	default:
+		it.RawKey = p.raw(p.start, p.pos)
+	}
+	it.Quoted = !p.cfg.literalQuotes && !p.group && len(it.RawValue) >= 2 &&
+		it.RawValue[0] == p.cfg.quote && it.RawValue[len(it.RawValue)-1] == p.cfg.quote
+
+	return it
+}
+
+// raw returns the source text between from and to without the whitespace
+// the parser trims.
+func (p *parser) raw(from, to int) string {
+	s := p.tag[from:to]
+	if p.cfg.preserveWhitespace {
+		return s
+	}
+	start, end := trimWhitespace(s, p.cfg.escape)
+
+	return s[start:end]
+}
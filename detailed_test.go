@@ -0,0 +1,54 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDetailed(t *testing.T) {
+	items, err := ParseDetailed(`min=5, msg='a, b' ,omitempty,,k\,ey = v\=al`)
+	require.NoError(t, err)
+	assert.Equal(t, []Item{
+		{Key: "min", Value: "5", Raw: "min=5", Offset: 0, RawKey: "min", RawValue: "5", KeyPos: 0, ValuePos: 4},
+		{
+			Key: "msg", Value: "a, b", Raw: " msg='a, b' ", Offset: 6,
+			RawKey: "msg", RawValue: "'a, b'", KeyPos: 7, ValuePos: 11, Quoted: true,
+		},
+		{Key: "omitempty", Raw: "omitempty", Offset: 19, RawKey: "omitempty", KeyPos: 19, ValuePos: -1},
+		{
+			Key: "k,ey", Value: "v=al", Raw: `k\,ey = v\=al`, Offset: 30,
+			RawKey: `k\,ey`, RawValue: `v\=al`, KeyPos: 30, ValuePos: 38,
+		},
+	}, items)
+
+	for _, it := range items {
+		assert.Equal(t, it.Raw, `min=5, msg='a, b' ,omitempty,,k\,ey = v\=al`[it.Offset:it.Offset+len(it.Raw)])
+	}
+}
+
+func TestParseDetailedWithName(t *testing.T) {
+	items, err := ParseDetailedWithName(` 'my name' ,!x`)
+	require.NoError(t, err)
+	assert.Equal(t, []Item{
+		{Value: "my name", Raw: " 'my name' ", RawValue: "'my name'", KeyPos: -1, ValuePos: 1, Quoted: true},
+		{Key: "!x", Raw: "!x", Offset: 12, RawKey: "!x", KeyPos: 12, ValuePos: -1},
+	}, items)
+
+	items, err = New(WithPreserveWhitespace()).ParseDetailedWithName(` a ,b= c`)
+	require.NoError(t, err)
+	assert.Equal(t, " a ", items[0].RawValue)
+	assert.Equal(t, " c", items[1].RawValue)
+}
+
+func TestParseDetailed_Errors(t *testing.T) {
+	_, err := ParseDetailed(`a=1,=2`)
+	require.Error(t, err)
+
+	items, err := New(WithLenient()).ParseDetailed(`a=1,=2,b`)
+	var errs *Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, items, 2)
+	assert.Equal(t, "b", items[1].Key)
+}
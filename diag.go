@@ -0,0 +1,191 @@
+package tagparser
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Severity ranks a Diagnostic.
+type Severity int
+
+// Severities of diagnostics, from the least to the most severe.
+const (
+	SeverityInfo    Severity = iota // Harmless but possibly unintended
+	SeverityWarning                 // Likely a mistake, or about to stop working
+)
+
+var severityNames = [...]string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+}
+
+// String returns the name of the severity, such as "warning".
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+
+	return severityNames[s]
+}
+
+// MarshalText encodes the severity as its name, such as "warning".
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// DiagnosticCode classifies a Diagnostic.
+type DiagnosticCode int
+
+// Diagnostic codes reported in Diagnostic.Code.
+const (
+	DiagDuplicateKey  DiagnosticCode = iota // Option overriding an earlier one with the same key
+	DiagEmptyValue                          // Key/value separator without a value, as in `k=`
+	DiagDeprecatedKey                       // Key marked deprecated with WithDeprecatedKey or WithSchemaMigration
+)
+
+var diagnosticCodeNames = [...]string{
+	DiagDuplicateKey:  "DuplicateKey",
+	DiagEmptyValue:    "EmptyValue",
+	DiagDeprecatedKey: "DeprecatedKey",
+}
+
+// String returns the name of the code, such as "DuplicateKey".
+func (c DiagnosticCode) String() string {
+	if c < 0 || int(c) >= len(diagnosticCodeNames) {
+		return fmt.Sprintf("DiagnosticCode(%d)", int(c))
+	}
+
+	return diagnosticCodeNames[c]
+}
+
+// MarshalText encodes the code as its name, such as "DuplicateKey".
+func (c DiagnosticCode) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// Diagnostic is a non-fatal finding about a tag that parsed successfully,
+// as reported by ParseDiag.
+type Diagnostic struct {
+	Severity Severity
+	Code     DiagnosticCode
+	Pos      int    // 0-based position of the key in the tag
+	Key      string // Key of the option
+	Msg      string // Description of the finding
+}
+
+// String returns the severity, message and 1-based position of d, as in
+// `warning: duplicate key "a" (at 5)`.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s (at %d)", d.Severity, d.Msg, d.Pos+1)
+}
+
+// diagnosticJSON is the JSON encoding of a Diagnostic.
+type diagnosticJSON struct {
+	Severity Severity       `json:"severity"`
+	Code     DiagnosticCode `json:"code"`
+	Pos      int            `json:"pos"`
+	Key      string         `json:"key"`
+	Message  string         `json:"message"`
+}
+
+// MarshalJSON encodes d as an object with the names of the severity and the
+// code, the 0-based position, the key and the message, like Error does:
+//
+//	{"severity": "warning", "code": "DuplicateKey", "pos": 16, "key": "min", "message": "..."}
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(diagnosticJSON{Severity: d.Severity, Code: d.Code, Pos: d.Pos, Key: d.Key, Message: d.Msg})
+}
+
+// ParseDiag parses a tag like Parse and also returns diagnostics for
+// conditions that do not fail the parse but may be mistakes: an option
+// overriding an earlier one with the same key and an option with a key
+// deprecated by WithDeprecatedKey or WithSchemaMigration (warnings), and an
+// option with a key/value separator but no value, such as `k=` (for
+// information). Diagnostics are ordered by position and are returned along
+// with the partial result of a lenient Parser.
+func ParseDiag(tag string) (*Tag, []Diagnostic, error) {
+	return defaultParser.ParseDiag(tag)
+}
+
+// ParseDiagWithName is like ParseDiag but treats the first item as a name,
+// like ParseWithName.
+func ParseDiagWithName(tag string) (*Tag, []Diagnostic, error) {
+	return defaultParser.ParseDiagWithName(tag)
+}
+
+// ParseDiag parses a tag and reports diagnostics, like the package-level
+// ParseDiag.
+func (p *Parser) ParseDiag(tag string) (*Tag, []Diagnostic, error) {
+	return p.parseDiag(tag, false)
+}
+
+// ParseDiagWithName parses a tag treating the first item as a name and
+// reports diagnostics, like the package-level ParseDiagWithName.
+func (p *Parser) ParseDiagWithName(tag string) (*Tag, []Diagnostic, error) {
+	return p.parseDiag(tag, true)
+}
+
+func (p *Parser) parseDiag(tag string, withName bool) (*Tag, []Diagnostic, error) {
+	tag = p.unquoteGo(tag)
+
+	result := &Tag{Options: make(map[string]string), listSep: p.listSep, groups: p.groupParser(), bools: p.bools}
+	var diags []Diagnostic
+	seen := make(map[string]int) // position of each key
+	ps := parser{cfg: p, tag: tag, treatFirstAsName: withName}
+	err := ps.parsePos(func(key, value string, keyPos, valPos int) error {
+		if key == "" {
+			result.Name = value
+
+			return nil
+		}
+
+		if old := ps.deprecatedKey; old != "" {
+			msg := fmt.Sprintf("%q is deprecated", old)
+			if version, ok := p.migrated[old]; ok {
+				msg += fmt.Sprintf(" since version %d", version)
+			}
+			if old != key {
+				msg += fmt.Sprintf(", use %q", key)
+			}
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     DiagDeprecatedKey,
+				Pos:      keyPos,
+				Key:      old,
+				Msg:      msg,
+			})
+		}
+		if prev, dup := seen[key]; dup {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     DiagDuplicateKey,
+				Pos:      keyPos,
+				Key:      key,
+				Msg:      fmt.Sprintf("duplicate key %q overrides the option at %d", key, prev+1),
+			})
+		}
+		if valPos >= 0 && value == "" {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityInfo,
+				Code:     DiagEmptyValue,
+				Pos:      keyPos,
+				Key:      key,
+				Msg:      fmt.Sprintf("empty value for %q, same as a flag", key),
+			})
+		}
+		seen[key] = keyPos
+		result.setOption(key, value, valPos >= 0)
+		if ps.escapedSeps != nil {
+			result.setList(key, value, ps.escapedSeps)
+		}
+
+		return nil
+	})
+
+	parsed, err := p.result(result, err)
+	if parsed == nil {
+		return nil, nil, err
+	}
+
+	return parsed, diags, err
+}
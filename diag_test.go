@@ -0,0 +1,88 @@
+package tagparser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDiag(t *testing.T) {
+	tag, diags, err := ParseDiagWithName(`name,min=1,max=,min=2`)
+	require.NoError(t, err)
+	assert.Equal(t, "name", tag.Name)
+	assert.Equal(t, M{"min": "2", "max": ""}, tag.Options)
+	_, hasValue, _ := tag.Lookup("max")
+	assert.True(t, hasValue)
+	assert.Equal(t, []Diagnostic{
+		{Severity: SeverityInfo, Code: DiagEmptyValue, Pos: 11, Key: "max", Msg: `empty value for "max", same as a flag`},
+		{Severity: SeverityWarning, Code: DiagDuplicateKey, Pos: 16, Key: "min", Msg: `duplicate key "min" overrides the option at 6`},
+	}, diags)
+	assert.Equal(t, `warning: duplicate key "min" overrides the option at 6 (at 17)`, diags[1].String())
+
+	// Clean tags have no diagnostics
+	_, diags, err = ParseDiag(`"a,b=1"`)
+	require.NoError(t, err)
+	assert.Empty(t, diags)
+
+	// Errors discard the diagnostics unless the parser is lenient
+	tag, diags, err = ParseDiag(`a,a,=x`)
+	require.Error(t, err)
+	assert.Nil(t, tag)
+	assert.Nil(t, diags)
+
+	tag, diags, err = New(WithLenient()).ParseDiag(`a,a,=x`)
+	require.Error(t, err)
+	assert.Equal(t, M{"a": ""}, tag.Options)
+	assert.Len(t, diags, 1)
+}
+
+func TestSeverity_String(t *testing.T) {
+	assert.Equal(t, "info", SeverityInfo.String())
+	assert.Equal(t, "Severity(9)", Severity(9).String())
+	assert.Equal(t, "EmptyValue", DiagEmptyValue.String())
+	assert.Equal(t, "DiagnosticCode(-1)", DiagnosticCode(-1).String())
+}
+
+func TestParseDiag_DeprecatedKey(t *testing.T) {
+	p := New(
+		WithDeprecatedKey("colunm", "column"),
+		WithDeprecatedKey("old", ""),
+		WithKnownKeys("column", "old"),
+		WithCaseInsensitiveKeys(),
+	)
+
+	tag, diags, err := p.ParseDiagWithName(`id,COLUNM=x,old`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"column": "x", "old": ""}, tag.Options)
+	assert.Equal(t, []Diagnostic{
+		{Severity: SeverityWarning, Code: DiagDeprecatedKey, Pos: 3, Key: "colunm", Msg: `"colunm" is deprecated, use "column"`},
+		{Severity: SeverityWarning, Code: DiagDeprecatedKey, Pos: 12, Key: "old", Msg: `"old" is deprecated`},
+	}, diags)
+
+	// Parse renames silently
+	tag, err = p.Parse(`colunm=y`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"column": "y"}, tag.Options)
+
+	// The replacement overriding the deprecated key is a duplicate
+	_, diags, err = p.ParseDiag(`colunm=x,column=y`)
+	require.NoError(t, err)
+	require.Len(t, diags, 2)
+	assert.Equal(t, DiagDuplicateKey, diags[1].Code)
+}
+
+func TestDiagnostic_MarshalJSON(t *testing.T) {
+	_, diags, err := ParseDiag(`min=1,min=2`)
+	require.NoError(t, err)
+	b, err := json.Marshal(diags)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"severity": "warning",
+		"code": "DuplicateKey",
+		"pos": 6,
+		"key": "min",
+		"message": "duplicate key \"min\" overrides the option at 1"
+	}]`, string(b))
+}
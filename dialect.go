@@ -0,0 +1,229 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownDialect is returned by ParseDialect for names that are not
+// registered.
+var ErrUnknownDialect = errors.New("unknown dialect")
+
+// A Dialect describes a tag syntax, so that tags of any registered dialect
+// can be parsed by name with ParseDialect.
+type Dialect interface {
+	// Parser returns the parser implementing the syntax of the dialect: its
+	// separators, key/value delimiter, quote and escape rules.
+	Parser() *Parser
+
+	// HasName reports whether the first item of a tag is a name.
+	HasName() bool
+
+	// Finish post-processes tag, parsed from raw, before it is returned.
+	// It can normalize the tag or reject it with an error.
+	Finish(raw string, tag *Tag) error
+}
+
+// DialectSpec is a Dialect assembled from its parts.
+type DialectSpec struct {
+	Syntax      *Parser                          // Parser for the syntax, nil for the default syntax
+	Name        bool                             // Whether the first item is a name
+	PostProcess func(raw string, tag *Tag) error // Optional post-processing, see Dialect.Finish
+}
+
+// Parser returns the Syntax parser, or a parser with the default syntax.
+func (d DialectSpec) Parser() *Parser {
+	if d.Syntax == nil {
+		return defaultParser
+	}
+
+	return d.Syntax
+}
+
+// HasName returns d.Name.
+func (d DialectSpec) HasName() bool {
+	return d.Name
+}
+
+// Finish calls PostProcess, if set.
+func (d DialectSpec) Finish(raw string, tag *Tag) error {
+	if d.PostProcess == nil {
+		return nil
+	}
+
+	return d.PostProcess(raw, tag)
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{
+		"default":      DialectSpec{Name: true},
+		"gorm":         DialectGorm,
+		"json":         DialectJSON,
+		"xml":          DialectXML,
+		"mapstructure": DialectMapstructure,
+		"env":          DialectEnv,
+		"http":         DialectHTTP,
+		"cookie":       DialectCookie,
+		"protobuf":     DialectProtobuf,
+		"validator":    DialectValidator,
+	}
+)
+
+// RegisterDialect makes a dialect available under name to ParseDialect.
+// The dialects default, gorm, json, xml, mapstructure, env, http, cookie,
+// protobuf and validator are registered by this package. RegisterDialect
+// panics if name is empty, d is nil or name is already registered, and is
+// typically called from an init function.
+func RegisterDialect(name string, d Dialect) {
+	if name == "" || d == nil {
+		panic("tagparser: RegisterDialect with empty name or nil dialect")
+	}
+
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	if _, dup := dialects[name]; dup {
+		panic("tagparser: RegisterDialect called twice for " + name)
+	}
+	dialects[name] = d
+}
+
+// LookupDialect returns the dialect registered under name.
+func LookupDialect(name string) (Dialect, bool) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	d, ok := dialects[name]
+
+	return d, ok
+}
+
+// Dialects returns the names of the registered dialects in sorted order.
+func Dialects() []string {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+
+	return slices.Sorted(maps.Keys(dialects))
+}
+
+// ParseDialect parses tag, the value of a struct tag key, with the dialect
+// registered under name and post-processes the result. Unlike Parse, it does
+// not unquote Go string literals. It returns an error wrapping
+// ErrUnknownDialect if no dialect has that name.
+func ParseDialect(name, tag string) (*Tag, error) {
+	d, ok := LookupDialect(name)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownDialect, name)
+	}
+
+	return parseDialect(d, tag)
+}
+
+// parseDialect parses tag with d.
+func parseDialect(d Dialect, tag string) (*Tag, error) {
+	p := d.Parser()
+	parsed, err := p.result(p.parseUnquoted(tag, d.HasName()))
+	if parsed == nil {
+		return nil, err
+	}
+	if finishErr := d.Finish(tag, parsed); finishErr != nil {
+		return nil, finishErr
+	}
+
+	return parsed, err
+}
+
+// finishJSON drops names encoding/json does not accept, see ParseJSON.
+func finishJSON(_ string, tag *Tag) error {
+	if !isValidJSONName(tag.Name) {
+		tag.Name = ""
+	}
+
+	return nil
+}
+
+// finishXML rejects the tags ParseXML rejects.
+func finishXML(raw string, _ *Tag) error {
+	_, err := ParseXML(raw)
+
+	return err
+}
+
+// finishEnv rejects the tags ParseEnv rejects.
+func finishEnv(raw string, _ *Tag) error {
+	_, err := ParseEnv(raw)
+
+	return err
+}
+
+// finishHTTP rejects the header values ParseHTTPParams rejects.
+func finishHTTP(raw string, _ *Tag) error {
+	_, err := ParseHTTPParams(raw)
+
+	return err
+}
+
+// finishHTTPList rejects the header values ParseHTTPDirectives rejects.
+func finishHTTPList(raw string, _ *Tag) error {
+	_, err := ParseHTTPDirectives(raw)
+
+	return err
+}
+
+// finishProtobuf rejects the tags ParseProtobuf rejects and keeps the
+// commas of the def option, which holds the rest of the tag.
+func finishProtobuf(raw string, tag *Tag) error {
+	if _, err := ParseProtobuf(raw); err != nil {
+		return err
+	}
+	i := strings.Index(raw, ",def=")
+	if i < 0 {
+		return nil
+	}
+	head, err := parseDialect(DialectSpec{Syntax: protobufParser}, raw[:i])
+	if err != nil {
+		return err
+	}
+	head.Set("def", raw[i+len(",def="):])
+	*tag = *head
+
+	return nil
+}
+
+// finishValidator rejects the tags ParseValidator rejects.
+func finishValidator(raw string, _ *Tag) error {
+	_, err := ParseValidator(raw)
+
+	return err
+}
+
+// DialectGorm is the dialect of gorm struct tags such as
+// `column:id;primaryKey;check:age>13`: items are separated by semicolons,
+// keys from values by colons and keys are case-insensitive, reported in
+// lower case. Quotes are ordinary characters, so constraints such as
+// `check:name <> 'admin'` are kept intact. Unlike gorm itself, its parser
+// trims whitespace around values and processes backslash escapes.
+var DialectGorm = DialectSpec{Syntax: gormParser}
+
+// gormParser is the parser of DialectGorm.
+var gormParser = New(
+	WithSeparator(';'),
+	WithKeyValueSeparator(':'),
+	WithCaseInsensitiveKeys(),
+	WithLiteralQuotes(),
+)
+
+// dialectError reports a problem at pos in a tag of a dialect.
+func dialectError(tag string, pos int, code ErrorCode, msg string) *Error {
+	return (&Error{
+		Tag:     tag,
+		Pos:     pos,
+		Msg:     msg,
+		Segment: tag,
+		Len:     len(tag),
+		Code:    code,
+	}).locate()
+}
@@ -0,0 +1,99 @@
+package tagparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DialectCookie is the dialect of cookie attribute strings such as
+// `Path=/; Secure; HttpOnly; Max-Age=3600` (RFC 6265): items are separated
+// by semicolons, attribute names are case-insensitive, reported in lower
+// case, and values such as `Expires=Wed, 21 Oct 2015 07:28:00 GMT` are
+// taken literally, without quotes or escapes. Use ParseSetCookie for a
+// whole Set-Cookie header value.
+var DialectCookie = DialectSpec{Syntax: cookieParser}
+
+// cookieParser is the parser of DialectCookie.
+var cookieParser = New(
+	WithSeparator(';'),
+	WithCaseInsensitiveKeys(),
+	WithLiteralQuotes(),
+	WithNoEscapes(),
+)
+
+// cookieSyntax is cookieParser with case-sensitive keys, for the cookie
+// name of a Set-Cookie header value.
+var cookieSyntax = New(
+	WithSeparator(';'),
+	WithLiteralQuotes(),
+	WithNoEscapes(),
+)
+
+// ParseSetCookie parses a Set-Cookie header value into the name and value
+// of the cookie and its attributes:
+//
+//	name, value, attrs, err := tagparser.ParseSetCookie(`id=a3fWa; Path=/; Secure; Max-Age=3600`)
+//	// name == "id", value == "a3fWa"
+//	// attrs.Options == map[string]string{"path": "/", "secure": "", "max-age": "3600"}
+//
+// The value loses its surrounding double quotes, if any. Attributes are
+// parsed as by DialectCookie, a repeated attribute keeping its last value.
+// A first item without an equals sign is reported with CodeMissingValue,
+// a name that is not a token with CodeInvalidRule and a value with
+// characters RFC 6265 does not allow, other than spaces and commas, with
+// CodeInvalidValue.
+func ParseSetCookie(header string) (name, value string, attrs *Tag, err error) {
+	attrs = &Tag{Options: make(map[string]string)}
+	var cookieErr error // first invalid item
+	first := true
+	ps := parser{cfg: cookieSyntax, tag: header}
+	err = ps.parsePos(func(key, v string, keyPos, valPos int) error {
+		if !first {
+			attrs.setOption(strings.ToLower(key), v, valPos >= 0)
+
+			return nil
+		}
+		first = false
+		name, value = key, v
+		if cookieErr == nil {
+			cookieErr = checkCookie(header, key, v, keyPos, valPos)
+		}
+
+		return nil
+	})
+	switch {
+	case err != nil:
+		return "", "", nil, err
+	case first:
+		return "", "", nil, dialectError(header, 0, CodeMissingName, "missing cookie name")
+	case cookieErr != nil:
+		return "", "", nil, cookieErr
+	}
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = value[1 : len(value)-1]
+	}
+
+	return name, value, attrs, nil
+}
+
+// checkCookie checks the name and value of a cookie.
+func checkCookie(raw, name, value string, keyPos, valPos int) error {
+	switch {
+	case valPos < 0:
+		return dialectError(raw, keyPos, CodeMissingValue, fmt.Sprintf("%s for cookie %q", errMissingValue, name))
+	case !isToken(name):
+		return dialectError(raw, keyPos, CodeInvalidRule, fmt.Sprintf("invalid cookie name %q", name))
+	}
+
+	inner := value
+	if len(inner) >= 2 && inner[0] == '"' && inner[len(inner)-1] == '"' {
+		inner = inner[1 : len(inner)-1]
+	}
+	for i := range len(inner) {
+		if c := inner[i]; c < ' ' || c > '~' || c == '"' || c == '\\' {
+			return dialectError(raw, valPos, CodeInvalidValue, fmt.Sprintf("%s %q for cookie %q", errInvalidValue, value, name))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package tagparser
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// DialectEnv is the dialect of env struct tags such as `PORT,required`,
+// with the same literal syntax as DialectJSON. It rejects the tags ParseEnv
+// rejects. Use ParseEnv or LookupEnv for a typed result.
+var DialectEnv = DialectSpec{Syntax: envParser, Name: true, PostProcess: finishEnv}
+
+// envParser is the parser of DialectEnv.
+var envParser = New(withStdlibSyntax())
+
+// EnvTag holds the environment variable binding of a field, as read by
+// github.com/caarlos0/env from the env and envDefault struct tags.
+type EnvTag struct {
+	VarName    string // Name of the variable, empty to derive it from the field name
+	Required   bool   // The variable must be set
+	NotEmpty   bool   // The variable must not be empty
+	File       bool   // The variable holds the path of a file to read the value from
+	Unset      bool   // The variable is unset after being read
+	Expand     bool   // References to other variables are expanded
+	Init       bool   // Nil pointers are initialized even without a value
+	Default    string // Value used when the variable is unset, from envDefault
+	HasDefault bool   // Whether envDefault is present, possibly empty
+}
+
+// ParseEnv parses the value of an env tag such as `PORT,required,notEmpty`:
+// the variable name followed by the flags required, notEmpty, file, unset,
+// expand and init. Unknown flags are reported with CodeUnknownKey, as
+// caarlos0/env rejects them.
+func ParseEnv(tag string) (EnvTag, error) {
+	var t EnvTag
+	var flagErr *Error // first unknown flag
+	err := envParser.ParseFuncWithNamePos(tag, func(key, value string, keyPos, _ int) error {
+		switch key {
+		case "":
+			t.VarName = value
+		case "required":
+			t.Required = true
+		case "notEmpty":
+			t.NotEmpty = true
+		case "file":
+			t.File = true
+		case "unset":
+			t.Unset = true
+		case "expand":
+			t.Expand = true
+		case "init":
+			t.Init = true
+		default:
+			if flagErr == nil {
+				flagErr = dialectError(tag, keyPos, CodeUnknownKey, fmt.Sprintf("%s %q", errUnknownKey, key))
+			}
+		}
+
+		return nil
+	})
+	switch {
+	case err != nil:
+		return EnvTag{}, err
+	case flagErr != nil:
+		return EnvTag{}, flagErr
+	}
+
+	return t, nil
+}
+
+// LookupEnv reads the env and envDefault tags of a struct field into a
+// single EnvTag, as in
+//
+//	Port int `env:"PORT,required" envDefault:"8080"`
+//
+// ok is false if the field has no env tag. Errors are prefixed with the tag
+// key, like those of LookupChain.
+func LookupEnv(st reflect.StructTag) (t EnvTag, ok bool, err error) {
+	raw, ok := st.Lookup("env")
+	if !ok {
+		return EnvTag{}, false, nil
+	}
+	t, err = ParseEnv(raw)
+	if err != nil {
+		return EnvTag{}, true, fmt.Errorf("env tag: %w", err)
+	}
+	t.Default, t.HasDefault = st.Lookup("envDefault")
+
+	return t, true, nil
+}
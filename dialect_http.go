@@ -0,0 +1,135 @@
+package tagparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DialectHTTP is the dialect of the parameters of HTTP and MIME header
+// values, such as `text/html; charset="utf-8"` in Content-Type (RFC 9110,
+// RFC 2045): items are separated by semicolons, values can be quoted strings
+// in double quotes, in which a backslash escapes any character, and
+// parameter names are case-insensitive, reported in lower case. The first
+// item is the leading value, and the values ParseHTTPParams rejects are
+// rejected.
+var DialectHTTP = DialectSpec{Syntax: httpParser, Name: true, PostProcess: finishHTTP}
+
+// DialectHTTPList is the dialect of comma-separated HTTP directives, such
+// as `no-cache, max-age=0` in Cache-Control, with the syntax of DialectHTTP
+// otherwise. It rejects the values ParseHTTPDirectives rejects.
+var DialectHTTPList = DialectSpec{Syntax: httpListParser, PostProcess: finishHTTPList}
+
+var (
+	httpParser     = New(withHTTPSyntax(';')) // parser of DialectHTTP
+	httpListParser = New(withHTTPSyntax(',')) // parser of DialectHTTPList
+)
+
+// ParseHTTPParams parses a header value made of an optional leading value
+// and parameters, such as the media type and parameters of Content-Type or
+// the disposition and parameters of Content-Disposition:
+//
+//	tag, err := tagparser.ParseHTTPParams(`text/html; charset="utf-8"`)
+//	// tag.Name == "text/html", tag.Options == map[string]string{"charset": "utf-8"}
+//
+// Quoted strings are unquoted. Unlike with DialectHTTP, names must be
+// tokens and unquoted values tokens too, while the leading value may be a
+// pair of tokens separated by a slash; other characters are reported with
+// CodeInvalidRule for names and CodeInvalidValue otherwise. Parameters
+// without a value are reported with CodeMissingValue.
+func ParseHTTPParams(value string) (*Tag, error) {
+	return parseHTTP(httpParser, value, true)
+}
+
+// ParseHTTPDirectives parses a comma-separated list of directives such as
+// Cache-Control, each a token with an optional value:
+//
+//	tag, err := tagparser.ParseHTTPDirectives(`no-cache, max-age=0, private="set-cookie"`)
+//	// tag.Options == map[string]string{"no-cache": "", "max-age": "0", "private": "set-cookie"}
+//
+// Tokens are checked as by ParseHTTPParams.
+func ParseHTTPDirectives(value string) (*Tag, error) {
+	return parseHTTP(httpListParser, value, false)
+}
+
+// parseHTTP parses value with p and checks the tokens of its items. With
+// params, the first item may be a leading value, and the others must have
+// values.
+func parseHTTP(p *Parser, value string, params bool) (*Tag, error) {
+	t := &Tag{Options: make(map[string]string)}
+	var tokenErr error // first invalid token
+	ps := parser{cfg: p, tag: value, treatFirstAsName: params}
+	err := ps.parsePos(func(key, v string, keyPos, valPos int) error {
+		var err error
+		switch {
+		case key == "":
+			t.Name = v
+			err = checkHTTPLeading(value, v, valPos)
+		case params && valPos < 0:
+			err = dialectError(value, keyPos, CodeMissingValue, fmt.Sprintf("%s for %q", errMissingValue, key))
+		default:
+			t.setOption(key, v, valPos >= 0)
+			err = checkHTTPItem(value, key, v, keyPos, valPos)
+		}
+		if tokenErr == nil {
+			tokenErr = err
+		}
+
+		return nil
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case tokenErr != nil:
+		return nil, tokenErr
+	}
+
+	return t, nil
+}
+
+// checkHTTPLeading checks the leading value of parameters, such as a media
+// type.
+func checkHTTPLeading(raw, v string, pos int) error {
+	typ, sub, slash := strings.Cut(v, "/")
+	if isToken(typ) && (!slash || isToken(sub)) && raw[pos] != '"' {
+		return nil
+	}
+
+	return dialectError(raw, pos, CodeInvalidValue, fmt.Sprintf("invalid token %q", v))
+}
+
+// checkHTTPItem checks the name and value of an item.
+func checkHTTPItem(raw, key, v string, keyPos, valPos int) error {
+	if !isToken(key) || raw[keyPos] == '"' {
+		return dialectError(raw, keyPos, CodeInvalidRule, fmt.Sprintf("invalid token %q", key))
+	}
+	switch {
+	case valPos < 0:
+		return nil
+	case valPos < len(raw) && raw[valPos] == '"':
+		if strings.ContainsFunc(v, func(r rune) bool { return r < ' ' && r != '\t' || r == 0x7f }) {
+			return dialectError(raw, valPos, CodeInvalidValue, fmt.Sprintf("%s %q for %q", errInvalidValue, v, key))
+		}
+	case v == "":
+		return dialectError(raw, valPos, CodeMissingValue, fmt.Sprintf("%s for %q", errMissingValue, key))
+	case !isToken(v) || !strings.HasPrefix(raw[valPos:], v):
+		return dialectError(raw, valPos, CodeInvalidValue, fmt.Sprintf("invalid token %q for %q", v, key))
+	}
+
+	return nil
+}
+
+// isToken reports whether s is a token of RFC 9110: one or more visible
+// ASCII characters other than delimiters.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := range len(s) {
+		c := s[i]
+		if !isAlnum(c) && !strings.ContainsRune("!#$%&'*+-.^_`|~", rune(c)) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,78 @@
+package tagparser
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// DialectJSON is the dialect of json struct tags, with the syntax of
+// encoding/json: the first item is the name and the others are flags, all
+// taken literally. Equals signs, quotes, backslashes and whitespace are part
+// of the items, and tags have no length limit. Names encoding/json rejects
+// are dropped. Use ParseJSON to interpret a tag exactly like encoding/json.
+var DialectJSON = DialectSpec{Syntax: jsonParser, Name: true, PostProcess: finishJSON}
+
+// jsonParser is the parser of DialectJSON.
+var jsonParser = New(withStdlibSyntax())
+
+// JSONTag is a json struct tag as interpreted by encoding/json.
+type JSONTag struct {
+	Name  string   // Object key, empty to use the field name
+	Skip  bool     // The tag is "-": the field is ignored
+	Flags []string // Options such as "omitempty", in order
+}
+
+// ParseJSON interprets a json struct tag exactly like encoding/json, so that
+// serializers can stay compatible with it: a tag of "-" skips the field, a
+// name that encoding/json rejects as an object key is dropped in favor of
+// the field name, and the remaining items are flags. Like encoding/json,
+// ParseJSON never fails.
+//
+// Names containing quotes, backslashes or other reserved characters follow
+// the classic encoding/json rules; builds with the jsonv2 experiment treat
+// them differently.
+func ParseJSON(tag string) JSONTag {
+	if tag == "-" {
+		return JSONTag{Skip: true}
+	}
+
+	var t JSONTag
+	for key, value := range jsonParser.OptionsWithName(tag) {
+		if key == "" {
+			if isValidJSONName(value) {
+				t.Name = value
+			}
+
+			continue
+		}
+		t.Flags = append(t.Flags, key)
+	}
+
+	return t
+}
+
+// Has reports whether the tag has the flag, such as "omitempty".
+func (t JSONTag) Has(flag string) bool {
+	return slices.Contains(t.Flags, flag)
+}
+
+// isValidJSONName reports whether encoding/json accepts name as an object
+// key in a tag.
+func isValidJSONName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but
+			// otherwise any punctuation chars are allowed
+			// in a tag name.
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,45 @@
+package tagparser
+
+// DialectMapstructure is the dialect of mapstructure struct tags such as
+// `name,squash`, with the same literal syntax as DialectJSON. Use
+// ParseMapstructure for a typed result.
+var DialectMapstructure = DialectSpec{Syntax: mapstructureParser, Name: true}
+
+// mapstructureParser is the parser of DialectMapstructure.
+var mapstructureParser = New(withStdlibSyntax())
+
+// MapstructureTag is a mapstructure struct tag as interpreted by
+// github.com/mitchellh/mapstructure.
+type MapstructureTag struct {
+	Name      string // Map key, empty to use the field name
+	Skip      bool   // The name is "-": the field is ignored
+	Squash    bool   // Embed the fields of a struct into the parent
+	Remain    bool   // Collect the unused keys of the map
+	OmitEmpty bool   // Omit the zero value when encoding into a map
+}
+
+// ParseMapstructure interprets a mapstructure struct tag: the first item is
+// the name, or "-" to skip the field, and the flags squash, remain and
+// omitempty set the corresponding fields. Other flags are ignored, as by
+// mapstructure itself, and ParseMapstructure never fails.
+func ParseMapstructure(tag string) MapstructureTag {
+	var t MapstructureTag
+	for key, value := range mapstructureParser.OptionsWithName(tag) {
+		switch key {
+		case "":
+			t.Name = value
+			t.Skip = value == "-"
+		case "squash":
+			t.Squash = true
+		case "remain":
+			t.Remain = true
+		case "omitempty":
+			t.OmitEmpty = true
+		}
+	}
+	if t.Skip {
+		t.Name = ""
+	}
+
+	return t
+}
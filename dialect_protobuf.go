@@ -0,0 +1,106 @@
+package tagparser
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DialectProtobuf is the dialect of the protobuf struct tags of generated
+// code, such as `bytes,1,opt,name=theme,proto3`: comma-separated items taken
+// literally, with key=value pairs. It rejects the tags ParseProtobuf rejects
+// and keeps the commas of the def option. Use ParseProtobuf to read the
+// positional fields and the default value.
+var DialectProtobuf = DialectSpec{Syntax: protobufParser, PostProcess: finishProtobuf}
+
+// protobufParser is the parser of DialectProtobuf.
+var protobufParser = New(withStdlibSyntax(), WithKeyValueSeparator('='))
+
+// ProtobufTag is a protobuf struct tag.
+type ProtobufTag struct {
+	WireType    string            // Encoding, such as "varint" or "bytes"
+	Number      int               // Field number
+	Cardinality string            // "opt", "req" or "rep"
+	Options     map[string]string // Named options such as name=theme, and flags such as proto3 with an empty value
+}
+
+// protobufWireTypes are the encodings of protobuf struct tags.
+var protobufWireTypes = []string{"varint", "zigzag32", "zigzag64", "fixed32", "fixed64", "bytes", "group"}
+
+// ParseProtobuf parses a protobuf struct tag such as
+// `bytes,1,opt,name=theme,json=themeName,proto3`. The first three items are
+// the wire type, the field number and the cardinality; the others are named
+// options and flags. As in generated code, the def option holds the rest of
+// the tag, commas included, so `varint,2,opt,name=x,def=a,b` has the
+// default value "a,b".
+//
+// Missing positional items are reported with CodeMissingValue and invalid
+// ones with CodeInvalidValue.
+func ParseProtobuf(tag string) (ProtobufTag, error) {
+	t := ProtobufTag{Options: make(map[string]string)}
+
+	head := tag
+	if i := strings.Index(tag, ",def="); i >= 0 {
+		head = tag[:i]
+		t.Options["def"] = tag[i+len(",def="):]
+	}
+
+	item := 0
+	var itemErr *Error // first invalid positional item
+	err := protobufParser.ParseFuncPos(head, func(key, value string, keyPos, valPos int) error {
+		item++
+		if item > 3 {
+			t.Options[key] = value
+
+			return nil
+		}
+		if itemErr == nil && !t.setPositional(item, key, valPos) {
+			itemErr = dialectError(tag, keyPos, CodeInvalidValue, "invalid "+protobufItems[item-1])
+		}
+
+		return nil
+	})
+	switch {
+	case err != nil:
+		return ProtobufTag{}, err
+	case itemErr != nil:
+		return ProtobufTag{}, itemErr
+	case item < 3:
+		return ProtobufTag{}, dialectError(tag, len(head), CodeMissingValue, "missing "+protobufItems[item])
+	}
+
+	return t, nil
+}
+
+// Name returns the name option, the field name in the .proto file.
+func (t ProtobufTag) Name() string {
+	return t.Options["name"]
+}
+
+// protobufItems names the positional items of protobuf tags.
+var protobufItems = [...]string{"wire type", "field number", "cardinality"}
+
+// setPositional sets the positional item numbered from 1 and reports
+// whether it is valid. valPos is the position of its value, which
+// positional items cannot have.
+func (t *ProtobufTag) setPositional(item int, key string, valPos int) bool {
+	if valPos >= 0 {
+		return false
+	}
+
+	switch item {
+	case 1:
+		t.WireType = key
+
+		return slices.Contains(protobufWireTypes, key)
+	case 2:
+		n, err := strconv.Atoi(key)
+		t.Number = n
+
+		return err == nil && n > 0
+	default:
+		t.Cardinality = key
+
+		return key == "opt" || key == "req" || key == "rep"
+	}
+}
@@ -0,0 +1,514 @@
+package tagparser
+
+import (
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialectGorm(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want M
+	}{
+		{`column:id;primaryKey`, M{"column": "id", "primarykey": ""}},
+		{`type:varchar(100);NOT NULL;default:'n/a'`, M{"type": "varchar(100)", "not null": "", "default": "'n/a'"}},
+		{`check:age>13`, M{"check": "age>13"}},
+		{`check:name_checker,name <> 'jinzhu'`, M{"check": "name_checker,name <> 'jinzhu'"}},
+		{`index:idx_name,unique;uniqueIndex:,sort:desc`, M{"index": "idx_name,unique", "uniqueindex": ",sort:desc"}},
+		{`foreignKey:UserID;References:ID`, M{"foreignkey": "UserID", "references": "ID"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			tag, err := DialectGorm.Parser().Parse(tt.tag)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tag.Options)
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want JSONTag
+	}{
+		{``, JSONTag{}},
+		{`-`, JSONTag{Skip: true}},
+		{`-,`, JSONTag{Name: "-"}},
+		{`name`, JSONTag{Name: "name"}},
+		{`,omitempty`, JSONTag{Flags: []string{"omitempty"}}},
+		{`name,omitempty,string`, JSONTag{Name: "name", Flags: []string{"omitempty", "string"}}},
+		{`a=b, omitempty,,x='y'`, JSONTag{Name: "a=b", Flags: []string{" omitempty", "x='y'"}}},
+		{`it's,omitempty`, JSONTag{Flags: []string{"omitempty"}}},
+		{`a\b`, JSONTag{}},
+		{`naïve key`, JSONTag{Name: "naïve key"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseJSON(tt.tag))
+		})
+	}
+
+	assert.True(t, ParseJSON(`x,omitempty`).Has("omitempty"))
+	assert.False(t, ParseJSON(`x, omitempty`).Has("omitempty"))
+}
+
+// TestParseJSON_Stdlib checks ParseJSON against encoding/json by marshaling
+// a zero int field with each tag. Invalid names are left out since the
+// jsonv2 experiment treats them differently.
+func TestParseJSON_Stdlib(t *testing.T) {
+	tags := []string{``, `-`, `-,`, `name`, `,omitempty`, `name, omitempty`, `a=b,string`, `x y,omitempty,string`}
+	for _, tag := range tags {
+		t.Run(tag, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{
+				Name: "Field",
+				Type: reflect.TypeFor[int](),
+				Tag:  reflect.StructTag(`json:` + strconv.Quote(tag)),
+			}})
+			got, err := json.Marshal(reflect.New(typ).Elem().Interface())
+			require.NoError(t, err)
+
+			jt := ParseJSON(tag)
+			want := "{}"
+			if !jt.Skip && !jt.Has("omitempty") {
+				name := cmp.Or(jt.Name, "Field")
+				value := "0"
+				if jt.Has("string") {
+					value = `"0"`
+				}
+				want = fmt.Sprintf("{%q:%s}", name, value)
+			}
+			assert.Equal(t, want, string(got))
+		})
+	}
+}
+
+func TestParseXML(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want XMLTag
+	}{
+		{``, XMLTag{}},
+		{`-`, XMLTag{Skip: true}},
+		{`name`, XMLTag{Path: []string{"name"}}},
+		{`a>b>c,omitempty`, XMLTag{Path: []string{"a", "b", "c"}, Flags: []string{"omitempty"}}},
+		{`>b`, XMLTag{Path: []string{"", "b"}}},
+		{`id,attr,omitempty`, XMLTag{Path: []string{"id"}, Flags: []string{"attr", "omitempty"}}},
+		{`http://example.com/ns id,attr`, XMLTag{Namespace: "http://example.com/ns", Path: []string{"id"}, Flags: []string{"attr"}}},
+		{`,chardata`, XMLTag{Flags: []string{"chardata"}}},
+		{`,any,attr`, XMLTag{Flags: []string{"any", "attr"}}},
+		{`,innerxml,custom`, XMLTag{Flags: []string{"innerxml", "custom"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := ParseXML(tt.tag)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	xt, err := ParseXML(`a>b,omitempty`)
+	require.NoError(t, err)
+	assert.Equal(t, "b", xt.Name())
+	assert.True(t, xt.Has("omitempty"))
+	assert.Empty(t, XMLTag{}.Name())
+}
+
+func TestParseXML_Errors(t *testing.T) {
+	tests := []struct {
+		tag string
+		pos int
+		msg string
+	}{
+		{`a>`, 1, "trailing '>' in element path"},
+		{`ns a>b>,attr`, 6, "trailing '>' in element path"},
+		{`a>b,attr`, 0, "element path not valid with 'attr' flag"},
+		{`name,chardata`, 0, "name not valid with 'chardata' flag"},
+		{`,attr,chardata`, 6, "'chardata' flag not valid with 'attr'"},
+		{`,chardata,omitempty`, 1, "'omitempty' not valid with 'chardata' flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			_, err := ParseXML(tt.tag)
+			var parseErr *Error
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, CodeInvalidRule, parseErr.Code)
+			assert.Equal(t, tt.pos, parseErr.Pos)
+			assert.Equal(t, tt.msg, parseErr.Msg)
+		})
+	}
+}
+
+// TestParseXML_Stdlib checks that ParseXML rejects the same tags as
+// encoding/xml.
+func TestParseXML_Stdlib(t *testing.T) {
+	tags := []string{
+		``, `-`, `name`, `a>b>c,omitempty`, `>b`, `id,attr,omitempty`, `ns id,attr`, `,chardata`, `,any,attr`,
+		`,any`, `,innerxml,custom`, `a>`, `a>b,attr`, `name,chardata`, `,attr,chardata`, `,chardata,omitempty`,
+		`,comment,omitempty`, `x,any,attr`,
+	}
+	for _, tag := range tags {
+		t.Run(tag, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{
+				Name: "Field",
+				Type: reflect.TypeFor[string](),
+				Tag:  reflect.StructTag(`xml:` + strconv.Quote(tag)),
+			}})
+			enc := xml.NewEncoder(io.Discard)
+			stdErr := enc.EncodeElement(reflect.New(typ).Elem().Interface(), xml.StartElement{Name: xml.Name{Local: "r"}})
+
+			_, err := ParseXML(tag)
+			assert.Equal(t, stdErr != nil, err != nil, "encoding/xml: %v, ParseXML: %v", stdErr, err)
+		})
+	}
+}
+
+func TestParseProtobuf(t *testing.T) {
+	pt, err := ParseProtobuf(`bytes,1,opt,name=theme,json=themeName,proto3`)
+	require.NoError(t, err)
+	assert.Equal(t, ProtobufTag{
+		WireType:    "bytes",
+		Number:      1,
+		Cardinality: "opt",
+		Options:     M{"name": "theme", "json": "themeName", "proto3": ""},
+	}, pt)
+	assert.Equal(t, "theme", pt.Name())
+
+	pt, err = ParseProtobuf(`varint,15,rep,packed,name=ids,enum=pkg.Kind`)
+	require.NoError(t, err)
+	assert.Equal(t, 15, pt.Number)
+	assert.Equal(t, "rep", pt.Cardinality)
+	assert.Equal(t, M{"packed": "", "name": "ids", "enum": "pkg.Kind"}, pt.Options)
+
+	// The default value holds the rest of the tag
+	pt, err = ParseProtobuf(`bytes,2,opt,name=greeting,def=hello, world`)
+	require.NoError(t, err)
+	assert.Equal(t, "hello, world", pt.Options["def"])
+	assert.Equal(t, "greeting", pt.Name())
+
+	// The registered dialect reports positional items as flags
+	tag, err := ParseDialect("protobuf", `bytes,2,opt,name=greeting,def=hello, world`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"bytes": "", "2": "", "opt": "", "name": "greeting", "def": "hello, world"}, tag.Options)
+	_, err = ParseDialect("protobuf", `bytes,0,opt`)
+	require.Error(t, err)
+}
+
+func TestParseProtobuf_Errors(t *testing.T) {
+	tests := []struct {
+		tag  string
+		code ErrorCode
+		pos  int
+		msg  string
+	}{
+		{``, CodeMissingValue, 0, "missing wire type"},
+		{`bytes`, CodeMissingValue, 5, "missing field number"},
+		{`bytes,1,def=x`, CodeMissingValue, 7, "missing cardinality"},
+		{`string,1,opt`, CodeInvalidValue, 0, "invalid wire type"},
+		{`bytes,0,opt`, CodeInvalidValue, 6, "invalid field number"},
+		{`bytes,x,opt`, CodeInvalidValue, 6, "invalid field number"},
+		{`bytes,1,optional`, CodeInvalidValue, 8, "invalid cardinality"},
+		{`bytes,name=x,1,opt`, CodeInvalidValue, 6, "invalid field number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			_, err := ParseProtobuf(tt.tag)
+			var parseErr *Error
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tt.code, parseErr.Code)
+			assert.Equal(t, tt.pos, parseErr.Pos)
+			assert.Equal(t, tt.msg, parseErr.Msg)
+		})
+	}
+}
+
+func TestParseMapstructure(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want MapstructureTag
+	}{
+		{``, MapstructureTag{}},
+		{`-`, MapstructureTag{Skip: true}},
+		{`-,omitempty`, MapstructureTag{Skip: true, OmitEmpty: true}},
+		{`name`, MapstructureTag{Name: "name"}},
+		{`,squash`, MapstructureTag{Squash: true}},
+		{`extra,remain`, MapstructureTag{Name: "extra", Remain: true}},
+		{`port,omitempty,unknown`, MapstructureTag{Name: "port", OmitEmpty: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseMapstructure(tt.tag))
+		})
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want EnvTag
+	}{
+		{``, EnvTag{}},
+		{`PORT`, EnvTag{VarName: "PORT"}},
+		{`PORT,required`, EnvTag{VarName: "PORT", Required: true}},
+		{`,notEmpty,file,unset,expand,init`, EnvTag{NotEmpty: true, File: true, Unset: true, Expand: true, Init: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := ParseEnv(tt.tag)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := ParseEnv(`PORT,required,optional`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeUnknownKey, parseErr.Code)
+	assert.Equal(t, 14, parseErr.Pos)
+	assert.Equal(t, `unknown key "optional"`, parseErr.Msg)
+}
+
+func TestLookupEnv(t *testing.T) {
+	type config struct {
+		Port  int    `env:"PORT,required" envDefault:"8080"`
+		Host  string `env:"HOST" envDefault:""`
+		Debug bool   `env:"DEBUG"`
+		Name  string `json:"name"`
+		Bad   string `env:"BAD,sometimes"`
+	}
+	typ := reflect.TypeFor[config]()
+	lookup := func(name string) (EnvTag, bool, error) {
+		field, _ := typ.FieldByName(name)
+
+		return LookupEnv(field.Tag)
+	}
+
+	et, ok, err := lookup("Port")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, EnvTag{VarName: "PORT", Required: true, Default: "8080", HasDefault: true}, et)
+
+	et, _, err = lookup("Host")
+	require.NoError(t, err)
+	assert.Equal(t, EnvTag{VarName: "HOST", HasDefault: true}, et)
+
+	et, _, err = lookup("Debug")
+	require.NoError(t, err)
+	assert.Equal(t, EnvTag{VarName: "DEBUG"}, et)
+
+	_, ok, err = lookup("Name")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = lookup("Bad")
+	assert.True(t, ok)
+	require.EqualError(t, err, `env tag: unknown key "sometimes" (at 5)`)
+}
+
+func TestParseDialect(t *testing.T) {
+	tests := []struct {
+		dialect, tag string
+		want         *Tag
+	}{
+		{"default", `name,omitempty,min='a, b'`, &Tag{Name: "name", Options: M{"omitempty": "", "min": "a, b"}}},
+		{"gorm", `column:id;primaryKey`, &Tag{Options: M{"column": "id", "primarykey": ""}}},
+		{"json", `it's,omitempty`, &Tag{Options: M{"omitempty": ""}}},
+		{"json", `-`, &Tag{Name: "-", Options: M{}}},
+		{"xml", `a>b,attr`, nil},
+		{"xml", `id,attr`, &Tag{Name: "id", Options: M{"attr": ""}}},
+		{"mapstructure", `,squash`, &Tag{Options: M{"squash": ""}}},
+		{"env", `PORT,required`, &Tag{Name: "PORT", Options: M{"required": ""}}},
+		{"env", `PORT,optional`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect+":"+tt.tag, func(t *testing.T) {
+			got, err := ParseDialect(tt.dialect, tt.tag)
+			if tt.want == nil {
+				require.Error(t, err)
+				assert.Nil(t, got)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.Name, got.Name)
+			assert.Equal(t, tt.want.Options, got.Options)
+		})
+	}
+
+	_, err := ParseDialect("yaml", `a`)
+	require.ErrorIs(t, err, ErrUnknownDialect)
+	require.EqualError(t, err, `unknown dialect "yaml"`)
+}
+
+func TestRegisterDialect(t *testing.T) {
+	// A dialect requiring upper-case names
+	RegisterDialect("test-upper", DialectSpec{
+		Syntax: New(WithSeparator('|')),
+		Name:   true,
+		PostProcess: func(raw string, tag *Tag) error {
+			if tag.Name != strings.ToUpper(tag.Name) {
+				return fmt.Errorf("name %q in %q is not upper case", tag.Name, raw)
+			}
+
+			return nil
+		},
+	})
+	t.Cleanup(func() {
+		dialectsMu.Lock()
+		delete(dialects, "test-upper")
+		dialectsMu.Unlock()
+	})
+
+	assert.Contains(t, Dialects(), "test-upper")
+	d, ok := LookupDialect("test-upper")
+	require.True(t, ok)
+	assert.True(t, d.HasName())
+
+	tag, err := ParseDialect("test-upper", `ID|a=1,2|b`)
+	require.NoError(t, err)
+	assert.Equal(t, "ID", tag.Name)
+	assert.Equal(t, M{"a": "1,2", "b": ""}, tag.Options)
+
+	_, err = ParseDialect("test-upper", `id|a`)
+	require.EqualError(t, err, `name "id" in "id|a" is not upper case`)
+
+	assert.Panics(t, func() { RegisterDialect("test-upper", DialectSpec{}) })
+	assert.Panics(t, func() { RegisterDialect("", DialectSpec{}) })
+	assert.Panics(t, func() { RegisterDialect("nil", nil) })
+}
+
+func TestParseHTTPParams(t *testing.T) {
+	tests := []struct {
+		value string
+		name  string
+		opts  map[string]string
+	}{
+		{`text/html; charset="utf-8"`, "text/html", map[string]string{"charset": "utf-8"}},
+		{`text/plain;CharSet=UTF-8 ; format=flowed`, "text/plain", map[string]string{"charset": "UTF-8", "format": "flowed"}},
+		{`attachment; filename="a \"b\";c.txt"`, "attachment", map[string]string{"filename": `a "b";c.txt`}},
+		{`form-data; name="f"; filename="C:\\dir\\x.txt"`, "form-data", map[string]string{"name": "f", "filename": `C:\dir\x.txt`}},
+		{`multipart/mixed; boundary="simple boundary"`, "multipart/mixed", map[string]string{"boundary": "simple boundary"}},
+		{`inline; q="\a\b"`, "inline", map[string]string{"q": "ab"}},
+		{`charset=utf-8`, "", map[string]string{"charset": "utf-8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			tag, err := ParseHTTPParams(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, tag.Name)
+			assert.Equal(t, tt.opts, tag.Options)
+		})
+	}
+}
+
+func TestParseHTTPParams_Errors(t *testing.T) {
+	tests := []struct {
+		value string
+		code  ErrorCode
+		pos   int
+	}{
+		{`text/html; charset`, CodeMissingValue, 11},
+		{`text/html; charset=`, CodeMissingValue, 19},
+		{`text/html; charset=a b`, CodeInvalidValue, 19},
+		{`text/html; charset=a\;b`, CodeInvalidValue, 19},
+		{`text/html; char set=utf-8`, CodeInvalidRule, 11},
+		{`text/html; c@=utf-8`, CodeInvalidRule, 11},
+		{`text html; a=b`, CodeInvalidValue, 0},
+		{`text/html/x`, CodeInvalidValue, 0},
+		{`"text"; a=b`, CodeInvalidValue, 0},
+		{"a; b=\"x\x01\"", CodeInvalidValue, 5},
+		{`a; b="x`, CodeUnterminatedQuote, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			_, err := ParseHTTPParams(tt.value)
+			var perr *Error
+			require.ErrorAs(t, err, &perr)
+			assert.Equal(t, tt.code, perr.Code, perr.Error())
+			assert.Equal(t, tt.pos, perr.Pos)
+		})
+	}
+}
+
+func TestParseHTTPDirectives(t *testing.T) {
+	tag, err := ParseHTTPDirectives(`no-cache, Max-Age=0, private="set-cookie, x-a"`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"no-cache": "", "max-age": "0", "private": "set-cookie, x-a"}, tag.Options)
+	assert.Empty(t, tag.Name)
+
+	_, err = ParseHTTPDirectives(`no-cache, max-age=1 2`)
+	var perr *Error
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, CodeInvalidValue, perr.Code)
+
+	tag, err = ParseDialect("http", `text/html; charset=utf-8`)
+	require.NoError(t, err)
+	assert.Equal(t, "text/html", tag.Name)
+	_, err = ParseDialect("http", `text/html; charset`)
+	require.Error(t, err)
+
+	tag, err = parseDialect(DialectHTTPList, `no-cache, Max-Age=0`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"no-cache": "", "max-age": "0"}, tag.Options)
+	_, err = parseDialect(DialectHTTPList, `no-cache, max-age=1 2`)
+	require.Error(t, err)
+}
+
+func TestParseSetCookie(t *testing.T) {
+	name, value, attrs, err := ParseSetCookie(`id=a3fWa; Expires=Wed, 21 Oct 2015 07:28:00 GMT; Path=/; Secure; HttpOnly; Max-Age=3600; SameSite=Lax;`)
+	require.NoError(t, err)
+	assert.Equal(t, "id", name)
+	assert.Equal(t, "a3fWa", value)
+	assert.Equal(t, map[string]string{
+		"expires":  "Wed, 21 Oct 2015 07:28:00 GMT",
+		"path":     "/",
+		"secure":   "",
+		"httponly": "",
+		"max-age":  "3600",
+		"samesite": "Lax",
+	}, attrs.Options)
+
+	name, value, attrs, err = ParseSetCookie(`SID="a=b"; path=/a; Path=/b`)
+	require.NoError(t, err)
+	assert.Equal(t, "SID", name)
+	assert.Equal(t, "a=b", value)
+	assert.Equal(t, map[string]string{"path": "/b"}, attrs.Options)
+
+	_, value, _, err = ParseSetCookie(`empty=`)
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	tests := []struct {
+		header string
+		code   ErrorCode
+		pos    int
+	}{
+		{``, CodeMissingName, 0},
+		{`Secure; id=a`, CodeMissingValue, 0},
+		{`a b=c`, CodeInvalidRule, 0},
+		{`id=a"b`, CodeInvalidValue, 3},
+		{`id=a\b; Path=/`, CodeInvalidValue, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			_, _, _, err := ParseSetCookie(tt.header)
+			var perr *Error
+			require.ErrorAs(t, err, &perr)
+			assert.Equal(t, tt.code, perr.Code, perr.Error())
+			assert.Equal(t, tt.pos, perr.Pos)
+		})
+	}
+
+	tag, err := ParseDialect("cookie", `Path=/; Secure; Max-Age=60`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"path": "/", "secure": "", "max-age": "60"}, tag.Options)
+}
@@ -0,0 +1,115 @@
+package tagparser
+
+import (
+	"slices"
+	"strings"
+)
+
+// DialectXML is the dialect of xml struct tags, with the syntax of
+// encoding/xml, like DialectJSON. It rejects the tags ParseXML rejects. Use
+// ParseXML to interpret a tag like encoding/xml.
+var DialectXML = DialectSpec{Syntax: xmlParser, Name: true, PostProcess: finishXML}
+
+// xmlParser is the parser of DialectXML.
+var xmlParser = New(withStdlibSyntax())
+
+// XMLTag is an xml struct tag as interpreted by encoding/xml.
+type XMLTag struct {
+	Skip      bool     // The tag is "-": the field is ignored
+	Namespace string   // Namespace before the first space, as in `http://example.com/ns name`
+	Path      []string // Element names from the outermost parent to the element, as in `a>b>c`
+	Flags     []string // Options such as "attr" or "omitempty", in order
+}
+
+// xmlModes are the flags selecting how a field is mapped.
+var xmlModes = []string{"attr", "cdata", "chardata", "innerxml", "comment", "any"}
+
+// ParseXML interprets an xml struct tag like encoding/xml, such as
+// `name>a>b,omitempty` or `http://example.com/ns id,attr`. Path holds the
+// element names split on '>'; it is empty when the tag has no name and
+// starts with an empty name when the outermost element is named after the
+// field, as in `>b`. Flags unknown to encoding/xml are kept in Flags.
+//
+// The combinations encoding/xml rejects are reported with CodeInvalidRule:
+// a trailing '>', an element path or a name given with a flag that does not
+// map an element, several mapping flags other than `any,attr`, and
+// omitempty with a flag that does not map an element or an attribute.
+func ParseXML(tag string) (XMLTag, error) {
+	if tag == "-" {
+		return XMLTag{Skip: true}, nil
+	}
+
+	var t XMLTag
+	offset := 0
+	if ns, _, ok := strings.Cut(tag, " "); ok {
+		t.Namespace = ns
+		offset = len(ns) + 1
+	}
+
+	name, namePos := "", offset
+	var flagPos []int
+	err := xmlParser.ParseFuncWithNamePos(tag[offset:], func(key, value string, keyPos, valPos int) error {
+		if key == "" {
+			name, namePos = value, offset+valPos
+		} else {
+			t.Flags = append(t.Flags, key)
+			flagPos = append(flagPos, offset+keyPos)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return XMLTag{}, err
+	}
+	if name != "" {
+		t.Path = strings.Split(name, ">")
+		if t.Path[len(t.Path)-1] == "" {
+			pos := namePos + len(name) - 1
+
+			return XMLTag{}, dialectError(tag, pos, CodeInvalidRule, "trailing '>' in element path")
+		}
+	}
+
+	// Find the mapping mode, like encoding/xml
+	mode, modePos := "", -1
+	for i, flag := range t.Flags {
+		if !slices.Contains(xmlModes, flag) {
+			continue
+		}
+		switch {
+		case mode == "":
+			mode, modePos = flag, flagPos[i]
+		case mode+","+flag == "any,attr" || mode+","+flag == "attr,any":
+			mode = "any,attr"
+		default:
+			return XMLTag{}, dialectError(tag, flagPos[i], CodeInvalidRule, "'"+flag+"' flag not valid with '"+mode+"'")
+		}
+	}
+
+	element := mode == "" || mode == "any"
+	switch {
+	case mode != "" && mode != "attr" && name != "":
+		return XMLTag{}, dialectError(tag, namePos, CodeInvalidRule, "name not valid with '"+mode+"' flag")
+	case len(t.Path) > 1 && !element:
+		return XMLTag{}, dialectError(tag, namePos, CodeInvalidRule, "element path not valid with '"+mode+"' flag")
+	case t.Has("omitempty") && !element && !strings.Contains(mode, "attr"):
+		return XMLTag{}, dialectError(tag, modePos, CodeInvalidRule, "'omitempty' not valid with '"+mode+"' flag")
+	}
+
+	return t, nil
+}
+
+// Name returns the name of the element or attribute, the last element of
+// Path, or "" when the field name is used.
+func (t XMLTag) Name() string {
+	if len(t.Path) == 0 {
+		return ""
+	}
+
+	return t.Path[len(t.Path)-1]
+}
+
+// Has reports whether the tag has the flag, such as "attr".
+func (t XMLTag) Has(flag string) bool {
+	return slices.Contains(t.Flags, flag)
+}
@@ -0,0 +1,225 @@
+package tagparser
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+)
+
+// tagDoc is the JSON and YAML form of a Tag. It has the fields of Tag, so
+// that the encoding is the one of the plain struct, with the keys written
+// with an empty value, as in `default=`, listed apart so that Lookup
+// survives a round trip.
+type tagDoc struct {
+	Name        string            `json:"Name"                  yaml:"name"`
+	Options     map[string]string `json:"Options"               yaml:"options"`
+	EmptyValues []string          `json:"EmptyValues,omitempty" yaml:"emptyvalues,omitempty"`
+}
+
+func (t Tag) doc() tagDoc {
+	doc := tagDoc{Name: t.Name, Options: t.Options}
+	for _, key := range t.Keys() {
+		if t.Options[key] == "" && t.empty[key] {
+			doc.EmptyValues = append(doc.EmptyValues, key)
+		}
+	}
+
+	return doc
+}
+
+func (t *Tag) setDoc(doc tagDoc) error {
+	out := Tag{Name: doc.Name, Options: make(map[string]string, len(doc.Options))}
+	for key, value := range doc.Options {
+		out.setOption(key, value, false)
+	}
+	for _, key := range doc.EmptyValues {
+		if value, ok := out.Options[key]; !ok || value != "" {
+			return fmt.Errorf("option %q: listed in EmptyValues but not empty", key)
+		}
+		out.setOption(key, "", true)
+	}
+	*t = out
+
+	return nil
+}
+
+// MarshalJSON encodes t as an object with the fields of Tag, as
+// encoding/json encodes a struct without this method, options in key
+// order, so that tag inventories can be written to files and read back:
+//
+//	{"Name": "id", "Options": {"default": "", "min": "5", "omitempty": ""}, "EmptyValues": ["default"]}
+//
+// The EmptyValues field, left out when it is empty, lists the options
+// written with an empty value rather than as a flag, as told apart by
+// Lookup. The syntax of the Parser that produced t, such as its list
+// separator, is not encoded.
+func (t Tag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.doc())
+}
+
+// UnmarshalJSON decodes a tag encoded by MarshalJSON, or by encoding/json
+// for a struct with the fields of Tag, replacing t.
+func (t *Tag) UnmarshalJSON(data []byte) error {
+	var doc tagDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	return t.setDoc(doc)
+}
+
+// MarshalYAML encodes t like MarshalJSON for YAML libraries such as
+// gopkg.in/yaml.v3, which call it through their Marshaler interface. Keys
+// are lower-cased, as yaml.v3 writes the fields of a struct: name, options
+// and emptyvalues.
+func (t Tag) MarshalYAML() (any, error) {
+	return t.doc(), nil
+}
+
+// UnmarshalYAML decodes a tag encoded by MarshalYAML, replacing t. unmarshal
+// decodes the YAML node into its argument, as passed by gopkg.in/yaml.v2
+// and gopkg.in/yaml.v3.
+func (t *Tag) UnmarshalYAML(unmarshal func(any) error) error {
+	var doc tagDoc
+	if err := unmarshal(&doc); err != nil {
+		return err
+	}
+
+	return t.setDoc(doc)
+}
+
+// binaryVersion starts the binary form of a Tag, see AppendBinary.
+const binaryVersion = 1
+
+// errInvalidBinary is returned by UnmarshalBinary for malformed input.
+var errInvalidBinary = errors.New("invalid binary tag")
+
+// AppendBinary appends the binary form of t to b: a version byte, the name,
+// the number of options and the options in key order, strings prefixed by
+// their length as uvarints and each value preceded by a byte telling a
+// flag from a value. The encoding is deterministic, so that equal tags have
+// equal encodings, and like MarshalJSON it does not keep the syntax of the
+// Parser that produced t.
+func (t Tag) AppendBinary(b []byte) ([]byte, error) {
+	return t.appendBinary(b), nil
+}
+
+func (t Tag) appendBinary(b []byte) []byte {
+	b = append(b, binaryVersion)
+	b = appendString(b, t.Name)
+	b = binary.AppendUvarint(b, uint64(len(t.Options)))
+	for _, key := range t.Keys() {
+		b = appendString(b, key)
+		value, hasValue, _ := t.Lookup(key)
+		if !hasValue {
+			b = append(b, 0)
+
+			continue
+		}
+		b = append(b, 1)
+		b = appendString(b, value)
+	}
+
+	return b
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+
+	return append(b, s...)
+}
+
+// MarshalBinary returns the binary form of t, see AppendBinary. It also
+// lets encoding/gob encode tags.
+func (t Tag) MarshalBinary() ([]byte, error) {
+	return t.AppendBinary(nil)
+}
+
+// UnmarshalBinary decodes the binary form of a tag, replacing t.
+func (t *Tag) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != binaryVersion {
+		return fmt.Errorf("%w: unknown version", errInvalidBinary)
+	}
+	d := binaryDecoder{data: data[1:]}
+	out := Tag{Name: d.string()}
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		return errInvalidBinary
+	}
+	out.Options = make(map[string]string, n)
+	for range n {
+		key := d.string()
+		switch d.byte() {
+		case 0:
+			out.setOption(key, "", false)
+		case 1:
+			out.setOption(key, d.string(), true)
+		default:
+			d.err = true
+		}
+	}
+	if d.err || len(d.data) > 0 {
+		return errInvalidBinary
+	}
+	*t = out
+
+	return nil
+}
+
+// binaryDecoder reads the binary form of a tag, setting err if it is
+// truncated or malformed.
+type binaryDecoder struct {
+	data []byte
+	err  bool
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err, d.data = true, nil
+
+		return 0
+	}
+	d.data = d.data[n:]
+
+	return v
+}
+
+func (d *binaryDecoder) byte() byte {
+	if len(d.data) == 0 {
+		d.err = true
+
+		return 0
+	}
+	c := d.data[0]
+	d.data = d.data[1:]
+
+	return c
+}
+
+func (d *binaryDecoder) string() string {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.err, d.data = true, nil
+
+		return ""
+	}
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+
+	return s
+}
+
+// Hash returns a hash of t that is stable across processes and Go
+// versions, for cache keys such as those of code generators. Tags with the
+// same name and options have the same hash whatever the order they were
+// written in, while a flag and an empty value differ, as for Lookup. It is
+// the 64-bit FNV-1a hash of the binary form.
+func (t Tag) Hash() uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(t.appendBinary(nil))
+
+	return h.Sum64()
+}
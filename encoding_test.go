@@ -0,0 +1,110 @@
+package tagparser
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTag_MarshalJSON(t *testing.T) {
+	tag := MustParseWithName(`id,omitempty,min=5,default=`)
+
+	data, err := json.Marshal(tag)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Name": "id", "Options": {"default": "", "min": "5", "omitempty": ""}, "EmptyValues": ["default"]}`, string(data))
+
+	// Tags held by value encode the same way
+	data, err = json.Marshal(struct{ Tag Tag }{tag.Only("min")})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Tag": {"Name": "id", "Options": {"min": "5"}}}`, string(data))
+
+	// Tags without empty values encode as the plain struct does
+	type plainTag struct {
+		Name    string
+		Options map[string]string
+	}
+	plain := MustParse(`omitempty,min=5`)
+	data, err = json.Marshal(plain)
+	require.NoError(t, err)
+	want, err := json.Marshal(plainTag{Name: plain.Name, Options: plain.Options})
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(data))
+
+	var back Tag
+	require.NoError(t, json.Unmarshal([]byte(`{"Name": "id", "Options": {"default": "", "min": "5", "omitempty": ""}, "EmptyValues": ["default"]}`), &back))
+	assert.Equal(t, "id", back.Name)
+	assert.Equal(t, M{"default": "", "min": "5", "omitempty": ""}, back.Options)
+	_, hasValue, _ := back.Lookup("default")
+	assert.True(t, hasValue)
+	_, hasValue, _ = back.Lookup("omitempty")
+	assert.False(t, hasValue)
+
+	empty, err := json.Marshal(Tag{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Name": "", "Options": null}`, string(empty))
+
+	require.Error(t, json.Unmarshal([]byte(`{"Options": {"min": 5}}`), &back))
+	require.EqualError(t, json.Unmarshal([]byte(`{"Options": {"min": "5"}, "EmptyValues": ["min"]}`), &back),
+		`option "min": listed in EmptyValues but not empty`)
+}
+
+func TestTag_MarshalYAML(t *testing.T) {
+	tag := MustParseWithName(`id,omitempty,min=5`)
+
+	data, err := yaml.Marshal(tag)
+	require.NoError(t, err)
+	assert.Equal(t, "name: id\noptions:\n    min: \"5\"\n    omitempty: \"\"\n", string(data))
+
+	var back Tag
+	require.NoError(t, yaml.Unmarshal(data, &back))
+	assert.Equal(t, tag.Name, back.Name)
+	assert.Equal(t, tag.Options, back.Options)
+
+	require.NoError(t, yaml.Unmarshal([]byte("options:\n  default: \"\"\nemptyvalues: [default]\n"), &back))
+	_, hasValue, _ := back.Lookup("default")
+	assert.True(t, hasValue)
+	require.Error(t, yaml.Unmarshal([]byte("options:\n  min: [5]\n"), &back))
+}
+
+func TestTag_MarshalBinary(t *testing.T) {
+	tag := MustParseWithName(`id,omitempty,min=5,default=`)
+
+	data, err := tag.MarshalBinary()
+	require.NoError(t, err)
+	var back Tag
+	require.NoError(t, back.UnmarshalBinary(data))
+	assert.Equal(t, *tag, back)
+
+	// AppendBinary appends to its argument
+	prefixed, err := tag.AppendBinary([]byte("x"))
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte("x"), data...), prefixed)
+
+	// gob uses the binary form
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(tag))
+	var decoded Tag
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	assert.Equal(t, *tag, decoded)
+
+	for _, bad := range [][]byte{nil, {2}, data[:len(data)-1], append(data, 0)} {
+		require.Error(t, back.UnmarshalBinary(bad))
+	}
+}
+
+func TestTag_Hash(t *testing.T) {
+	a := MustParseWithName(`id,min=1,max=2,omitempty`)
+	b := MustParseWithName(`id, omitempty, max=2, min=1`)
+	assert.Equal(t, a.Hash(), b.Hash())
+	// Hashes are stable across releases
+	assert.Equal(t, uint64(0xaade1fe1f3eb2762), a.Hash())
+
+	for _, other := range []string{`id,min=1,max=2`, `x,min=1,max=2,omitempty`, `id,min=1,max=2,omitempty=`, `id,min=1,max=3,omitempty`} {
+		assert.NotEqual(t, a.Hash(), MustParseWithName(other).Hash(), other)
+	}
+}
@@ -0,0 +1,161 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// ErrMissingEnv is reported by BindEnv for a required environment variable
+// that is not set.
+var ErrMissingEnv = errors.New("environment variable not set")
+
+// envTagKey and envDefaultKey are the struct tag keys read by BindEnv.
+const (
+	envTagKey     = "env"
+	envDefaultKey = "default"
+)
+
+// BindOption configures BindEnv.
+type BindOption func(*envBinder)
+
+// WithEnvPrefix prepends prefix to the names of the variables BindEnv
+// reads, as in "APP_" for APP_PORT.
+func WithEnvPrefix(prefix string) BindOption {
+	return func(b *envBinder) {
+		b.prefix = prefix
+	}
+}
+
+// WithEnvLookup makes BindEnv read variables with lookup instead of
+// os.LookupEnv, for tests and for values coming from elsewhere, such as a
+// .env file.
+func WithEnvLookup(lookup func(name string) (string, bool)) BindOption {
+	return func(b *envBinder) {
+		b.lookup = lookup
+	}
+}
+
+type envBinder struct {
+	prefix string
+	lookup func(name string) (string, bool)
+}
+
+// BindEnv stores environment variables in the fields of the struct pointed
+// to by dst that have an `env` tag, so that configuration can be declared
+// by a struct:
+//
+//	type Config struct {
+//	    Port    int           `env:"PORT" default:"8080"`
+//	    DSN     string        `env:"DATABASE_URL,required"`
+//	    Timeout time.Duration `env:"TIMEOUT" default:"30s"`
+//	}
+//
+//	var cfg Config
+//	if err := tagparser.BindEnv(&cfg); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// The tag name is the variable name, the upper-cased field name if it is
+// empty; a name of "-" skips the field. A variable that is not set leaves
+// the field at the value of its `default` tag if it has one, and otherwise
+// unchanged, unless the tag has the required option. Fields may have the
+// types Unmarshal accepts, with slice elements separated by '|'. Nested
+// struct fields are walked like by WalkStruct, and nil pointers to structs
+// are allocated.
+//
+// Every problem is reported, in field order, in an error joining one
+// *FieldError per field whose Field is the path of the field, such as
+// "DB.Host": tags with other options, fields of unsupported types, missing
+// required variables (ErrMissingEnv) and values that do not convert.
+func BindEnv(dst any, opts ...BindOption) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrNotStruct, dst)
+	}
+	b := envBinder{lookup: os.LookupEnv}
+	for _, opt := range opts {
+		opt(&b)
+	}
+
+	root := v.Elem().Type().Name()
+	var errs []error
+	err := WalkStruct(v.Type(), envTagKey, func(path []string, field reflect.StructField, tag Tag) error {
+		if tag.Options == nil || tag.Name == "-" {
+			return nil
+		}
+		_, fv := fieldValue(v.Elem(), path)
+		if !fv.IsValid() {
+			return nil
+		}
+		if err := b.bind(field, tag, fv); err != nil {
+			key := envTagKey
+			if errors.Is(err, ErrUnsupportedType) || errors.As(err, new(*envValueError)) {
+				key = "" // the field or the variable is at fault, not the tag
+			}
+			errs = append(errs, &FieldError{Struct: root, Field: strings.Join(path, "."), Key: key, Err: err})
+		}
+
+		return SkipStruct
+	})
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(errs...)
+}
+
+// bind stores the variable named by tag in the field fv.
+func (b *envBinder) bind(field reflect.StructField, tag Tag, fv reflect.Value) error {
+	if !decodable(field.Type) {
+		return fmt.Errorf("%w: %v", ErrUnsupportedType, field.Type)
+	}
+	for key := range tag.Options {
+		if key != "required" {
+			return fmt.Errorf("%s %q", errUnknownKey, key)
+		}
+	}
+
+	name := tag.Name
+	if name == "" {
+		name = strings.ToUpper(field.Name)
+	}
+	name = b.prefix + name
+
+	value, ok := b.lookup(name)
+	if !ok {
+		_, required := tag.Options["required"]
+		value, ok = field.Tag.Lookup(envDefaultKey)
+		switch {
+		case required:
+			return &envValueError{name: name, err: ErrMissingEnv}
+		case !ok:
+			return nil
+		}
+	}
+	if err := setValue(fv, value, newListFormat(0)); err != nil {
+		return &envValueError{name: name, value: value, err: err}
+	}
+
+	return nil
+}
+
+// envValueError reports a variable that is missing or does not convert to
+// the type of its field.
+type envValueError struct {
+	name  string
+	value string
+	err   error
+}
+
+func (e *envValueError) Error() string {
+	if errors.Is(e.err, ErrMissingEnv) {
+		return fmt.Sprintf("%v: %s", e.err, e.name)
+	}
+
+	return fmt.Sprintf("%s %q for %s: %v", errInvalidValue, e.value, e.name, e.err)
+}
+
+func (e *envValueError) Unwrap() error { return e.err }
@@ -0,0 +1,97 @@
+package tagparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type envDB struct {
+	Host string `env:"DB_HOST,required"`
+	Port int    `env:"DB_PORT" default:"5432"`
+}
+
+type envConfig struct {
+	Port    int           `env:"PORT" default:"8080"`
+	Debug   bool          `env:""`
+	Timeout time.Duration `env:"TIMEOUT" default:"30s"`
+	Hosts   []string      `env:"HOSTS"`
+	Name    string        `env:"NAME"`
+	DB      *envDB
+	Skipped string `env:"-"`
+	Plain   string
+}
+
+func envMap(vars map[string]string) BindOption {
+	return WithEnvLookup(func(name string) (string, bool) {
+		value, ok := vars[name]
+
+		return value, ok
+	})
+}
+
+func TestBindEnv(t *testing.T) {
+	cfg := envConfig{Name: "keep", Plain: "plain"}
+	err := BindEnv(&cfg, envMap(map[string]string{
+		"DEBUG":   "true",
+		"TIMEOUT": "1m",
+		"HOSTS":   "a|b",
+		"DB_HOST": "db",
+		"SKIPPED": "x",
+		"PLAIN":   "x",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, envConfig{
+		Port:    8080,
+		Debug:   true,
+		Timeout: time.Minute,
+		Hosts:   []string{"a", "b"},
+		Name:    "keep",
+		DB:      &envDB{Host: "db", Port: 5432},
+		Plain:   "plain",
+	}, cfg)
+
+	t.Setenv("APP_PORT", "9000")
+	t.Setenv("APP_DB_HOST", "localhost")
+	cfg = envConfig{}
+	require.NoError(t, BindEnv(&cfg, WithEnvPrefix("APP_")))
+	assert.Equal(t, 9000, cfg.Port)
+	assert.Equal(t, "localhost", cfg.DB.Host)
+}
+
+func TestBindEnv_Errors(t *testing.T) {
+	var cfg envConfig
+	err := BindEnv(&cfg, envMap(map[string]string{"PORT": "http", "TIMEOUT": "soon"}))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrMissingEnv)
+	assert.Equal(t, strings.Join([]string{
+		`envConfig.Port: invalid value "http" for PORT: strconv.ParseInt: parsing "http": invalid syntax`,
+		`envConfig.Timeout: invalid value "soon" for TIMEOUT: time: invalid duration "soon"`,
+		`envConfig.DB.Host: environment variable not set: DB_HOST`,
+	}, "\n"), err.Error())
+
+	var fieldErr *FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "Port", fieldErr.Field)
+
+	var tagErrs struct {
+		A string            `env:"A,secret"`
+		M map[string]string `env:"M"`
+	}
+	err = BindEnv(&tagErrs, envMap(nil))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.Contains(t, err.Error(), `.A: env tag: unknown key "secret"`)
+
+	require.ErrorIs(t, BindEnv(cfg), ErrNotStruct)
+
+	var badTag struct {
+		A string `env:"A,'"`
+	}
+	err = BindEnv(&badTag)
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrMissingEnv))
+}
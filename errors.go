@@ -0,0 +1,378 @@
+package tagparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	errQuotesMustEnclose  = "quotes must enclose the entire value"
+	errUnterminatedQuote  = "unterminated quote"
+	errEmptyKey           = "empty key"
+	errUnterminatedEscape = "unterminated escape sequence"
+	errInvalidEscape      = "invalid escape character"
+	errInvalidQuote       = "invalid quote"
+	errTagTooLarge        = "tag too large"
+	errUnknownKey         = "unknown key"
+	errInvalidValue       = "invalid value"
+	errMissingKey         = "missing required key"
+	errMissingValue       = "missing value"
+	errUnexpectedValue    = "unexpected value"
+	errMissingName        = "missing name before key=value pair"
+	errNegatedValue       = "negated flag cannot have a value"
+	errTooManyOptions     = "too many options"
+	errKeyTooLong         = "key too long"
+	errValueTooLong       = "value too long"
+	errInvalidUTF8        = "invalid UTF-8"
+	errControlChar        = "control character"
+	errInvalidRule        = "invalid rule"
+	errUnterminatedGroup  = "unterminated group"
+	errInvalidGroup       = "text after group"
+	errTooManyErrors      = "too many errors"
+	errConflictingKey     = "conflicting key"
+)
+
+// ErrorCode classifies an Error, so programs can branch on the kind of
+// problem without matching against messages.
+type ErrorCode int
+
+// Error codes reported in Error.Code.
+const (
+	CodeUnknown            ErrorCode = iota // Unclassified error
+	CodeTagTooLarge                         // Tag exceeds the maximum length
+	CodeUnterminatedQuote                   // Quote opened but never closed
+	CodeUnterminatedEscape                  // Backslash at the end of the tag
+	CodeInvalidEscape                       // Escaped letter or digit
+	CodeEmptyKey                            // Key=value item with an empty key
+	CodeQuoteInMiddle                       // Quotes do not enclose the entire value
+	CodeInvalidQuote                        // More than one pair of quotes in a value
+	CodeCallback                            // Callback returned an error, see Cause
+	CodeStructTagSyntax                     // Malformed key:"value" struct tag literal
+	CodeUnknownKey                          // Option key not accepted by the destination
+	CodeInvalidValue                        // Option value not valid for its key
+	CodeMissingKey                          // Required option key absent
+	CodeMissingValue                        // Option given as a flag needs a value
+	CodeUnexpectedValue                     // Flag option given a value
+	CodeMissingName                         // First item is a key=value pair, see WithStrictName
+	CodeTooManyOptions                      // More options than allowed by WithMaxOptions
+	CodeKeyTooLong                          // Key longer than allowed by WithMaxKeyLength
+	CodeValueTooLong                        // Value longer than allowed by WithMaxValueLength
+	CodeInvalidUTF8                         // Invalid UTF-8, see WithStrictChars
+	CodeControlChar                         // ASCII control character, see WithStrictChars
+	CodeInvalidRule                         // Rule or flag not valid where it appears in a dialect
+	CodeUnterminatedGroup                   // Parenthesis opened but never closed, see WithGroups
+	CodeInvalidGroup                        // Text after the closing parenthesis of a group
+	CodeTooManyErrors                       // More errors than allowed by WithMaxErrors
+	CodeConflictingKey                      // Option excluded by another, see KeySpec.Conflicts
+)
+
+var errorCodeNames = [...]string{
+	CodeUnknown:            "Unknown",
+	CodeTagTooLarge:        "TagTooLarge",
+	CodeUnterminatedQuote:  "UnterminatedQuote",
+	CodeUnterminatedEscape: "UnterminatedEscape",
+	CodeInvalidEscape:      "InvalidEscape",
+	CodeEmptyKey:           "EmptyKey",
+	CodeQuoteInMiddle:      "QuoteInMiddle",
+	CodeInvalidQuote:       "InvalidQuote",
+	CodeCallback:           "Callback",
+	CodeStructTagSyntax:    "StructTagSyntax",
+	CodeUnknownKey:         "UnknownKey",
+	CodeInvalidValue:       "InvalidValue",
+	CodeMissingKey:         "MissingKey",
+	CodeMissingValue:       "MissingValue",
+	CodeUnexpectedValue:    "UnexpectedValue",
+	CodeMissingName:        "MissingName",
+	CodeTooManyOptions:     "TooManyOptions",
+	CodeKeyTooLong:         "KeyTooLong",
+	CodeValueTooLong:       "ValueTooLong",
+	CodeInvalidUTF8:        "InvalidUTF8",
+	CodeControlChar:        "ControlChar",
+	CodeInvalidRule:        "InvalidRule",
+	CodeUnterminatedGroup:  "UnterminatedGroup",
+	CodeInvalidGroup:       "InvalidGroup",
+	CodeTooManyErrors:      "TooManyErrors",
+	CodeConflictingKey:     "ConflictingKey",
+}
+
+// errorCodeMessages holds the default Error.Msg for syntax error codes.
+var errorCodeMessages = [...]string{
+	CodeTagTooLarge:        errTagTooLarge,
+	CodeUnterminatedQuote:  errUnterminatedQuote,
+	CodeUnterminatedEscape: errUnterminatedEscape,
+	CodeInvalidEscape:      errInvalidEscape,
+	CodeEmptyKey:           errEmptyKey,
+	CodeQuoteInMiddle:      errQuotesMustEnclose,
+	CodeInvalidQuote:       errInvalidQuote,
+	CodeCallback:           "",
+	CodeStructTagSyntax:    "",
+	CodeUnknownKey:         errUnknownKey,
+	CodeInvalidValue:       errInvalidValue,
+	CodeMissingKey:         errMissingKey,
+	CodeMissingValue:       errMissingValue,
+	CodeUnexpectedValue:    errUnexpectedValue,
+	CodeMissingName:        errMissingName,
+	CodeTooManyOptions:     errTooManyOptions,
+	CodeKeyTooLong:         errKeyTooLong,
+	CodeValueTooLong:       errValueTooLong,
+	CodeInvalidUTF8:        errInvalidUTF8,
+	CodeControlChar:        errControlChar,
+	CodeInvalidRule:        errInvalidRule,
+	CodeUnterminatedGroup:  errUnterminatedGroup,
+	CodeInvalidGroup:       errInvalidGroup,
+	CodeTooManyErrors:      errTooManyErrors,
+	CodeConflictingKey:     errConflictingKey,
+}
+
+// String returns the name of the code, such as "UnterminatedQuote".
+func (c ErrorCode) String() string {
+	if c < 0 || int(c) >= len(errorCodeNames) {
+		return fmt.Sprintf("ErrorCode(%d)", int(c))
+	}
+
+	return errorCodeNames[c]
+}
+
+// MarshalText encodes the code as its name, such as "UnterminatedQuote".
+func (c ErrorCode) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// message returns the default error message for c.
+func (c ErrorCode) message() string {
+	if c < 0 || int(c) >= len(errorCodeMessages) {
+		return ""
+	}
+
+	return errorCodeMessages[c]
+}
+
+// snippetContext is the number of bytes shown on either side of the error
+// position by Error.Snippet before the tag is elided.
+const snippetContext = 40
+
+// Error is the type of error returned by parse funcs in this package.
+//
+// Pos points at the offending byte, while Offset and Len delimit the whole
+// item containing it, so that Tag[Offset:Offset+Len] == Segment. In a tag
+// spanning several lines, Line and Column locate Pos as well; the message
+// uses them for a Parser with WithLineContinuation.
+type Error struct {
+	Tag     string    // Original tag string
+	Pos     int       // 0-based position of error
+	Msg     string    // Error message
+	Cause   error     // Optional underlying error
+	Segment string    // Raw text of the item containing the error
+	Offset  int       // 0-based byte offset of Segment in Tag
+	Len     int       // Length of Segment in bytes
+	Code    ErrorCode // Kind of error
+	Line    int       // 1-based line of Pos in a multi-line Tag, 0 otherwise
+	Column  int       // 1-based byte column of Pos in a multi-line Tag, 0 otherwise
+
+	format func(*Error) string // see WithErrorFormatter
+	lines  bool                // the message reports Line and Column
+}
+
+// Error returns the message of e with its 1-based position, as in
+// "unterminated quote (at 5)" or, in a multi-line tag parsed with
+// WithLineContinuation, "unterminated quote (at line 2, column 3)", or the
+// message from the formatter set with WithErrorFormatter on the Parser that
+// returned e.
+func (e *Error) Error() string {
+	if e.format != nil {
+		if msg := e.format(e); msg != "" {
+			return msg
+		}
+	}
+
+	if e.Cause != nil {
+		if e.Msg != "" {
+			return fmt.Sprintf("%s: %v (at %s)", e.Msg, e.Cause, e.where())
+		}
+
+		return fmt.Sprintf("%v (at %s)", e.Cause, e.where())
+	}
+
+	return fmt.Sprintf("%s (at %s)", e.Msg, e.where())
+}
+
+// where returns the 1-based position of e for Error.
+func (e *Error) where() string {
+	if e.lines && e.Line > 0 {
+		return fmt.Sprintf("line %d, column %d", e.Line, e.Column)
+	}
+
+	return strconv.Itoa(e.Pos + 1)
+}
+
+// locate sets the Line and Column of e from Pos when Tag spans several
+// lines, and returns e.
+func (e *Error) locate() *Error {
+	e.Line, e.Column = 0, 0
+	if strings.IndexByte(e.Tag, '\n') < 0 {
+		return e
+	}
+	pos := min(max(e.Pos, 0), len(e.Tag))
+	e.Line = strings.Count(e.Tag[:pos], "\n") + 1
+	e.Column = pos - strings.LastIndexByte(e.Tag[:pos], '\n')
+
+	return e
+}
+
+func (e *Error) Unwrap() error { return e.Cause }
+
+// errorJSON is the JSON encoding of an Error.
+type errorJSON struct {
+	Message string    `json:"message"`
+	Code    ErrorCode `json:"code"`
+	Pos     int       `json:"pos"`
+	Tag     string    `json:"tag"`
+	Segment string    `json:"segment"`
+	Offset  int       `json:"offset"`
+	Len     int       `json:"len"`
+	Line    int       `json:"line,omitempty"`
+	Column  int       `json:"column,omitempty"`
+	Snippet string    `json:"snippet"`
+	Cause   string    `json:"cause,omitempty"`
+}
+
+// MarshalJSON encodes e for tools such as editor plugins and CI
+// annotations, as an object with the message returned by Error, the code
+// name and the 0-based position, the tag and the offending item, the
+// Snippet and the message of the Cause if any:
+//
+//	{"message": "empty key (at 6)", "code": "EmptyKey", "pos": 5, ...}
+func (e *Error) MarshalJSON() ([]byte, error) {
+	out := errorJSON{
+		Message: e.Error(),
+		Code:    e.Code,
+		Pos:     e.Pos,
+		Tag:     e.Tag,
+		Segment: e.Segment,
+		Offset:  e.Offset,
+		Len:     e.Len,
+		Line:    e.Line,
+		Column:  e.Column,
+		Snippet: e.Snippet(),
+	}
+	if e.Cause != nil {
+		out.Cause = e.Cause.Error()
+	}
+
+	return json.Marshal(out)
+}
+
+// Snippet renders the tag with a caret under the error position:
+//
+//	alfa,b\ravo,charlie
+//	       ^
+//
+// Only the line holding the error is shown from a multi-line tag, and long
+// lines are elided with "..." around the error position. Tabs are kept in
+// the padding so the caret lines up in terminals.
+func (e *Error) Snippet() string {
+	pos := min(max(e.Pos, 0), len(e.Tag))
+
+	start := strings.LastIndexByte(e.Tag[:pos], '\n') + 1
+	end := len(e.Tag)
+	if i := strings.IndexByte(e.Tag[pos:], '\n'); i >= 0 {
+		end = pos + i
+	}
+	prefix, suffix := "", ""
+	if pos-start > snippetContext {
+		start = pos - snippetContext
+		for start > 0 && !utf8.RuneStart(e.Tag[start]) {
+			start--
+		}
+		prefix = "..."
+	}
+	if end-pos > snippetContext {
+		end = pos + snippetContext
+		for end < len(e.Tag) && !utf8.RuneStart(e.Tag[end]) {
+			end++
+		}
+		suffix = "..."
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteString(e.Tag[start:end])
+	b.WriteString(suffix)
+	b.WriteByte('\n')
+	b.WriteString(strings.Repeat(" ", len(prefix)))
+	for _, r := range e.Tag[start:pos] {
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteByte('^')
+
+	return b.String()
+}
+
+// FieldError is a problem with the tag of a struct field, as returned by
+// ParseStruct, WalkStruct and Unmarshal. Programs parsing tags during their
+// own struct walks can return it too, so that errors read the same
+// everywhere:
+//
+//	User.Email: validate tag: empty key (at 6)
+type FieldError struct {
+	Struct string // Name of the struct type
+	Field  string // Name of the field
+	Key    string // Struct tag key, such as "validate", or "" if the problem is not in a tag
+	Err    error  // The problem, usually an *Error
+}
+
+// Error returns the problem prefixed with the struct, the field and the
+// tag key.
+func (e *FieldError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s.%s: %v", e.Struct, e.Field, e.Err)
+	}
+
+	return fmt.Sprintf("%s.%s: %s tag: %v", e.Struct, e.Field, e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// Errors is the list of errors found in a single tag by ParseAll and by
+// lenient parsing. Errors are ordered by position.
+type Errors struct {
+	Tag  string   // Original tag string
+	List []*Error // Errors ordered by position
+}
+
+// Error returns the messages of all errors, one per line.
+func (e *Errors) Error() string {
+	var b strings.Builder
+	for i, err := range e.List {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(err.Error())
+	}
+
+	return b.String()
+}
+
+// MarshalJSON encodes e as an array of its errors.
+func (e *Errors) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.List)
+}
+
+// Unwrap returns the individual errors so that errors.Is and errors.As
+// can match any of them.
+func (e *Errors) Unwrap() []error {
+	errs := make([]error, len(e.List))
+	for i, err := range e.List {
+		errs[i] = err
+	}
+
+	return errs
+}
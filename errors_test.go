@@ -0,0 +1,242 @@
+package tagparser
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAll(t *testing.T) {
+	tag, errs := ParseAll(`alfa,b\ravo,charlie='delta'x,=echo,foxtrot=golf`)
+	require.NotNil(t, errs)
+	assert.Equal(t, M{"alfa": "", "foxtrot": "golf"}, tag.Options)
+
+	type diag struct {
+		pos     int
+		msg     string
+		segment string
+	}
+	var got []diag
+	for _, err := range errs.List {
+		got = append(got, diag{err.Pos, err.Msg, err.Segment})
+	}
+	assert.Equal(t, []diag{
+		{7, errInvalidEscape, `b\ravo`},
+		{26, errQuotesMustEnclose, `charlie='delta'x`},
+		{29, errEmptyKey, `=echo`},
+	}, got)
+
+	assert.Equal(t, strings.Join([]string{
+		`invalid escape character (at 8)`,
+		`quotes must enclose the entire value (at 27)`,
+		`empty key (at 30)`,
+	}, "\n"), errs.Error())
+}
+
+func TestParseAll_OrderedByPosition(t *testing.T) {
+	// The unterminated quote is only found at the end of the tag, after the
+	// escape it encloses
+	_, errs := ParseAll(`a,=b,c='x,d=\q`)
+	require.NotNil(t, errs)
+	var got []ErrorCode
+	var pos []int
+	for _, err := range errs.List {
+		got = append(got, err.Code)
+		pos = append(pos, err.Pos)
+	}
+	assert.Equal(t, []ErrorCode{CodeEmptyKey, CodeUnterminatedQuote, CodeInvalidEscape}, got)
+	assert.Equal(t, []int{2, 7, 13}, pos)
+}
+
+func TestParseAll_NoErrors(t *testing.T) {
+	tag, errs := ParseAllWithName(`alfa,bravo=charlie`)
+	assert.Nil(t, errs)
+	assert.Equal(t, "alfa", tag.Name)
+	assert.Equal(t, M{"bravo": "charlie"}, tag.Options)
+}
+
+func TestParseAll_TagTooLarge(t *testing.T) {
+	tag, errs := ParseAll(strings.Repeat("a", MaxTagLength+1))
+	require.NotNil(t, errs)
+	require.Len(t, errs.List, 1)
+	assert.True(t, errors.Is(errs, ErrTagTooLarge))
+	assert.Empty(t, tag.Options)
+}
+
+func TestParseAll_KeepsParserConfig(t *testing.T) {
+	tag, errs := New(WithPreserveWhitespace()).ParseAll(` alfa ,=bravo`)
+	require.NotNil(t, errs)
+	assert.Equal(t, M{" alfa ": ""}, tag.Options)
+}
+
+func TestError_Segment(t *testing.T) {
+	_, err := Parse(`alfa,bravo='charlie,delta`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, `bravo='charlie,delta`, parseErr.Segment)
+
+	_, err = Parse(`alfa,bravo='charlie\,delta',echo\x`)
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, `echo\x`, parseErr.Segment)
+}
+
+func TestError_Span(t *testing.T) {
+	_, err := Parse(`alfa,bravo=ch'arlie',delta`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 13, parseErr.Pos)
+	assert.Equal(t, 5, parseErr.Offset)
+	assert.Equal(t, 15, parseErr.Len)
+	assert.Equal(t, parseErr.Segment, parseErr.Tag[parseErr.Offset:parseErr.Offset+parseErr.Len])
+}
+
+func TestError_Snippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		snippet string
+	}{
+		{"simple", `alfa,b\ravo`, "alfa,b\\ravo\n       ^"},
+		{"at start", `=alfa`, "=alfa\n^"},
+		{"tabs kept", "\talfa,=bravo", "\talfa,=bravo\n\t     ^"},
+		{"multibyte", `ünï,=bravo`, "ünï,=bravo\n    ^"},
+		{"multi-line", "alfa,\n  bravo=\\q,\ncharlie", "  bravo=\\q,\n         ^"},
+		{
+			"elided",
+			strings.Repeat("a", 50) + `,=` + strings.Repeat("b", 50),
+			"..." + strings.Repeat("a", 39) + `,=` + strings.Repeat("b", 39) + "...\n" + strings.Repeat(" ", 3+40) + "^",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.tag)
+			var parseErr *Error
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tt.snippet, parseErr.Snippet())
+		})
+	}
+}
+
+func TestError_Code(t *testing.T) {
+	tests := []struct {
+		tag  string
+		code ErrorCode
+	}{
+		{`'alfa`, CodeUnterminatedQuote},
+		{`alfa\`, CodeUnterminatedEscape},
+		{`al\fa`, CodeInvalidEscape},
+		{`alfa,=bravo`, CodeEmptyKey},
+		{`alfa='bravo'x`, CodeQuoteInMiddle},
+		{strings.Repeat("a", MaxTagLength+1), CodeTagTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			_, err := Parse(tt.tag)
+			var parseErr *Error
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tt.code, parseErr.Code)
+		})
+	}
+
+	err := ParseFunc(`alfa`, func(key, value string) error { return errSimulated })
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeCallback, parseErr.Code)
+}
+
+func TestFieldError(t *testing.T) {
+	_, cause := Parse(`alfa,=b`)
+	err := error(&FieldError{Struct: "User", Field: "Email", Key: "validate", Err: cause})
+	assert.EqualError(t, err, "User.Email: validate tag: empty key (at 6)")
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeEmptyKey, parseErr.Code)
+
+	err = &FieldError{Struct: "User", Field: "Ch", Err: ErrUnsupportedType}
+	assert.EqualError(t, err, "User.Ch: unsupported field type")
+}
+
+func TestError_LineColumn(t *testing.T) {
+	_, err := Parse("alfa,\nbravo,\n  c=\\q")
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 18, parseErr.Pos)
+	assert.Equal(t, 3, parseErr.Line)
+	assert.Equal(t, 6, parseErr.Column)
+	assert.EqualError(t, err, "invalid escape character (at 19)")
+	assert.Contains(t, mustJSON(t, err), `"line":3,"column":6`)
+
+	// The message reports them with line continuation
+	_, err = New(WithLineContinuation()).Parse("alfa,\nbravo,\n  c=\\q")
+	assert.EqualError(t, err, "invalid escape character (at line 3, column 6)")
+
+	// Single-line tags have no line and column
+	_, err = Parse("alfa,=bravo")
+	require.ErrorAs(t, err, &parseErr)
+	assert.Zero(t, parseErr.Line)
+	assert.Zero(t, parseErr.Column)
+	assert.NotContains(t, mustJSON(t, err), `"line"`)
+
+	// Errors reported at the end of the tag point after its last line
+	lines := New(WithLineContinuation())
+	_, err = lines.Parse("a,\nb='c\\\n")
+	assert.EqualError(t, err, "unterminated quote (at line 2, column 3)")
+	_, err = lines.Parse("a,\nb=c\\")
+	assert.EqualError(t, err, "unterminated escape sequence (at line 2, column 4)")
+
+	// without changing the message otherwise
+	_, err = Parse("a,\nb='c")
+	assert.EqualError(t, err, "unterminated quote (at 6)")
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return string(b)
+}
+
+func TestError_MarshalJSON(t *testing.T) {
+	_, err := Parse(`alfa,=b`)
+	b, jsonErr := json.Marshal(err)
+	require.NoError(t, jsonErr)
+	assert.JSONEq(t, `{
+		"message": "empty key (at 6)",
+		"code": "EmptyKey",
+		"pos": 5,
+		"tag": "alfa,=b",
+		"segment": "=b",
+		"offset": 5,
+		"len": 2,
+		"snippet": "alfa,=b\n     ^"
+	}`, string(b))
+
+	err = ParseFunc(`a`, func(key, value string) error {
+		return errors.New("boom")
+	})
+	b, jsonErr = json.Marshal(err)
+	require.NoError(t, jsonErr)
+	assert.Contains(t, string(b), `"cause":"boom"`)
+
+	_, errs := ParseAll(`a=',=b`)
+	b, jsonErr = json.Marshal(errs)
+	require.NoError(t, jsonErr)
+	var list []map[string]any
+	require.NoError(t, json.Unmarshal(b, &list))
+	require.Len(t, list, len(errs.List))
+	assert.Equal(t, errs.List[0].Code.String(), list[0]["code"])
+}
+
+func TestErrorCode_String(t *testing.T) {
+	assert.Equal(t, "QuoteInMiddle", CodeQuoteInMiddle.String())
+	assert.Equal(t, "Unknown", CodeUnknown.String())
+	assert.Equal(t, "TooManyErrors", CodeTooManyErrors.String())
+	assert.Equal(t, "ErrorCode(99)", ErrorCode(99).String())
+}
@@ -0,0 +1,70 @@
+// Package expvarmetrics provides a tagparser.MetricsCollector publishing
+// its counters with expvar:
+//
+//	m := expvarmetrics.New("tagparser")
+//	p := tagparser.New(tagparser.WithMetrics(m))
+//	// /debug/vars: "tagparser": {"duration_ns": 183204, "errors": 2, "parsed": 1041}
+//
+// It lives apart from tagparser because importing expvar registers the
+// /debug/vars handler on http.DefaultServeMux, which programs that only
+// parse tags should not get.
+package expvarmetrics
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/talav/tagparser"
+)
+
+// Metrics is a tagparser.MetricsCollector keeping counters that expvar can
+// publish, as a map such as
+//
+//	{"duration_ns": 183204, "errors": 2, "parsed": 1041}
+//
+// The zero value is not ready for use; create one with New.
+type Metrics struct {
+	Parsed   expvar.Int // parses, successful or not
+	Errors   expvar.Int // parses that returned an error
+	Duration expvar.Int // total time spent parsing, in nanoseconds
+
+	vars expvar.Map
+}
+
+var _ tagparser.MetricsCollector = (*Metrics)(nil)
+
+// New returns a Metrics published with expvar under name, such as
+// "tagparser", or not published if name is empty. Like expvar.Publish, it
+// panics if name is already in use. A Metrics is itself an expvar.Var, for
+// publishing it later or inside another map.
+func New(name string) *Metrics {
+	m := &Metrics{}
+	m.vars.Set("parsed", &m.Parsed)
+	m.vars.Set("errors", &m.Errors)
+	m.vars.Set("duration_ns", &m.Duration)
+	if name != "" {
+		expvar.Publish(name, m)
+	}
+
+	return m
+}
+
+// IncParsed implements tagparser.MetricsCollector.
+func (m *Metrics) IncParsed() {
+	m.Parsed.Add(1)
+}
+
+// IncErrors implements tagparser.MetricsCollector.
+func (m *Metrics) IncErrors() {
+	m.Errors.Add(1)
+}
+
+// ObserveDuration implements tagparser.MetricsCollector.
+func (m *Metrics) ObserveDuration(d time.Duration) {
+	m.Duration.Add(int64(d))
+}
+
+// String returns the metrics as a JSON object, implementing expvar.Var.
+func (m *Metrics) String() string {
+	return m.vars.String()
+}
@@ -0,0 +1,30 @@
+package expvarmetrics_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talav/tagparser"
+	"github.com/talav/tagparser/expvarmetrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := expvarmetrics.New("tagparser_test")
+	assert.Same(t, m, expvar.Get("tagparser_test"))
+
+	p := tagparser.New(tagparser.WithMetrics(m))
+	_, _ = p.ParseBatch([]string{"a", "b='", "c"})
+	assert.Equal(t, int64(3), m.Parsed.Value())
+	assert.Equal(t, int64(1), m.Errors.Value())
+	assert.GreaterOrEqual(t, m.Duration.Value(), int64(0))
+
+	var vars map[string]int64
+	require.NoError(t, json.Unmarshal([]byte(m.String()), &vars))
+	assert.Equal(t, map[string]int64{"parsed": 3, "errors": 1, "duration_ns": m.Duration.Value()}, vars)
+
+	assert.Equal(t, `{"duration_ns": 0, "errors": 0, "parsed": 0}`, expvarmetrics.New("").String())
+}
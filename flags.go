@@ -0,0 +1,162 @@
+package tagparser
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// flagTagKey is the struct tag key read by BindFlags.
+const flagTagKey = "flag"
+
+// BindFlags registers a flag on fs for every field of the struct pointed to
+// by dst with a `flag` tag, so that command-line flags can be declared by a
+// struct:
+//
+//	type Options struct {
+//	    Addr    string        `flag:"addr,usage='listen address',default=:8080"`
+//	    Timeout time.Duration `flag:"timeout,default=30s"`
+//	    Verbose bool          `flag:"v,usage='log requests'"`
+//	}
+//
+//	var opts Options
+//	if err := tagparser.BindFlags(flag.CommandLine, &opts); err != nil {
+//	    log.Fatal(err)
+//	}
+//	flag.Parse()
+//
+// The tag name is the flag name, the lower-cased field name if it is
+// empty; a name of "-" skips the field. The usage option is the usage
+// message, and the default option is stored in the field when the flag is
+// registered. Fields may have the types Unmarshal accepts, and bool fields
+// are boolean flags, set by `-v` alone. Each occurrence of a slice flag
+// adds its elements, separated by '|', to those of the previous ones.
+// Nested struct fields are walked like by WalkStruct, and nil pointers to
+// structs are allocated.
+//
+// Tags with other options, fields of unsupported types, invalid defaults
+// and flags already defined on fs are reported as *FieldError.
+func BindFlags(fs *flag.FlagSet, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrNotStruct, dst)
+	}
+
+	return WalkStruct(v.Type(), flagTagKey, func(path []string, field reflect.StructField, tag Tag) error {
+		if tag.Options == nil || tag.Name == "-" {
+			return nil
+		}
+		parent, fv := fieldValue(v.Elem(), path)
+		fieldErr := func(key string, err error) error {
+			return &FieldError{Struct: parent.Name(), Field: field.Name, Key: key, Err: err}
+		}
+		if !fv.IsValid() {
+			return nil
+		}
+		if !decodable(field.Type) {
+			return fieldErr("", fmt.Errorf("%w: %v", ErrUnsupportedType, field.Type))
+		}
+		for key := range tag.Options {
+			if key != "usage" && key != "default" {
+				return fieldErr(flagTagKey, fmt.Errorf("%s %q", errUnknownKey, key))
+			}
+		}
+
+		name := tag.Name
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if fs.Lookup(name) != nil {
+			return fieldErr(flagTagKey, fmt.Errorf("flag %q already defined", name))
+		}
+		if def, ok := tag.Options["default"]; ok {
+			if err := setValue(fv, def, newListFormat(0)); err != nil {
+				return fieldErr(flagTagKey, fmt.Errorf("default %q: %w", def, err))
+			}
+		}
+
+		fs.Var(&flagValue{v: fv}, name, tag.Options["usage"])
+		if fv.IsZero() {
+			// Like the flags of the flag package, zero defaults are not printed
+			fs.Lookup(name).DefValue = ""
+		}
+
+		return SkipStruct
+	})
+}
+
+// fieldValue returns the struct holding the field of v at path, by field
+// names, and the field itself, allocating nil pointers to structs on the
+// way. The field is invalid if it is behind a pointer that cannot be set.
+func fieldValue(v reflect.Value, path []string) (reflect.Type, reflect.Value) {
+	for _, name := range path[:len(path)-1] {
+		v = v.FieldByName(name)
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return nil, reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+	}
+
+	return v.Type(), v.FieldByName(path[len(path)-1])
+}
+
+// flagValue is the flag.Value of a field bound by BindFlags.
+type flagValue struct {
+	v   reflect.Value
+	set bool // Set was called, so that slices append
+}
+
+// String returns the value of the field, with slice elements separated by
+// '|', or the empty string for the zero flagValue the flag package creates.
+func (f *flagValue) String() string {
+	v := f.v
+	for v.IsValid() && v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+	if v.Kind() == reflect.Slice && !reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elems[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+
+		return strings.Join(elems, defaultListSep)
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+// Set stores s in the field, appending to the elements set by earlier
+// calls for a slice.
+func (f *flagValue) Set(s string) error {
+	list := newListFormat(0)
+	if f.v.Kind() != reflect.Slice || !f.set || reflect.PointerTo(f.v.Type()).Implements(textUnmarshalerType) {
+		f.set = true
+
+		return setValue(f.v, s, list)
+	}
+
+	elems := reflect.New(f.v.Type()).Elem()
+	if err := setValue(elems, s, list); err != nil {
+		return err
+	}
+	f.v.Set(reflect.AppendSlice(f.v, elems))
+
+	return nil
+}
+
+// IsBoolFlag lets bool flags be set without a value.
+func (f *flagValue) IsBoolFlag() bool {
+	return f.v.IsValid() && f.v.Kind() == reflect.Bool
+}
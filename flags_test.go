@@ -0,0 +1,96 @@
+package tagparser
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flagTLS struct {
+	Cert string `flag:"tls-cert,usage='certificate file'"`
+}
+
+type flagOptions struct {
+	Addr    string        `flag:"addr,usage='listen address',default=:8080"`
+	Timeout time.Duration `flag:"timeout,default=30s"`
+	Verbose bool          `flag:"v,usage='log requests'"`
+	Retries int           `flag:""`
+	Tags    []string      `flag:"tag,default=a|b"`
+	Limit   *float64      `flag:"limit"`
+	TLS     *flagTLS
+	Skipped string `flag:"-"`
+	Plain   string
+}
+
+func TestBindFlags(t *testing.T) {
+	var opts flagOptions
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	require.NoError(t, BindFlags(fs, &opts))
+	assert.Equal(t, ":8080", opts.Addr)
+	assert.Equal(t, 30*time.Second, opts.Timeout)
+	assert.Equal(t, []string{"a", "b"}, opts.Tags)
+	assert.Nil(t, fs.Lookup("skipped"))
+	assert.Nil(t, fs.Lookup("plain"))
+
+	require.NoError(t, fs.Parse([]string{
+		"-addr", "localhost:9000", "-timeout=1m", "-v", "-retries", "3",
+		"-tag", "x", "-tag", "y|z", "-limit", "0.5", "-tls-cert", "cert.pem", "arg",
+	}))
+	limit := 0.5
+	assert.Equal(t, flagOptions{
+		Addr:    "localhost:9000",
+		Timeout: time.Minute,
+		Verbose: true,
+		Retries: 3,
+		Tags:    []string{"x", "y", "z"},
+		Limit:   &limit,
+		TLS:     &flagTLS{Cert: "cert.pem"},
+	}, opts)
+	assert.Equal(t, []string{"arg"}, fs.Args())
+
+	var usage bytes.Buffer
+	fs.SetOutput(&usage)
+	fs.PrintDefaults()
+	assert.Contains(t, usage.String(), "listen address (default :8080)")
+	assert.Contains(t, usage.String(), "(default a|b)")
+	assert.Contains(t, usage.String(), "-v\tlog requests")
+	assert.NotContains(t, usage.String(), "default 0")
+
+	err := fs.Parse([]string{"-retries", "many"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid value "many" for flag -retries`)
+}
+
+func TestBindFlags_Errors(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	require.ErrorIs(t, BindFlags(fs, flagOptions{}), ErrNotStruct)
+	require.ErrorIs(t, BindFlags(fs, (*flagOptions)(nil)), ErrNotStruct)
+
+	var unknown struct {
+		A string `flag:"a,help=x"`
+	}
+	assert.EqualError(t, BindFlags(fs, &unknown), `.A: flag tag: unknown key "help"`)
+
+	var badDefault struct {
+		N int `flag:"n,default=x"`
+	}
+	err := BindFlags(fs, &badDefault)
+	var fieldErr *FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "N", fieldErr.Field)
+
+	var unsupported struct {
+		M map[string]string `flag:"m"`
+	}
+	require.ErrorIs(t, BindFlags(fs, &unsupported), ErrUnsupportedType)
+
+	var dup struct {
+		A string `flag:"x"`
+		B string `flag:"x"`
+	}
+	assert.EqualError(t, BindFlags(flag.NewFlagSet("dup", flag.ContinueOnError), &dup), `.B: flag tag: flag "x" already defined`)
+}
@@ -0,0 +1,334 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotRepresentable is returned when a tag cannot be written in the syntax
+// of a dialect, such as a value for a dialect whose items have no values.
+var ErrNotRepresentable = errors.New("not representable")
+
+// Convert parses tag in the dialect from and writes it in the dialect to,
+// keeping the order of the options, as in
+//
+//	tagparser.Convert(`column:id;NOT NULL`, tagparser.DialectGorm, tagparser.DialectSpec{})
+//	// "column=id,not null"
+//
+// The name is kept when both dialects have one. Items are written with the
+// separators of to and quoted or escaped as its syntax requires. Tags that
+// to cannot express, such as a name for a dialect without names or values
+// for the json dialect, are reported with an error wrapping
+// ErrNotRepresentable. The result is parsed back with to, so that its
+// post-processing can reject it too.
+func Convert(tag string, from, to Dialect) (string, error) {
+	parsed, err := parseDialect(from, tag)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Name != "" && !to.HasName() {
+		return "", fmt.Errorf("name %q: %w in a dialect without names", parsed.Name, ErrNotRepresentable)
+	}
+
+	keys := from.Parser().sourceKeys(tag, parsed, from.HasName())
+	out, err := to.Parser().format(parsed, to.HasName(), keys, false)
+	if err != nil {
+		return "", err
+	}
+	if _, err := parseDialect(to, out); err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+// Canonicalize parses tag like ParseWithName and writes it back in
+// canonical form, so that equivalent tags compare equal and generated code
+// diffs stay stable: the name comes first, followed by the options sorted by
+// key, without whitespace around items. Values are quoted only when they
+// contain a comma or surrounding whitespace, and other special characters
+// are escaped:
+//
+//	tagparser.Canonicalize(` name , min = 5, max='10', omitempty `)
+//	// "name,max=10,min=5,omitempty"
+//
+// Duplicate keys keep their last value, and an empty name is omitted when
+// the first option has a value.
+func Canonicalize(tag string) (string, error) {
+	return defaultParser.Canonicalize(tag)
+}
+
+// Canonicalize writes tag in canonical form, like the package-level
+// Canonicalize, in the syntax of p.
+func (p *Parser) Canonicalize(tag string) (string, error) {
+	t, err := p.ParseWithName(tag)
+	if err != nil {
+		return "", err
+	}
+
+	return p.format(t, true, t.Keys(), false)
+}
+
+// Minify parses tag like ParseWithName and writes it back without
+// insignificant whitespace and quoting, keeping the order of the options,
+// for compact generated code:
+//
+//	tagparser.Minify(` name , min = 5, max='10', msg='a b', omitempty `)
+//	// "name,min=5,max=10,msg=a b,omitempty"
+//
+// Quotes are kept only where a value needs them, and an empty value as in
+// `default=` stays told apart from a flag. A repeated key is written once,
+// where it first appears, with its last value.
+func Minify(tag string) (string, error) {
+	return defaultParser.Minify(tag)
+}
+
+// Normalize is like Minify but sorts the options by key, for tags that
+// compare equal whenever they hold the same options:
+//
+//	tagparser.Normalize(` name , min = 5, max='10', omitempty `)
+//	// "name,max=10,min=5,omitempty"
+//
+// It differs from Canonicalize only in keeping `default=` apart from
+// `default`, as Lookup does.
+func Normalize(tag string) (string, error) {
+	return defaultParser.Normalize(tag)
+}
+
+// Minify writes tag compactly in the syntax of p, like the package-level
+// Minify.
+func (p *Parser) Minify(tag string) (string, error) {
+	tag = p.unquoteGo(tag)
+	t, err := p.result(p.parseUnquoted(tag, true))
+	if err != nil {
+		return "", err
+	}
+
+	return p.format(t, true, p.sourceKeys(tag, t, true), true)
+}
+
+// Normalize writes tag compactly with sorted options in the syntax of p,
+// like the package-level Normalize.
+func (p *Parser) Normalize(tag string) (string, error) {
+	t, err := p.ParseWithName(tag)
+	if err != nil {
+		return "", err
+	}
+
+	return p.format(t, true, t.Keys(), true)
+}
+
+// sourceKeys returns the option keys of t, as parsed from tag, in the order
+// they first appear in tag.
+func (p *Parser) sourceKeys(tag string, t *Tag, withName bool) []string {
+	keys := make([]string, 0, len(t.Options))
+	seen := make(map[string]bool, len(t.Options))
+	for key := range p.items(tag, withName) {
+		if _, ok := t.Options[key]; ok && key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// Equal parses a and b like Parse and reports whether they hold the same
+// options, as Tag.Equal does, so that code generators can tell whether a tag
+// actually changed:
+//
+//	tagparser.Equal(`min=1, max='5'`, `max=5,min=1`) // true, nil
+//
+// Keys, unescaped values and flags are compared; order, whitespace, quoting
+// and escaping are not. A malformed a or b is reported with its error.
+func Equal(a, b string) (bool, error) {
+	return defaultParser.Equal(a, b)
+}
+
+// EqualWithName is like Equal but parses a and b like ParseWithName, so
+// that their names are compared too.
+func EqualWithName(a, b string) (bool, error) {
+	return defaultParser.EqualWithName(a, b)
+}
+
+// Equal compares a and b parsed with p, like the package-level Equal.
+func (p *Parser) Equal(a, b string) (bool, error) {
+	return p.equal(a, b, false)
+}
+
+// EqualWithName compares a and b parsed with names, like the package-level
+// EqualWithName.
+func (p *Parser) EqualWithName(a, b string) (bool, error) {
+	return p.equal(a, b, true)
+}
+
+func (p *Parser) equal(a, b string, withName bool) (bool, error) {
+	ta, err := p.result(p.parseTag(a, withName))
+	if err != nil {
+		return false, err
+	}
+	tb, err := p.result(p.parseTag(b, withName))
+	if err != nil {
+		return false, err
+	}
+
+	return ta.Equal(tb), nil
+}
+
+// format writes the name of t, when withName is set, and its options keys
+// in the syntax of p. Empty values are written as flags unless keepEmpty is
+// set. An empty name is omitted unless the first option is a flag, which
+// would otherwise be read as the name.
+//
+// A tag that would be read back as a Go string literal, such as `','` for
+// the name ",", has its first item escaped rather than quoted.
+func (p *Parser) format(t *Tag, withName bool, keys []string, keepEmpty bool) (string, error) {
+	isFlag := func(key string) bool {
+		_, hasValue, _ := t.Lookup(key)
+
+		return t.Options[key] == "" && !(keepEmpty && hasValue)
+	}
+	withName = withName && (t.Name != "" || len(keys) > 0 && isFlag(keys[0]))
+
+	var b strings.Builder
+	first := "" // first item, name or key, as written
+	if withName {
+		name, err := p.quoteItem(t.Name, true, nil)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(name)
+		first = t.Name
+	}
+
+	for i, key := range keys {
+		if withName || i > 0 {
+			b.WriteByte(p.sep)
+		}
+		quoted, err := p.quoteItem(key, true, nil)
+		if err != nil {
+			return "", err
+		}
+		if b.Len() == 0 {
+			first = key
+		}
+		b.WriteString(quoted)
+
+		if isFlag(key) {
+			continue
+		}
+		value := t.Options[key]
+		if p.kvSep == 0 {
+			return "", fmt.Errorf("value of %q: %w in a dialect without values", key, ErrNotRepresentable)
+		}
+		quoted, err = p.quoteItem(value, false, t.escapedSeps(key, value))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(p.kvSep)
+		b.WriteString(quoted)
+	}
+
+	return p.unlikeGoLiteral(b.String(), first)
+}
+
+// unlikeGoLiteral returns tag, written by format with first as its first
+// item, rewriting that item with escapes if p would read tag as a Go string
+// literal.
+func (p *Parser) unlikeGoLiteral(tag, first string) (string, error) {
+	if p.unquoteGo(tag) == tag {
+		return tag, nil
+	}
+
+	quoted, err := p.quoteItem(first, true, nil)
+	if err != nil {
+		return "", err
+	}
+	escaped, err := p.writeItem(first, true, nil, true)
+	if err != nil {
+		return "", err
+	}
+	tag = escaped + tag[len(quoted):]
+	if p.unquoteGo(tag) != tag {
+		return "", fmt.Errorf("%q: %w, read as a Go string literal", tag, ErrNotRepresentable)
+	}
+
+	return tag, nil
+}
+
+// quoteItem returns s written so that p reads it back unchanged. Keys and
+// names, for which key is set, also escape the key/value separator. The
+// list separators at the offsets escapedSeps are escaped, so that they stay
+// in their element.
+//
+// Items containing the separator or surrounded by whitespace are quoted when
+// p has quotes; other special characters are escaped with the escape
+// character of p.
+func (p *Parser) quoteItem(s string, key bool, escapedSeps []int) (string, error) {
+	return p.writeItem(s, key, escapedSeps, false)
+}
+
+// writeItem implements quoteItem. With escapeOnly, s starts a tag and is
+// written without quotes, its first character escaped, so that the tag
+// cannot be read as a Go string literal.
+func (p *Parser) writeItem(s string, key bool, escapedSeps []int, escapeOnly bool) (string, error) {
+	special := func(i int) bool {
+		switch c := s[i]; {
+		case p.isSep(c), key && c == p.kvSep && c != 0:
+			return true
+		case c == '(':
+			return key && p.groups
+		case c == p.quote:
+			return !p.literalQuotes
+		case c == p.escape:
+			return !p.noEscapes
+		case c == '!':
+			return key && i == 0 && p.negation
+		default:
+			return false
+		}
+	}
+	trimmed := !p.preserveWhitespace && s != "" &&
+		(asciiSpace[s[0]] != 0 || asciiSpace[s[len(s)-1]] != 0)
+
+	needs := trimmed || escapeOnly || len(escapedSeps) > 0 && !p.noEscapes
+	for i := 0; i < len(s) && !needs; i++ {
+		needs = special(i)
+	}
+	if !needs {
+		return s, nil
+	}
+	if p.noEscapes {
+		if p.literalQuotes || escapeOnly || strings.IndexByte(s, p.quote) >= 0 {
+			return "", fmt.Errorf("%q: %w without escapes", s, ErrNotRepresentable)
+		}
+
+		return string(p.quote) + s + string(p.quote), nil
+	}
+
+	quote := !p.literalQuotes && !escapeOnly && (trimmed || strings.IndexByte(s, p.sep) >= 0)
+	var b strings.Builder
+	if quote {
+		b.WriteByte(p.quote)
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case len(escapedSeps) > 0 && escapedSeps[0] == i:
+			escapedSeps = escapedSeps[1:]
+			b.WriteByte(p.escape)
+		case quote && c != p.quote && c != p.escape:
+			// Quoted text only needs quotes and escape characters escaped
+		case special(i), escapeOnly && i == 0,
+			!quote && trimmed && (i == 0 || i == len(s)-1) && asciiSpace[c] != 0:
+			b.WriteByte(p.escape)
+		}
+		b.WriteByte(c)
+	}
+	if quote {
+		b.WriteByte(p.quote)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,185 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	gorm := DialectGorm
+	plain := DialectSpec{}
+	named := DialectSpec{Name: true}
+	json, _ := LookupDialect("json")
+
+	tests := []struct {
+		tag      string
+		from, to Dialect
+		want     string
+	}{
+		{`column:id;NOT NULL;default:0`, gorm, plain, `column=id,not null,default=0`},
+		{`check:a,b;comment:'x'`, gorm, plain, `check='a,b',comment=\'x\'`},
+		{`a=1,b='x;y',c`, plain, gorm, `a:1;b:x\;y;c`},
+		{`name,omitempty,min=5`, named, named, `name,omitempty,min=5`},
+		{`,omitempty`, named, json, `,omitempty`},
+		{`name,string`, json, named, `name,string`},
+		{`it's,string`, json, named, `,string`},
+		{`a=' x ',b=\=`, plain, plain, `a=' x ',b==`},
+		{`'a=b',x`, named, named, `a\=b,x`},
+		{`pattern=a\\b`, plain, gorm, `pattern:a\\b`},
+		{``, named, plain, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := Convert(tt.tag, tt.from, tt.to)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// The result reads back the same
+			want, err := parseDialect(tt.from, tt.tag)
+			require.NoError(t, err)
+			back, err := parseDialect(tt.to, got)
+			require.NoError(t, err)
+			assert.Equal(t, want.Options, back.Options)
+		})
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	named := DialectSpec{Name: true}
+	json, _ := LookupDialect("json")
+	env, _ := LookupDialect("env")
+
+	_, err := Convert(`name,a=1`, named, DialectSpec{})
+	require.ErrorIs(t, err, ErrNotRepresentable)
+
+	_, err = Convert(`name,min=5`, named, json)
+	require.ErrorIs(t, err, ErrNotRepresentable)
+
+	_, err = Convert(`name,'a,b'`, named, json)
+	require.ErrorIs(t, err, ErrNotRepresentable)
+
+	// Rejected by the post-processing of the target
+	_, err = Convert(`PORT,optional`, named, env)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeUnknownKey, parseErr.Code)
+
+	// Syntax errors in the source
+	_, err = Convert(`a=\x`, named, named)
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeInvalidEscape, parseErr.Code)
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		equal    bool
+		withName bool
+	}{
+		{`min=1, max='5'`, `max=5,min=1`, true, true},
+		{`a\,b=x`, `'a,b'=x`, true, true},
+		{`a=1,a=2`, `a=2`, true, true},
+		{`a,b`, `b,a`, true, false},
+		{`a,b`, `b,a`, false, true},
+		{`a`, `a=`, false, false},
+		{`a=1`, `a=2`, false, false},
+		{``, ` `, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			equal := Equal
+			if tt.withName {
+				equal = EqualWithName
+			}
+			got, err := equal(tt.a, tt.b)
+			require.NoError(t, err)
+			assert.Equal(t, tt.equal, got)
+		})
+	}
+
+	_, err := Equal(`a`, `b='`)
+	assert.EqualError(t, err, `unterminated quote (at 3)`)
+
+	got, err := New(WithCaseInsensitiveKeys()).Equal(`Min=1`, `min=1`)
+	require.NoError(t, err)
+	assert.True(t, got)
+}
+
+func TestMinify(t *testing.T) {
+	tests := []struct {
+		tag, minified, normalized string
+	}{
+		{` name , min = 5, max='10', msg='a b', omitempty `, `name,min=5,max=10,msg=a b,omitempty`, `name,max=10,min=5,msg=a b,omitempty`},
+		{`"id,omitempty"`, `id,omitempty`, `id,omitempty`},
+		{`b=1, a=, b=2`, `b=2,a=`, `a=,b=2`},
+		{`, z, a`, `,z,a`, `,a,z`},
+		{` ,z=1`, `z=1`, `z=1`},
+		{`'a,b', x=' c', y=\,`, `'a,b',x=' c',y=','`, `'a,b',x=' c',y=','`},
+		{`  `, ``, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			minified, err := Minify(tt.tag)
+			require.NoError(t, err)
+			assert.Equal(t, tt.minified, minified)
+			same, err := EqualWithName(tt.tag, minified)
+			require.NoError(t, err)
+			assert.True(t, same)
+
+			normalized, err := Normalize(tt.tag)
+			require.NoError(t, err)
+			assert.Equal(t, tt.normalized, normalized)
+		})
+	}
+
+	_, err := Minify(`a='`)
+	assert.EqualError(t, err, `unterminated quote (at 3)`)
+	_, err = Normalize(`a='`)
+	assert.EqualError(t, err, `unterminated quote (at 3)`)
+
+	out, err := gormParser.Minify(` column : id ; NOT NULL `)
+	require.NoError(t, err)
+	assert.Equal(t, `column:id;not null`, out)
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		tag, want string
+	}{
+		{``, ``},
+		{`name`, `name`},
+		{` name , min = 5, max='10', omitempty `, `name,max=10,min=5,omitempty`},
+		{`b=2,a=1`, `a=1,b=2`},
+		{`,omitempty,a=1`, `a=1,omitempty`},
+		{`,b,a`, `,a,b`},
+		{`x=1,x=2`, `x=2`},
+		{`msg='a, b',sep=' ',path=C:\\dir,q=it\'s`, `msg='a, b',path=C:\\dir,q=it\'s,sep=' '`},
+		{`"quoted,min=1"`, `quoted,min=1`},
+		// Tags that would read as Go string literals are escaped instead
+		{`\,`, `\,`},
+		{"'\\\t'", "\\\t"},
+		{`\"a,k=b"`, `\"a,k=b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := Canonicalize(tt.tag)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// Canonical tags are fixed points
+			again, err := Canonicalize(got)
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+
+	_, err := Canonicalize(`a,=1`)
+	require.Error(t, err)
+
+	// Custom parsers write their own syntax
+	got, err := New(WithSeparator(';'), WithKeyValueSeparator(':')).Canonicalize(`id;type:int;index:a,b`)
+	require.NoError(t, err)
+	assert.Equal(t, `id;index:a,b;type:int`, got)
+}
@@ -0,0 +1,101 @@
+package tagparser
+
+// WithGroups enables groups: an option whose key is followed by
+// parentheses, as in `oneof(a,b,c)` or `unique(scope=tenant)`, takes the
+// text between them as its value. Separators, key/value separators and
+// balanced parentheses inside a group do not end it, so rule dialects can
+// nest options without quoting them:
+//
+//	p := New(WithGroups())
+//	tag, _ := p.Parse(`oneof(a,b,c),unique(scope=tenant)`)
+//	// tag.Options == map[string]string{"oneof": "a,b,c", "unique": "scope=tenant"}
+//
+// The value of a group keeps its quotes and escapes, so that it can be
+// parsed in turn with Tag.Group. Only whitespace may follow the closing
+// parenthesis, and an opening parenthesis after the key/value separator,
+// as in `k=f(x)`, is an ordinary character. An unclosed group is reported
+// with CodeUnterminatedGroup and text after it with CodeInvalidGroup.
+//
+// New panics if a separator of the Parser is a parenthesis.
+func WithGroups() Option {
+	return func(p *Parser) {
+		p.groups = true
+	}
+}
+
+func isParen(c byte) bool {
+	return c == '(' || c == ')'
+}
+
+// groupParser returns p if it has groups, for the tags it produces.
+func (p *Parser) groupParser() *Parser {
+	if p.groups {
+		return p
+	}
+
+	return nil
+}
+
+// Group parses the value of a group option, such as the `scope=tenant` of
+// `unique(scope=tenant)`, with the Parser that produced t, treating all
+// items as options:
+//
+//	unique, err := tag.Group("unique")
+//	// unique.Options == map[string]string{"scope": "tenant"}
+//
+// Tags from a Parser without WithGroups parse the value with Parse. Group
+// returns nil and no error if the key is absent.
+func (t *Tag) Group(key string) (*Tag, error) {
+	value, ok := t.Options[key]
+	if !ok {
+		return nil, nil //nolint:nilnil // an absent key is not an error
+	}
+
+	p := t.groups
+	if p == nil {
+		p = defaultParser
+	}
+
+	return p.Parse(value)
+}
+
+// openGroup starts a group at the opening parenthesis at pos, taking the
+// text before it as the key.
+func (p *parser) openGroup() error {
+	p.group, p.groupDepth, p.groupStart = true, 1, p.pos
+
+	return p.setKey()
+}
+
+// scanGroup processes an unquoted byte of a group item.
+func (p *parser) scanGroup(c byte) error {
+	switch {
+	case p.groupDepth == 0:
+		if asciiSpace[c] == 0 {
+			return p.fail(p.errorAt(p.pos, CodeInvalidGroup))
+		}
+	case c == p.cfg.quote && !p.cfg.literalQuotes:
+		p.inQuote = true
+	case c == p.cfg.escape && !p.cfg.noEscapes:
+		return p.consumeEscape()
+	case c == '(':
+		p.groupDepth++
+	case c == ')':
+		p.groupDepth--
+		if p.groupDepth == 0 {
+			p.groupEnd = p.pos
+		}
+	}
+
+	return nil
+}
+
+// valueEnd returns the end of the value of the current item: the closing
+// parenthesis of a group, or the end of the item.
+func (p *parser) valueEnd() int {
+	if p.group && p.groupDepth == 0 {
+		return p.groupEnd
+	}
+
+	return p.pos
+}
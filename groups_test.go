@@ -0,0 +1,125 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithGroups(t *testing.T) {
+	p := New(WithGroups())
+
+	tests := []struct {
+		tag  string
+		want M
+	}{
+		{`oneof(a,b,c),unique(scope=tenant)`, M{"oneof": "a,b,c", "unique": "scope=tenant"}},
+		{`a( x , y ) , b`, M{"a": "x , y", "b": ""}},
+		{`a(b(c,d)),e`, M{"a": "b(c,d)", "e": ""}},
+		{`a('x)',y)`, M{"a": "'x)',y"}},
+		{`a(\),b)`, M{"a": `\),b`}},
+		{`empty()`, M{"empty": ""}},
+		{`k=f(x),y`, M{"k": "f(x)", "y": ""}},
+		{`a)`, M{"a)": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			tag, err := p.Parse(tt.tag)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tag.Options)
+		})
+	}
+
+	tag, err := p.ParseWithName(`id,oneof(a,b)`)
+	require.NoError(t, err)
+	assert.Equal(t, "id", tag.Name)
+	assert.Equal(t, M{"oneof": "a,b"}, tag.Options)
+
+	// Without the option parentheses are ordinary characters
+	tag, err = Parse(`f(a),b)`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"f(a)": "", "b)": ""}, tag.Options)
+}
+
+func TestWithGroups_Errors(t *testing.T) {
+	p := New(WithGroups())
+
+	_, err := p.Parse(`a,b(c,d`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeUnterminatedGroup, parseErr.Code)
+	assert.Equal(t, 3, parseErr.Pos)
+	assert.Equal(t, "b(c,d", parseErr.Segment)
+
+	_, err = p.Parse(`a(b)c,d`)
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeInvalidGroup, parseErr.Code)
+	assert.EqualError(t, err, "text after group (at 5)")
+	assert.Equal(t, "a(b)c", parseErr.Segment)
+
+	_, err = p.Parse(`(a)`)
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeEmptyKey, parseErr.Code)
+
+	tag, err := New(WithGroups(), WithLenient()).Parse(`a(b)c,d(e,f),g(h`)
+	assert.EqualError(t, err, "text after group (at 5)\nunterminated group (at 15)")
+	assert.Equal(t, M{"d": "e,f"}, tag.Options)
+
+	assert.Panics(t, func() { New(WithGroups(), WithSeparator('(')) })
+	assert.Panics(t, func() { New(WithGroups(), WithListSeparator(')')) })
+}
+
+func TestTag_Group(t *testing.T) {
+	p := New(WithGroups(), WithKeyValueSeparator(':'))
+
+	tag, err := p.Parse(`unique(scope:tenant,where:'a,b'),oneof(a,b)`)
+	require.NoError(t, err)
+
+	unique, err := tag.Group("unique")
+	require.NoError(t, err)
+	assert.Equal(t, M{"scope": "tenant", "where": "a,b"}, unique.Options)
+
+	only := tag.Only("oneof")
+	oneof, err := only.Group("oneof")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, oneof.Keys())
+
+	missing, err := tag.Group("missing")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	// Tags from other parsers use Parse
+	tag, err = Parse(`g='x=1'`)
+	require.NoError(t, err)
+	g, err := tag.Group("g")
+	require.NoError(t, err)
+	assert.Equal(t, M{"x": "1"}, g.Options)
+}
+
+func TestWithGroups_Syntax(t *testing.T) {
+	p := New(WithGroups())
+
+	items, err := p.ParseDetailed(`a(b,c) ,d`)
+	require.NoError(t, err)
+	assert.Equal(t, Item{
+		Key: "a", Value: "b,c", Raw: "a(b,c) ", RawKey: "a", RawValue: "b,c", ValuePos: 2,
+	}, items[0])
+
+	cst, err := p.ParseCST(`x=1, a( b,c ) ,d`)
+	require.NoError(t, err)
+	assert.Equal(t, `x=1, a( b,c ) ,d`, cst.Render())
+	assert.Equal(t, "( ", cst.Items[1].Assign)
+	require.NoError(t, cst.SetValue(1, "e,f"))
+	assert.Equal(t, `x=1, a='e,f' ,d`, cst.Render())
+	require.NoError(t, cst.Insert(2, "g", "h"))
+	assert.Equal(t, `x=1, a='e,f' , g=h,d`, cst.Render())
+
+	// Keys with parentheses are escaped
+	s, err := p.Canonicalize(`'f(x)'=1, a(b,c)`)
+	require.NoError(t, err)
+	assert.Equal(t, `a='b,c',f\(x)=1`, s)
+	tag, err := p.Parse(s)
+	require.NoError(t, err)
+	assert.Equal(t, M{"f(x)": "1", "a": "b,c"}, tag.Options)
+}
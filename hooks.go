@@ -0,0 +1,80 @@
+package tagparser
+
+import "time"
+
+// Hooks are functions called around the parses of a Parser, see WithHooks.
+// Nil functions are skipped.
+type Hooks struct {
+	// OnParseStart is called with the tag, unquoted, before it is parsed.
+	OnParseStart func(tag string)
+
+	// OnParseEnd is called after the tag is parsed, with the time the parse
+	// took and its error, nil on success.
+	OnParseEnd func(tag string, elapsed time.Duration, err error)
+
+	// OnError is called with the error of a failed parse, before OnParseEnd.
+	// The error of a lenient Parser lists every malformed item.
+	OnError func(tag string, err error)
+}
+
+// WithHooks sets functions called around each parse of a tag into a Tag,
+// for services recording parse counts, durations and error rates without
+// wrapping every call site:
+//
+//	p := tagparser.New(tagparser.WithHooks(tagparser.Hooks{
+//	    OnParseEnd: func(tag string, elapsed time.Duration, err error) {
+//	        parseDuration.Observe(elapsed.Seconds())
+//	    },
+//	    OnError: func(tag string, err error) {
+//	        parseErrors.Inc()
+//	    },
+//	}))
+//
+// The hooks run for Parse, ParseAll, ParseInto, ParseBatch, ParseStructTag,
+// LazyTag.Tag and their WithName variants, but not for ParseDiag or for
+// scans such as ParseFunc, GetOption or Validate, nor for the cache hits of
+// a CachedParser, which are not parsed again. They are called
+// synchronously and may be called concurrently when the Parser is shared.
+// A later call replaces the hooks of an earlier one.
+func WithHooks(h Hooks) Option {
+	return func(p *Parser) {
+		p.hooks = &h
+	}
+}
+
+// fillObserved is fill with the hooks and metrics of p around the parse.
+func (p *Parser) fillObserved(t *Tag, tag string, withName bool) error {
+	h := p.hooks
+	if h == nil {
+		h = &Hooks{}
+	}
+	if h.OnParseStart != nil {
+		h.OnParseStart(tag)
+	}
+	var start time.Time
+	if h.OnParseEnd != nil || p.metrics != nil {
+		start = time.Now()
+	}
+
+	err := p.fillTag(t, tag, withName)
+
+	var elapsed time.Duration
+	if !start.IsZero() {
+		elapsed = time.Since(start)
+	}
+	if m := p.metrics; m != nil {
+		m.IncParsed()
+		if err != nil {
+			m.IncErrors()
+		}
+		m.ObserveDuration(elapsed)
+	}
+	if err != nil && h.OnError != nil {
+		h.OnError(tag, err)
+	}
+	if h.OnParseEnd != nil {
+		h.OnParseEnd(tag, elapsed, err)
+	}
+
+	return err
+}
@@ -0,0 +1,87 @@
+package tagparser
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type hookRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *hookRecorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *hookRecorder) hooks() Hooks {
+	return Hooks{
+		OnParseStart: func(tag string) {
+			r.record("start " + tag)
+		},
+		OnParseEnd: func(tag string, elapsed time.Duration, err error) {
+			if elapsed < 0 {
+				r.record("negative duration")
+			}
+			r.record("end " + tag + " " + errString(err))
+		},
+		OnError: func(tag string, err error) {
+			r.record("error " + tag + " " + errString(err))
+		},
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "ok"
+	}
+
+	return err.Error()
+}
+
+func TestWithHooks(t *testing.T) {
+	var rec hookRecorder
+	p := New(WithHooks(rec.hooks()))
+
+	_, err := p.ParseWithName(`"id,omitempty"`)
+	require.NoError(t, err)
+	_, err = p.Parse(`a='b`)
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"start id,omitempty",
+		"end id,omitempty ok",
+		"start a='b",
+		"error a='b unterminated quote (at 3)",
+		"end a='b unterminated quote (at 3)",
+	}, rec.events)
+
+	// Other methods building a Tag run the hooks, scans do not
+	rec.events = nil
+	var dst Tag
+	require.NoError(t, p.ParseInto(&dst, `x`))
+	_, errs := p.ParseBatch([]string{"a", "b"})
+	assert.Nil(t, errs)
+	_, err = p.ParseLazy(`c`).Tag()
+	require.NoError(t, err)
+	assert.True(t, p.HasOption(`d`, "d"))
+	require.NoError(t, p.Validate(`e`))
+	assert.ElementsMatch(t, []string{
+		"start x", "end x ok",
+		"start a", "end a ok",
+		"start b", "end b ok",
+		"start c", "end c ok",
+	}, rec.events)
+
+	// Nil hooks are skipped
+	only := New(WithHooks(Hooks{OnError: rec.hooks().OnError}))
+	rec.events = nil
+	_, err = only.Parse(`ok`)
+	require.NoError(t, err)
+	assert.Empty(t, rec.events)
+}
@@ -0,0 +1,52 @@
+package tagparser
+
+import "iter"
+
+// Options returns an iterator over the options of a tag, treating all items
+// as options like ParseFunc:
+//
+//	for key, value := range tagparser.Options(`json,omitempty,min=5`) {
+//	    // ...
+//	}
+//
+// Iteration stops at the first malformed item; use ParseFunc when the error
+// matters. Breaking out of the loop stops parsing. The iterator does not
+// allocate on well-formed input.
+func Options(tag string) iter.Seq2[string, string] {
+	return defaultParser.Options(tag)
+}
+
+// OptionsWithName returns an iterator over the items of a tag treating the
+// first item as a name, like ParseFuncWithName. The name is yielded first
+// with an empty key.
+func OptionsWithName(tag string) iter.Seq2[string, string] {
+	return defaultParser.OptionsWithName(tag)
+}
+
+// Options returns an iterator over the options of a tag, like the
+// package-level Options. A lenient Parser skips malformed items instead of
+// stopping at them.
+func (p *Parser) Options(tag string) iter.Seq2[string, string] {
+	return p.items(tag, false)
+}
+
+// OptionsWithName returns an iterator over the items of a tag treating the
+// first item as a name, like the package-level OptionsWithName.
+func (p *Parser) OptionsWithName(tag string) iter.Seq2[string, string] {
+	return p.items(tag, true)
+}
+
+func (p *Parser) items(tag string, withName bool) iter.Seq2[string, string] {
+	return func(yield func(key, value string) bool) {
+		ps := parser{cfg: p, tag: tag, treatFirstAsName: withName}
+		if ps.checkLength() != nil {
+			return
+		}
+		for {
+			key, value, ok, err := ps.next()
+			if err != nil || !ok || !yield(key, value) {
+				return
+			}
+		}
+	}
+}
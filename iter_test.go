@@ -0,0 +1,55 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	opts := make(M)
+	for key, value := range Options(`alfa,bravo='charlie, delta',echo=foxtrot`) {
+		opts[key] = value
+	}
+	assert.Equal(t, M{"alfa": "", "bravo": "charlie, delta", "echo": "foxtrot"}, opts)
+}
+
+func TestOptionsWithName(t *testing.T) {
+	var keys, values []string
+	for key, value := range OptionsWithName(`alfa,bravo=charlie`) {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	assert.Equal(t, []string{"", "bravo"}, keys)
+	assert.Equal(t, []string{"alfa", "charlie"}, values)
+}
+
+func TestOptions_Break(t *testing.T) {
+	for _, p := range []*Parser{New(), New(WithLenient())} {
+		var keys []string
+		for key := range p.Options(`alfa,bravo,charlie`) {
+			keys = append(keys, key)
+			if key == "bravo" {
+				break
+			}
+		}
+		assert.Equal(t, []string{"alfa", "bravo"}, keys)
+	}
+}
+
+func TestOptions_StopsAtError(t *testing.T) {
+	var keys []string
+	for key := range Options(`alfa,=bravo,charlie`) {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"alfa"}, keys)
+}
+
+func TestOptions_ZeroAlloc(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		for key, value := range Options(benchTagSimple) {
+			_, _ = key, value
+		}
+	})
+	assert.Zero(t, allocs)
+}
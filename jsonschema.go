@@ -0,0 +1,147 @@
+package tagparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// jsonSchemaDialect is the JSON Schema version JSONSchema emits.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// valuePatterns are the patterns of the string values of typed keys. They
+// follow the Go syntax closely but not exactly: hexadecimal floats and
+// underscores in decimal numbers are not matched.
+var valuePatterns = map[ValueType]string{
+	TypeInt:      `^[+-]?(0[bB][01_]+|0[oO]?[0-7_]+|0[xX][0-9a-fA-F_]+|[1-9][0-9_]*|0)$`,
+	TypeFloat:    `^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$|^[+-]?([iI][nN][fF]([iI][nN][iI][tT][yY])?|[nN][aA][nN])$`,
+	TypeDuration: `^[+-]?(([0-9]+\.?[0-9]*|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+$|^[+-]?0$`,
+}
+
+// jsonBoolValues are the values strconv.ParseBool accepts, and the empty
+// string of a bare flag.
+var jsonBoolValues = []string{"", "1", "t", "T", "TRUE", "true", "True", "0", "f", "F", "FALSE", "false", "False"}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) document describing the
+// tags the schema accepts in the form Tag.MarshalJSON encodes them, so that
+// editors and configuration validators can share the definition:
+//
+//	doc, err := schema.JSONSchema()
+//	os.WriteFile("validate.schema.json", doc, 0o644)
+//
+// Flags are empty strings, and the values of other keys are strings
+// matching their type, their Enum and their Default. Descriptions,
+// deprecations, required keys, RequiredWith and Conflicts are declared too,
+// while AtMost has no equivalent and is left out. An error is returned for
+// a key of unknown type.
+func (s *Schema) JSONSchema() ([]byte, error) {
+	props := make(map[string]any, len(s.Keys))
+	var required []string
+	dependentRequired := make(map[string]any)
+	dependentSchemas := make(map[string]any)
+	for _, key := range slices.Sorted(maps.Keys(s.Keys)) {
+		spec := s.Keys[key]
+		prop, err := spec.jsonSchema()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key, err)
+		}
+		props[key] = prop
+		if spec.Required {
+			required = append(required, key)
+		}
+		if len(spec.RequiredWith) > 0 {
+			dependentRequired[key] = spec.RequiredWith
+		}
+		if len(spec.Conflicts) > 0 {
+			conflicts := make([]any, len(spec.Conflicts))
+			for i, other := range spec.Conflicts {
+				conflicts[i] = map[string]any{"required": []string{other}}
+			}
+			dependentSchemas[key] = map[string]any{"not": map[string]any{"anyOf": conflicts}}
+		}
+	}
+
+	var unknown any = false
+	if s.AllowUnknown {
+		unknown = map[string]any{"type": "string"}
+	}
+	options := map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": unknown,
+	}
+	if required != nil {
+		options["required"] = required
+	}
+	if len(dependentRequired) > 0 {
+		options["dependentRequired"] = dependentRequired
+	}
+	if len(dependentSchemas) > 0 {
+		options["dependentSchemas"] = dependentSchemas
+	}
+
+	name := map[string]any{"type": "string"}
+	if !s.WithName {
+		name = map[string]any{"const": ""}
+	}
+	properties := map[string]any{
+		"Name":        name,
+		"Options":     options,
+		"EmptyValues": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	}
+	doc := map[string]any{
+		"$schema":              jsonSchemaDialect,
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required != nil {
+		doc["required"] = []string{"Options"}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// jsonSchema returns the JSON Schema of the values of the key.
+func (k KeySpec) jsonSchema() (map[string]any, error) {
+	prop, err := k.jsonType()
+	if err != nil {
+		return nil, err
+	}
+	if k.Description != "" {
+		prop["description"] = k.Description
+	}
+	if k.Deprecated != "" {
+		prop["deprecated"] = true
+	}
+
+	return prop, nil
+}
+
+// jsonType returns the JSON Schema of the type, enum and default of the
+// values of the key.
+func (k KeySpec) jsonType() (map[string]any, error) {
+	if k.Flag {
+		return map[string]any{"const": ""}, nil
+	}
+
+	prop := map[string]any{"type": "string"}
+	switch k.Type {
+	case TypeBool:
+		prop = map[string]any{"enum": jsonBoolValues}
+	case TypeInt, TypeFloat, TypeDuration:
+		prop["pattern"] = valuePatterns[k.Type]
+	case TypeString, TypeEnum:
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownValueType, int(k.Type))
+	}
+	if k.Type == TypeEnum || k.Enum != nil {
+		prop["enum"] = append([]string{}, k.Enum...)
+	}
+	if k.Default != "" {
+		prop["default"] = k.Default
+	}
+
+	return prop, nil
+}
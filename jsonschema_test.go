@@ -0,0 +1,87 @@
+package tagparser
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_JSONSchema(t *testing.T) {
+	schema := Schema{
+		WithName: true,
+		Keys: map[string]KeySpec{
+			"omitempty": {Flag: true},
+			"min":       {Type: TypeInt, Default: "0", AtMost: "max"},
+			"max":       {Type: TypeInt},
+			"format":    {Type: TypeEnum, Enum: []string{"email", "url"}, Required: true},
+			"user":      {RequiredWith: []string{"password"}},
+			"password":  {},
+			"inline":    {Type: TypeBool, Conflicts: []string{"ref"}},
+		},
+	}
+	doc, err := schema.JSONSchema()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["Options"],
+		"properties": {
+			"Name": {"type": "string"},
+			"EmptyValues": {"type": "array", "items": {"type": "string"}},
+			"Options": {
+				"type": "object",
+				"additionalProperties": false,
+				"required": ["format"],
+				"dependentRequired": {"user": ["password"]},
+				"dependentSchemas": {"inline": {"not": {"anyOf": [{"required": ["ref"]}]}}},
+				"properties": {
+					"omitempty": {"const": ""},
+					"min": {"type": "string", "pattern": `+jsonString(valuePatterns[TypeInt])+`, "default": "0"},
+					"max": {"type": "string", "pattern": `+jsonString(valuePatterns[TypeInt])+`},
+					"format": {"type": "string", "enum": ["email", "url"]},
+					"user": {"type": "string"},
+					"password": {"type": "string"},
+					"inline": {"enum": ["", "1", "t", "T", "TRUE", "true", "True", "0", "f", "F", "FALSE", "false", "False"]}
+				}
+			}
+		}
+	}`, string(doc))
+
+	lax := Schema{AllowUnknown: true}
+	doc, err = lax.JSONSchema()
+	require.NoError(t, err)
+	assert.Contains(t, string(doc), `"additionalProperties": {
+        "type": "string"
+      }`)
+	assert.Contains(t, string(doc), `"const": ""`)
+
+	bad := Schema{Keys: map[string]KeySpec{"x": {Type: ValueType(42)}}}
+	_, err = bad.JSONSchema()
+	assert.ErrorIs(t, err, ErrUnknownValueType)
+}
+
+func TestSchema_JSONSchemaPatterns(t *testing.T) {
+	tests := map[ValueType][]string{
+		TypeInt:      {"0", "42", "-7", "+1", "0x1F", "0b101", "0o17", "017", "1_000", "x", "1.5", "", "09a"},
+		TypeFloat:    {"0", "1.5", "-.5", "1e3", "2.5E-3", "Inf", "-inf", "NaN", "1.", "x", "1e", "", "."},
+		TypeDuration: {"0", "1s", "1h30m", "-1.5h", "300ms", "2µs", "1", "1d", "", "s", "1.5"},
+	}
+	for typ, values := range tests {
+		re := regexp.MustCompile(valuePatterns[typ])
+		spec := KeySpec{Type: typ}
+		for _, value := range values {
+			_, err := spec.coerce(value)
+			assert.Equal(t, err == nil, re.MatchString(value), "%s %q", typ, value)
+		}
+	}
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+
+	return string(b)
+}
@@ -0,0 +1,253 @@
+package tagparser
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// lazyScanMax is the length up to which LazyTag.Get scans the tag instead of
+// parsing it into a Tag.
+const lazyScanMax = 256
+
+// LazyTag is a tag parsed only as far as its accessors need. Code that asks
+// a single question of a tag, such as whether it has `omitempty`, gets the
+// answer from a scan of the tag instead of a Tag and its map:
+//
+//	lazy := tagparser.ParseLazyWithName(field.Tag.Get("json"))
+//	if lazy.Has("omitempty") {
+//	    // ...
+//	}
+//
+// Tag builds the whole Tag once, when first called, and later calls to Get
+// use it. Tags longer than a few hundred bytes are built on the first Get.
+// A LazyTag is safe for concurrent use.
+type LazyTag struct {
+	raw      string
+	p        *Parser
+	withName bool
+
+	once   sync.Once
+	parsed atomic.Bool // tag and err are set
+	tag    *Tag
+	err    error
+}
+
+// ParseLazy returns a LazyTag for tag treating all items as options, like
+// Parse. Nothing is parsed until an accessor is called.
+func ParseLazy(tag string) *LazyTag {
+	return defaultParser.ParseLazy(tag)
+}
+
+// ParseLazyWithName is like ParseLazy but treats the first item without
+// equals as a name, like ParseWithName.
+func ParseLazyWithName(tag string) *LazyTag {
+	return defaultParser.ParseLazyWithName(tag)
+}
+
+// ParseLazy returns a LazyTag parsed with the syntax of p, like the
+// package-level ParseLazy.
+func (p *Parser) ParseLazy(tag string) *LazyTag {
+	return &LazyTag{raw: p.unquoteGo(tag), p: p}
+}
+
+// ParseLazyWithName is like ParseLazy but treats the first item without
+// equals as a name.
+func (p *Parser) ParseLazyWithName(tag string) *LazyTag {
+	return &LazyTag{raw: p.unquoteGo(tag), p: p, withName: true}
+}
+
+// Tag parses the tag, the first time it is called, and returns the result
+// Parse or ParseWithName would have returned. The Tag is shared by all
+// callers and must not be modified.
+func (l *LazyTag) Tag() (*Tag, error) {
+	l.once.Do(func() {
+		l.tag, l.err = l.p.result(l.p.parseUnquoted(l.raw, l.withName))
+		l.parsed.Store(true)
+	})
+
+	return l.tag, l.err
+}
+
+// Get returns the value of the option key and whether it is present. The
+// error is that of a malformed tag, for which the option is reported absent
+// unless the Parser is lenient.
+func (l *LazyTag) Get(key string) (value string, ok bool, err error) {
+	if !l.parsed.Load() && len(l.raw) <= lazyScanMax {
+		return l.p.find(l.raw, key, l.withName, true)
+	}
+
+	tag, err := l.Tag()
+	if tag == nil {
+		return "", false, err
+	}
+	if l.p.foldKeys {
+		key = strings.ToLower(key)
+	}
+	value, ok = tag.Options[key]
+
+	return value, ok, err
+}
+
+// Has reports whether the option key is present in a well-formed tag, like
+// HasOption.
+func (l *LazyTag) Has(key string) bool {
+	_, ok, err := l.Get(key)
+
+	return ok && err == nil
+}
+
+// HasOption reports whether a well-formed tag has the option key, treating
+// all items as options like Parse, without building a Tag or allocating:
+//
+//	if tagparser.HasOption(tag, "omitempty") {
+//	    // ...
+//	}
+func HasOption(tag, key string) bool {
+	return defaultParser.HasOption(tag, key)
+}
+
+// HasOptionWithName is like HasOption but treats the first item without
+// equals as a name, which is never an option.
+func HasOptionWithName(tag, key string) bool {
+	return defaultParser.HasOptionWithName(tag, key)
+}
+
+// GetOption returns the value of the option key of a tag and whether it is
+// present, treating all items as options like Parse, without building a
+// Tag. The value of a repeated key is the last one. A malformed tag is
+// reported with the error Parse would return.
+func GetOption(tag, key string) (value string, ok bool, err error) {
+	return defaultParser.GetOption(tag, key)
+}
+
+// GetOptionWithName is like GetOption but treats the first item without
+// equals as a name.
+func GetOptionWithName(tag, key string) (value string, ok bool, err error) {
+	return defaultParser.GetOptionWithName(tag, key)
+}
+
+// HasOption reports whether a well-formed tag has the option key, like the
+// package-level HasOption. A Parser with per-item checks, such as
+// WithValidator, unescapes the values it checks.
+func (p *Parser) HasOption(tag, key string) bool {
+	_, ok, err := p.find(p.unquoteGo(tag), key, false, false)
+
+	return ok && err == nil
+}
+
+// HasOptionWithName is like HasOption but treats the first item without
+// equals as a name.
+func (p *Parser) HasOptionWithName(tag, key string) bool {
+	_, ok, err := p.find(p.unquoteGo(tag), key, true, false)
+
+	return ok && err == nil
+}
+
+// GetOption returns the value of the option key of a tag, like the
+// package-level GetOption. A lenient Parser reports the option if it is
+// well-formed, along with the errors of the tag.
+func (p *Parser) GetOption(tag, key string) (value string, ok bool, err error) {
+	return p.find(p.unquoteGo(tag), key, false, true)
+}
+
+// GetOptionWithName is like GetOption but treats the first item without
+// equals as a name.
+func (p *Parser) GetOptionWithName(tag, key string) (value string, ok bool, err error) {
+	return p.find(p.unquoteGo(tag), key, true, true)
+}
+
+// SplitName returns the name of a tag, unquoted like ParseWithName does,
+// and the rest of the tag after its separator as written, without parsing
+// the options:
+//
+//	name, rest, err := tagparser.SplitName(`'a,b',omitempty,min=1`)
+//	// name == "a,b", rest == "omitempty,min=1"
+//
+// A tag whose first item is an option, as in `min=1,max=2`, has an empty
+// name and is returned whole as rest. Only the name is checked; errors in
+// rest are left for the code parsing it.
+func SplitName(tag string) (name, rest string, err error) {
+	return defaultParser.SplitName(tag)
+}
+
+// SplitName returns the name of a tag and the rest of it, like the
+// package-level SplitName.
+func (p *Parser) SplitName(tag string) (name, rest string, err error) {
+	cfg := p.syntax()
+	ps := parser{cfg: &cfg, tag: tag, treatFirstAsName: true}
+	if err := ps.checkLength(); err != nil {
+		return "", "", err
+	}
+	key, value, ok, err := ps.next()
+	switch {
+	case err != nil:
+		return "", "", err
+	case !ok:
+		return "", "", nil
+	case key != "":
+		return "", tag[ps.itemStart:], nil
+	case ps.pos < len(tag):
+		return value, tag[ps.pos+1:], nil
+	default:
+		return value, "", nil
+	}
+}
+
+// ParseNameOnly returns the name of a tag as ParseWithName would, without
+// building a Tag, for encoders that read nothing else:
+//
+//	name, err := tagparser.ParseNameOnly(`'a,b',omitempty`)
+//	// name == "a,b"
+//
+// Unlike SplitName, the whole tag is checked, so that a malformed tag is
+// reported with the error ParseWithName returns.
+func ParseNameOnly(tag string) (name string, err error) {
+	return defaultParser.ParseNameOnly(tag)
+}
+
+// ParseNameOnly returns the name of a tag, like the package-level
+// ParseNameOnly. A lenient Parser returns the name along with the errors of
+// the tag.
+func (p *Parser) ParseNameOnly(tag string) (name string, err error) {
+	ps := parser{cfg: p, tag: p.unquoteGo(tag), treatFirstAsName: true}
+	err = ps.parse(func(key, value string) error {
+		if key == "" {
+			name = value
+		}
+
+		return nil
+	})
+	if err != nil && !p.lenient {
+		return "", err
+	}
+
+	return name, err
+}
+
+// find returns the value of the last option of tag with the given key,
+// scanning the tag without building a Tag. Unless p has per-item checks,
+// which need every value, the values of other items are checked but not
+// unescaped, and neither is that of key unless withValue is set, so that
+// only a returned value allocates.
+func (p *Parser) find(tag, key string, withName, withValue bool) (value string, found bool, err error) {
+	if p.foldKeys {
+		key = strings.ToLower(key)
+	}
+	ps := parser{cfg: p, tag: tag, treatFirstAsName: withName, filter: p.simple}
+	if withValue {
+		ps.want = key
+	}
+	err = ps.parse(func(k, v string) error {
+		if k == key && k != "" {
+			value, found = v, true
+		}
+
+		return nil
+	})
+	if err != nil && !p.lenient {
+		return "", false, err
+	}
+
+	return value, found, err
+}
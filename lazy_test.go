@@ -0,0 +1,171 @@
+package tagparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyTag(t *testing.T) {
+	lazy := ParseLazyWithName(`"id,omitempty,min=1,min=2"`)
+	assert.True(t, lazy.Has("omitempty"))
+	assert.False(t, lazy.Has("id"))
+	value, ok, err := lazy.Get("min")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "2", value)
+
+	tag, err := lazy.Tag()
+	require.NoError(t, err)
+	assert.Equal(t, "id", tag.Name)
+	assert.Equal(t, M{"omitempty": "", "min": "2"}, tag.Options)
+	again, _ := lazy.Tag()
+	assert.Same(t, tag, again)
+	assert.True(t, lazy.Has("min"))
+
+	// Malformed tags report their error
+	lazy = ParseLazy(`a,=b`)
+	assert.False(t, lazy.Has("a"))
+	_, _, err = lazy.Get("a")
+	require.Error(t, err)
+	_, err = lazy.Tag()
+	require.Error(t, err)
+
+	// Long tags are parsed on first use, with folded keys
+	long := "K=1," + strings.Repeat("x,", lazyScanMax)
+	lazy = New(WithCaseInsensitiveKeys()).ParseLazy(long)
+	value, ok, err = lazy.Get("k")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+	assert.True(t, lazy.parsed.Load())
+
+	lazy = ParseLazyWithName(benchTagSimple)
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = lazy.Has("omitempty")
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestHasOption(t *testing.T) {
+	assert.True(t, HasOption(`json,omitempty`, "omitempty"))
+	assert.True(t, HasOption(`"name,omitempty"`, "name"))
+	assert.False(t, HasOptionWithName(`name,omitempty`, "name"))
+	assert.True(t, HasOptionWithName(`name,omitempty`, "omitempty"))
+	assert.False(t, HasOption(`json`, "omitempty"))
+	assert.False(t, HasOption(`omitempty,=x`, "omitempty"))
+
+	value, ok, err := GetOption(`min=1,max=5,min=2`, "min")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "2", value)
+
+	value, ok, err = GetOptionWithName(`max,min='a, b'`, "min")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "a, b", value)
+
+	_, ok, err = GetOption(`a,b`, "c")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = GetOption(`a,b=\x`, "a")
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeInvalidEscape, parseErr.Code)
+	assert.False(t, ok)
+
+	// Lenient parsers find well-formed options in malformed tags
+	value, ok, err = New(WithLenient()).GetOption(`a=1,b=\x`, "a")
+	require.Error(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = HasOption(benchTagSimple, "omitempty")
+	})
+	assert.Zero(t, allocs)
+
+	// Only the value looked up is unescaped; the others are still checked
+	const escaped = `a=\,b,omitempty`
+	value, ok, err = GetOption(escaped, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ",b", value)
+	assert.True(t, HasOption(escaped, "a"))
+	assert.False(t, HasOption(`a='x'y,omitempty`, "omitempty"))
+	assert.False(t, HasOptionWithName(`'a'b,omitempty`, "omitempty"))
+
+	lazy := ParseLazy(escaped)
+	allocs = testing.AllocsPerRun(100, func() {
+		_ = HasOption(escaped, "omitempty")
+		_ = HasOption(escaped, "a")
+		_, _, _ = GetOption(escaped, "omitempty")
+		_ = lazy.Has("omitempty")
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestSplitName(t *testing.T) {
+	tests := []struct {
+		tag, name, rest string
+	}{
+		{`id,omitempty,min=1`, "id", "omitempty,min=1"},
+		{` 'a,b' , x`, "a,b", " x"},
+		{`a\,b`, "a,b", ""},
+		{`,omitempty`, "", "omitempty"},
+		{`min=1,max=2`, "", "min=1,max=2"},
+		{`id,min=\x`, "id", `min=\x`},
+		{``, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			name, rest, err := SplitName(tt.tag)
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.rest, rest)
+		})
+	}
+
+	_, _, err := SplitName(`a'b',x`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeQuoteInMiddle, parseErr.Code)
+
+	name, rest, err := New(WithSeparator(';')).SplitName(`id;a,b`)
+	require.NoError(t, err)
+	assert.Equal(t, "id", name)
+	assert.Equal(t, "a,b", rest)
+}
+
+func TestParseNameOnly(t *testing.T) {
+	tests := []string{
+		`id,omitempty,min=1`, ` 'a,b' , x`, `a\,b`, `,omitempty`, `min=1,max=2`, ``, `"id,omitempty"`,
+		`id,min=\x`, `a'b',x`,
+	}
+	for _, tag := range tests {
+		t.Run(tag, func(t *testing.T) {
+			name, err := ParseNameOnly(tag)
+			want, wantErr := ParseWithName(tag)
+			if wantErr != nil {
+				assert.Equal(t, wantErr, err)
+				assert.Empty(t, name)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, want.Name, name)
+		})
+	}
+
+	name, err := New(WithLenient()).ParseNameOnly(`id,=x`)
+	assert.Equal(t, "id", name)
+	assert.Error(t, err)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = ParseNameOnly(`id,omitempty,min=1`)
+	})
+	assert.Zero(t, allocs)
+}
@@ -0,0 +1,167 @@
+package tagparser
+
+import "fmt"
+
+// TokenKind classifies a Token.
+type TokenKind int
+
+// Kinds of tokens.
+const (
+	TokenEOF       TokenKind = iota // End of the tag
+	TokenName                       // The name, first item of a tag lexed with a name
+	TokenKey                        // Option key, or flag
+	TokenAssign                     // Separator between a key and its value, '=' by default
+	TokenValue                      // Option value, possibly empty
+	TokenSeparator                  // Separator between items, ',' by default
+)
+
+var tokenKindNames = [...]string{
+	TokenEOF:       "EOF",
+	TokenName:      "Name",
+	TokenKey:       "Key",
+	TokenAssign:    "Assign",
+	TokenValue:     "Value",
+	TokenSeparator: "Separator",
+}
+
+// String returns the name of the kind, such as "Key".
+func (k TokenKind) String() string {
+	if k < 0 || int(k) >= len(tokenKindNames) {
+		return fmt.Sprintf("TokenKind(%d)", int(k))
+	}
+
+	return tokenKindNames[k]
+}
+
+// Token is a lexical token of a tag.
+type Token struct {
+	Kind TokenKind
+	Text string // Decoded text: unquoted and unescaped for names, keys and values
+	Raw  string // Text as written, without surrounding whitespace
+	Pos  int    // 0-based offset of Raw in the tag
+}
+
+// Lexer splits a tag into tokens with the same quote and escape rules as
+// the parser, for dialect implementations and syntax highlighters:
+//
+//	lex := tagparser.NewLexerWithName(`name,min=5`)
+//	for {
+//	    tok, err := lex.Next()
+//	    if err != nil || tok.Kind == tagparser.TokenEOF {
+//	        break
+//	    }
+//	    // Name "name", Separator ",", Key "min", Assign "=", Value "5"
+//	}
+//
+// Whitespace around tokens is not reported, and neither are empty items
+// other than their separators. A Lexer is not safe for concurrent use.
+type Lexer struct {
+	cfg     Parser // the lexer's parser configuration, never lenient
+	ps      parser
+	started bool
+	lastEnd int     // end of the last item lexed
+	pending []Token // tokens up to the end of the current item
+	next    int     // index of the next pending token
+	err     error   // sticky error
+}
+
+// NewLexer returns a Lexer for tag treating all items as options.
+func NewLexer(tag string) *Lexer {
+	return defaultParser.Lexer(tag)
+}
+
+// NewLexerWithName returns a Lexer for tag treating the first item as a
+// name, reported as a TokenName.
+func NewLexerWithName(tag string) *Lexer {
+	return defaultParser.LexerWithName(tag)
+}
+
+// Lexer returns a Lexer for tag with the syntax of p, treating all items as
+// options. Lexing stops at the first error even if p is lenient.
+func (p *Parser) Lexer(tag string) *Lexer {
+	return p.newLexer(tag, false)
+}
+
+// LexerWithName is like Lexer but treats the first item as a name.
+func (p *Parser) LexerWithName(tag string) *Lexer {
+	return p.newLexer(tag, true)
+}
+
+func (p *Parser) newLexer(tag string, withName bool) *Lexer {
+	l := &Lexer{cfg: p.syntax()}
+	l.ps = parser{cfg: &l.cfg, tag: tag, treatFirstAsName: withName}
+
+	return l
+}
+
+// Next returns the next token. At the end of the tag it returns a token of
+// kind TokenEOF, and after an error it keeps returning that error.
+func (l *Lexer) Next() (Token, error) {
+	for l.next == len(l.pending) {
+		if l.err != nil {
+			return Token{}, l.err
+		}
+		if err := l.fill(); err != nil {
+			l.err = err
+
+			return Token{}, err
+		}
+	}
+	tok := l.pending[l.next]
+	l.next++
+
+	return tok, nil
+}
+
+// fill queues the tokens up to the end of the next item.
+func (l *Lexer) fill() error {
+	l.next, l.pending = 0, l.pending[:0]
+	ps := &l.ps
+	if !l.started {
+		l.started = true
+		if err := ps.checkLength(); err != nil {
+			return err
+		}
+	}
+
+	key, value, ok, err := ps.next()
+	if err != nil {
+		return err
+	}
+	end := len(ps.tag)
+	if ok {
+		end = ps.itemStart
+	}
+	// Separators since the last item, including those of empty items
+	for i := l.lastEnd; i < end; i++ {
+		if l.cfg.isSep(ps.tag[i]) {
+			l.push(TokenSeparator, ps.tag[i:i+1], ps.tag[i:i+1], i)
+		}
+	}
+	if !ok {
+		l.lastEnd = len(ps.tag)
+		l.push(TokenEOF, "", "", len(ps.tag))
+
+		return nil
+	}
+
+	it := ps.item(key, value)
+	switch {
+	case key == "":
+		l.push(TokenName, value, it.RawValue, it.ValuePos)
+	case ps.inValue:
+		assign := ps.start - 1
+		l.push(TokenKey, key, it.RawKey, it.KeyPos)
+		l.push(TokenAssign, ps.tag[assign:assign+1], ps.tag[assign:assign+1], assign)
+		l.push(TokenValue, value, it.RawValue, it.ValuePos)
+	default:
+		l.push(TokenKey, key, it.RawKey, it.KeyPos)
+	}
+	l.lastEnd = ps.pos
+
+	return nil
+}
+
+func (l *Lexer) push(kind TokenKind, text, raw string, pos int) {
+	l.pending = append(l.pending, Token{Kind: kind, Text: text, Raw: raw, Pos: pos})
+}
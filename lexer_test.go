@@ -0,0 +1,89 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lexAll returns the tokens of l up to EOF, excluded.
+func lexAll(t *testing.T, l *Lexer) []Token {
+	t.Helper()
+
+	var toks []Token
+	for {
+		tok, err := l.Next()
+		require.NoError(t, err)
+		if tok.Kind == TokenEOF {
+			return toks
+		}
+		toks = append(toks, tok)
+	}
+}
+
+func TestLexer(t *testing.T) {
+	toks := lexAll(t, NewLexerWithName(`name, min = 5,,msg='a, b',k=,x\,y`))
+	assert.Equal(t, []Token{
+		{Kind: TokenName, Text: "name", Raw: "name", Pos: 0},
+		{Kind: TokenSeparator, Text: ",", Raw: ",", Pos: 4},
+		{Kind: TokenKey, Text: "min", Raw: "min", Pos: 6},
+		{Kind: TokenAssign, Text: "=", Raw: "=", Pos: 10},
+		{Kind: TokenValue, Text: "5", Raw: "5", Pos: 12},
+		{Kind: TokenSeparator, Text: ",", Raw: ",", Pos: 13},
+		{Kind: TokenSeparator, Text: ",", Raw: ",", Pos: 14},
+		{Kind: TokenKey, Text: "msg", Raw: "msg", Pos: 15},
+		{Kind: TokenAssign, Text: "=", Raw: "=", Pos: 18},
+		{Kind: TokenValue, Text: "a, b", Raw: "'a, b'", Pos: 19},
+		{Kind: TokenSeparator, Text: ",", Raw: ",", Pos: 25},
+		{Kind: TokenKey, Text: "k", Raw: "k", Pos: 26},
+		{Kind: TokenAssign, Text: "=", Raw: "=", Pos: 27},
+		{Kind: TokenValue, Text: "", Raw: "", Pos: 28},
+		{Kind: TokenSeparator, Text: ",", Raw: ",", Pos: 28},
+		{Kind: TokenKey, Text: "x,y", Raw: `x\,y`, Pos: 29},
+	}, toks)
+}
+
+func TestLexer_Separators(t *testing.T) {
+	kinds := func(toks []Token) []TokenKind {
+		out := make([]TokenKind, len(toks))
+		for i, tok := range toks {
+			out[i] = tok.Kind
+		}
+
+		return out
+	}
+
+	assert.Empty(t, lexAll(t, NewLexer(``)))
+	assert.Equal(t, []TokenKind{TokenSeparator, TokenSeparator}, kinds(lexAll(t, NewLexer(`,,`))))
+	assert.Equal(t, []TokenKind{TokenSeparator, TokenKey, TokenSeparator}, kinds(lexAll(t, NewLexerWithName(`,a,`))))
+
+	// Custom syntax
+	p := New(WithSeparator(';'), WithKeyValueSeparator(':'))
+	toks := lexAll(t, p.Lexer(`a:1;b`))
+	assert.Equal(t, []TokenKind{TokenKey, TokenAssign, TokenValue, TokenSeparator, TokenKey}, kinds(toks))
+	assert.Equal(t, ":", toks[1].Raw)
+	assert.Equal(t, ";", toks[3].Raw)
+}
+
+func TestLexer_Errors(t *testing.T) {
+	l := New(WithLenient()).Lexer(`a=1,b=\x,c`)
+	tok, err := l.Next()
+	require.NoError(t, err)
+	assert.Equal(t, TokenKey, tok.Kind)
+	for range 3 {
+		_, err = l.Next()
+	}
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeInvalidEscape, parseErr.Code)
+
+	// The error sticks
+	_, again := l.Next()
+	assert.Equal(t, err, again)
+}
+
+func TestTokenKind_String(t *testing.T) {
+	assert.Equal(t, "Separator", TokenSeparator.String())
+	assert.Equal(t, "TokenKind(42)", TokenKind(42).String())
+}
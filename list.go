@@ -0,0 +1,132 @@
+package tagparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// defaultListSep separates list elements when the Parser that produced a Tag
+// has no list separator.
+const defaultListSep = "|"
+
+// WithListSeparator declares the separator of list values such as
+// `oneof=red|green|blue` or `scopes='a;b;c'`, read with Tag.GetSlice.
+//
+// Elements can contain the separator when it is escaped, as in
+// `oneof=a\|b|c`. Option values are unescaped as usual, the option holding
+// `a|b|c`, and the Tag records which separators were escaped, so that
+// GetSlice, Unmarshal and the formatting methods keep them in their
+// element.
+//
+// sep must be an ASCII punctuation character other than the comma, equals
+// sign, quote and backslash, which have a meaning of their own; otherwise
+// WithListSeparator panics.
+func WithListSeparator(sep rune) Option {
+	if sep > 0x7f || !isListSep(byte(sep)) {
+		panic("tagparser: invalid list separator " + strconv.QuoteRune(sep))
+	}
+
+	return func(p *Parser) {
+		p.listSep = byte(sep)
+	}
+}
+
+func isListSep(c byte) bool {
+	switch c {
+	case ',', '=', '\'', '\\':
+		return false
+	}
+
+	return isPunct(c)
+}
+
+// isPunct reports whether c is an ASCII punctuation character.
+func isPunct(c byte) bool {
+	return c > ' ' && c < 0x7f && !isAlnum(c)
+}
+
+func isAlnum(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// GetSlice returns the value of key split into list elements, or nil if the
+// key is absent or its value is empty.
+//
+// The separator is the one declared with WithListSeparator on the Parser
+// that produced t, in which case the separators escaped in the tag are
+// part of their element, or '|'. Elements are not trimmed.
+func (t *Tag) GetSlice(key string) []string {
+	value := t.Options[key]
+	if value == "" {
+		return nil
+	}
+	list := newListFormat(t.listSep)
+	list.escaped = t.escapedSeps(key, value)
+
+	return list.split(value)
+}
+
+// listValue records the list separators of an option value that were
+// escaped, and so belong to an element rather than separate two.
+type listValue struct {
+	value   string // the value the offsets were recorded for
+	escaped []int  // offsets of the escaped separators in value
+}
+
+// setList records the offsets of the escaped list separators of the option
+// key, whose value is value.
+func (t *Tag) setList(key, value string, escaped []int) {
+	if t.lists == nil {
+		t.lists = make(map[string]listValue)
+	}
+	t.lists[key] = listValue{value: value, escaped: escaped}
+}
+
+// escapedSeps returns the offsets of the escaped list separators of value,
+// the value of the option key, or nil if it has none or was replaced since
+// it was parsed.
+func (t *Tag) escapedSeps(key, value string) []int {
+	if l, ok := t.lists[key]; ok && l.value == value {
+		return l.escaped
+	}
+
+	return nil
+}
+
+// newListFormat returns the list format of a Parser with the list separator
+// sep, 0 if it has none.
+func newListFormat(sep byte) listFormat {
+	if sep == 0 {
+		return listFormat{sep: defaultListSep}
+	}
+
+	return listFormat{sep: string(sep)}
+}
+
+// listFormat describes how list values are separated.
+type listFormat struct {
+	sep     string
+	escaped []int // offsets of separators that belong to an element
+}
+
+// split splits s into elements, leaving the escaped separators in their
+// element.
+func (f listFormat) split(s string) []string {
+	if len(f.escaped) == 0 {
+		return strings.Split(s, f.sep)
+	}
+
+	var elems []string
+	start, escaped := 0, f.escaped
+	for i := 0; i < len(s); i++ {
+		switch {
+		case len(escaped) > 0 && escaped[0] == i:
+			escaped = escaped[1:]
+		case s[i] == f.sep[0]:
+			elems = append(elems, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(elems, s[start:])
+}
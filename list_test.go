@@ -0,0 +1,78 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTag_GetSlice(t *testing.T) {
+	tag := MustParse(`oneof=red|green|blue,single=x,empty=,flag`)
+	assert.Equal(t, []string{"red", "green", "blue"}, tag.GetSlice("oneof"))
+	assert.Equal(t, []string{"x"}, tag.GetSlice("single"))
+	assert.Nil(t, tag.GetSlice("empty"))
+	assert.Nil(t, tag.GetSlice("flag"))
+	assert.Nil(t, tag.GetSlice("missing"))
+}
+
+func TestWithListSeparator(t *testing.T) {
+	p := New(WithListSeparator(';'))
+
+	tag, err := p.Parse(`scopes='read;write',oneof=a\;b;c\\;d,path=c:\\dir,plain=a\,b`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read", "write"}, tag.GetSlice("scopes"))
+	assert.Equal(t, []string{"a;b", `c\`, "d"}, tag.GetSlice("oneof"))
+	assert.Equal(t, []string{`c:\dir`}, tag.GetSlice("path"))
+	assert.Equal(t, []string{"a,b"}, tag.GetSlice("plain"))
+
+	// Values are unescaped, the escaped separators being recorded
+	assert.Equal(t, `a;b;c\;d`, tag.Options["oneof"])
+	assert.Equal(t, `c:\dir`, tag.Options["path"])
+	assert.Equal(t, "a,b", tag.Options["plain"])
+
+	// and kept escaped when the tag is written
+	s, err := p.Canonicalize(`scopes='read;write',oneof=a\;b;c\\;d,path=c:\\dir`)
+	require.NoError(t, err)
+	assert.Equal(t, `oneof=a\;b;c\\;d,path=c:\\dir,scopes=read;write`, s)
+
+	// A value replaced since parsing is split on every separator
+	tag.Set("oneof", "a;b")
+	assert.Equal(t, []string{"a", "b"}, tag.GetSlice("oneof"))
+	tag.Options["scopes"] = "x;y"
+	assert.Equal(t, []string{"x", "y"}, tag.GetSlice("scopes"))
+	other, err := p.Parse(`oneof=a\;b`)
+	require.NoError(t, err)
+	only := tag.Only("oneof")
+	assert.False(t, only.Equal(other))
+
+	// Keys and names are unescaped as usual
+	tag, err = p.ParseWithName(`a\;b,k\;=v`)
+	require.NoError(t, err)
+	assert.Equal(t, "a;b", tag.Name)
+	assert.Equal(t, M{"k;": "v"}, tag.Options)
+}
+
+func TestWithListSeparator_Invalid(t *testing.T) {
+	for _, sep := range []rune{',', '=', '\'', '\\', 'a', '1', ' ', 'é', 0} {
+		assert.Panics(t, func() { WithListSeparator(sep) }, "%q", sep)
+	}
+	assert.PanicsWithValue(t, `tagparser: invalid list separator '\x00'`, func() { WithListSeparator(0) })
+	assert.PanicsWithValue(t, `tagparser: invalid list separator '\n'`, func() { WithListSeparator('\n') })
+}
+
+func TestWithListSeparator_Unmarshal(t *testing.T) {
+	var dst struct {
+		OneOf  []string `tagparser:"oneof"`
+		Ports  []int    `tagparser:"ports"`
+		Scopes []string `tagparser:"scopes,sep=/"`
+		Label  string   `tagparser:"label"`
+	}
+	p := New(WithListSeparator(';'))
+	err := p.Unmarshal(`oneof=a\;b;c,ports=80;443,scopes=x/y\;z,label=a\;b\\c`, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a;b", "c"}, dst.OneOf)
+	assert.Equal(t, []int{80, 443}, dst.Ports)
+	assert.Equal(t, []string{"x", "y;z"}, dst.Scopes)
+	assert.Equal(t, `a;b\c`, dst.Label)
+}
@@ -0,0 +1,34 @@
+package tagparser
+
+import "time"
+
+// MetricsCollector receives the metrics of the parses of a Parser, see
+// WithMetrics. Its methods may be called concurrently.
+type MetricsCollector interface {
+	// IncParsed counts a parse, successful or not.
+	IncParsed()
+	// IncErrors counts a parse that returned an error.
+	IncErrors()
+	// ObserveDuration records the time a parse took.
+	ObserveDuration(d time.Duration)
+}
+
+// WithMetrics reports the parses of the Parser to c, for services parsing
+// tags in a request path. It observes the same parses as the hooks set with
+// WithHooks: each calls IncParsed, then IncErrors if it failed, then
+// ObserveDuration. The expvarmetrics package publishes the metrics with
+// expvar; a Prometheus collector needs a few lines:
+//
+//	type promMetrics struct{}
+//
+//	func (promMetrics) IncParsed()                      { parsedTotal.Inc() }
+//	func (promMetrics) IncErrors()                      { errorsTotal.Inc() }
+//	func (promMetrics) ObserveDuration(d time.Duration) { duration.Observe(d.Seconds()) }
+//
+// A later call replaces the collector of an earlier one, and a nil c
+// removes it.
+func WithMetrics(c MetricsCollector) Option {
+	return func(p *Parser) {
+		p.metrics = c
+	}
+}
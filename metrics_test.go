@@ -0,0 +1,39 @@
+package tagparser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingMetrics struct {
+	calls []string
+}
+
+func (c *countingMetrics) IncParsed() { c.calls = append(c.calls, "parsed") }
+
+func (c *countingMetrics) IncErrors() { c.calls = append(c.calls, "errors") }
+
+func (c *countingMetrics) ObserveDuration(d time.Duration) {
+	if d >= 0 {
+		c.calls = append(c.calls, "duration")
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	var c countingMetrics
+	var ended int
+	p := New(WithMetrics(&c), WithHooks(Hooks{
+		OnParseEnd: func(string, time.Duration, error) { ended++ },
+	}))
+
+	_, err := p.Parse(`a,b=1`)
+	require.NoError(t, err)
+	_, err = p.Parse(`a='`)
+	require.Error(t, err)
+	assert.True(t, p.HasOption(`a`, "a"))
+	assert.Equal(t, []string{"parsed", "duration", "parsed", "errors", "duration"}, c.calls)
+	assert.Equal(t, 2, ended)
+}
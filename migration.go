@@ -0,0 +1,69 @@
+package tagparser
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+)
+
+// Migration records the keys a version of a Schema renamed, such as
+//
+//	tagparser.Migration{Version: 2, Renames: map[string]string{"colunm": "column", "size": "max"}}
+//
+// for a dialect whose version 2 fixed a misspelled key and renamed another.
+type Migration struct {
+	Version int               // Version that renamed the keys
+	Renames map[string]string // New key of each old key
+}
+
+// WithSchemaMigration accepts the keys that the Migrations of schema
+// renamed, for a deprecation period spanning several releases of a tag
+// dialect. Old keys are reported under their key in the current version of
+// the schema, following renames across versions, so that with
+//
+//	Migrations: []tagparser.Migration{
+//	    {Version: 2, Renames: map[string]string{"colunm": "column"}},
+//	    {Version: 3, Renames: map[string]string{"column": "col"}},
+//	}
+//
+// both `colunm=id` and `column=id` are parsed as `col=id`. Like keys
+// deprecated with WithDeprecatedKey, ParseDiag reports them with a
+// DiagDeprecatedKey warning such as
+// `"colunm" is deprecated since version 2, use "col"`. Schema.Validate
+// only accepts the current keys; the Minify method of the Parser rewrites
+// a tag with them.
+func WithSchemaMigration(schema *Schema) Option {
+	return func(p *Parser) {
+		migrations := slices.SortedStableFunc(slices.Values(schema.Migrations), func(a, b Migration) int {
+			return cmp.Compare(b.Version, a.Version)
+		})
+		// Latest version first, so that the targets of renames are resolved
+		// to the current keys and a key renamed again later keeps the later
+		// rename.
+		renamed := make(map[string]string)
+		version := make(map[string]int)
+		for _, m := range migrations {
+			next := make(map[string]string, len(m.Renames))
+			for old, key := range m.Renames {
+				if latest, ok := renamed[key]; ok {
+					key = latest
+				}
+				next[old] = key
+			}
+			for old, key := range next {
+				if _, later := renamed[old]; !later && old != key {
+					renamed[old], version[old] = key, m.Version
+				}
+			}
+		}
+
+		for old, key := range renamed {
+			WithDeprecatedKey(old, key)(p)
+		}
+		if p.migrated == nil {
+			p.migrated = version
+		} else {
+			maps.Copy(p.migrated, version)
+		}
+	}
+}
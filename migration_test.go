@@ -0,0 +1,56 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSchemaMigration(t *testing.T) {
+	schema := &Schema{
+		Version: 3,
+		Keys:    map[string]KeySpec{"col": {}, "max": {Type: TypeInt}},
+		Migrations: []Migration{
+			{Version: 3, Renames: map[string]string{"column": "col", "size": "max"}},
+			{Version: 2, Renames: map[string]string{"colunm": "column", "sz": "size"}},
+		},
+	}
+	p := New(WithSchemaMigration(schema))
+
+	tag, diags, err := p.ParseDiag(`colunm=id,column=x,sz=5,col=y`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"col": "y", "max": "5"}, tag.Options)
+	msgs := make([]string, 0, len(diags))
+	for _, d := range diags {
+		if d.Code == DiagDeprecatedKey {
+			msgs = append(msgs, d.String())
+		}
+	}
+	assert.Equal(t, []string{
+		`warning: "colunm" is deprecated since version 2, use "col" (at 1)`,
+		`warning: "column" is deprecated since version 3, use "col" (at 11)`,
+		`warning: "sz" is deprecated since version 2, use "max" (at 20)`,
+	}, msgs)
+
+	migrated, err := p.Minify(`colunm=id, size=5`)
+	require.NoError(t, err)
+	assert.Equal(t, `col=id,max=5`, migrated)
+	assert.NoError(t, schema.Validate(migrated))
+
+	folded := New(WithCaseInsensitiveKeys(), WithSchemaMigration(schema))
+	_, diags, err = folded.ParseDiag(`Colunm=id`)
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, `"colunm" is deprecated since version 2, use "col"`, diags[0].Msg)
+
+	// A key renamed back to an old spelling is current again.
+	back := New(WithSchemaMigration(&Schema{Migrations: []Migration{
+		{Version: 2, Renames: map[string]string{"a": "b"}},
+		{Version: 3, Renames: map[string]string{"b": "a"}},
+	}}))
+	tag, diags, err = back.ParseDiag(`a=1`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1"}, tag.Options)
+	assert.Empty(t, diags)
+}
@@ -0,0 +1,45 @@
+package tagparser
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// MustParse is like Parse but panics if the tag cannot be parsed. It
+// simplifies initialization of global variables and tests holding known-good
+// tags.
+func MustParse(tag string) *Tag {
+	t, err := defaultParser.Parse(tag)
+	mustSucceed(err, "Parse", tag)
+
+	return t
+}
+
+// MustParseWithName is like ParseWithName but panics if the tag cannot be
+// parsed.
+func MustParseWithName(tag string) *Tag {
+	t, err := defaultParser.ParseWithName(tag)
+	mustSucceed(err, "ParseWithName", tag)
+
+	return t
+}
+
+// MustParseFunc is like ParseFunc but panics if the tag cannot be parsed or
+// callback returns an error.
+func MustParseFunc(tag string, callback func(key, value string) error) {
+	mustSucceed(defaultParser.ParseFunc(tag, callback), "ParseFunc", tag)
+}
+
+// MustParseFuncWithName is like ParseFuncWithName but panics if the tag cannot
+// be parsed or callback returns an error.
+func MustParseFuncWithName(tag string, callback func(key, value string) error) {
+	mustSucceed(defaultParser.ParseFuncWithName(tag, callback), "ParseFuncWithName", tag)
+}
+
+// mustSucceed panics with an error wrapping err, naming the function and
+// tag, if err is not nil.
+func mustSucceed(err error, fn, tag string) {
+	if err != nil {
+		panic(fmt.Errorf("tagparser: %s(%s): %w", fn, strconv.Quote(truncateForError(tag)), err))
+	}
+}
@@ -0,0 +1,35 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMustParse(t *testing.T) {
+	assert.Equal(t, &Tag{Options: M{"json": "", "min": "5"}}, MustParse(`json,min=5`))
+	assert.Equal(t, &Tag{Name: "json", Options: M{"min": "5"}}, MustParseWithName(`json,min=5`))
+
+	assert.PanicsWithError(t, `tagparser: Parse("a,=b"): empty key (at 3)`, func() { MustParse(`a,=b`) })
+	assert.PanicsWithError(t, `tagparser: ParseWithName("a,'b"): unterminated quote (at 3)`, func() { MustParseWithName(`a,'b`) })
+}
+
+func TestMustParseFunc(t *testing.T) {
+	opts := M{}
+	collect := func(key, value string) error {
+		opts[key] = value
+
+		return nil
+	}
+	MustParseFunc(`a,b=1`, collect)
+	MustParseFuncWithName(`n,c=2`, collect)
+	assert.Equal(t, M{"a": "", "b": "1", "": "n", "c": "2"}, opts)
+
+	defer func() {
+		err, ok := recover().(error)
+		require.True(t, ok)
+		require.ErrorIs(t, err, errSimulated)
+	}()
+	MustParseFuncWithName(`n,c=2`, func(string, string) error { return errSimulated })
+}
@@ -0,0 +1,58 @@
+package tagparser
+
+// KV is a key/value pair of a tag, as returned by ParsePairs. The value of
+// a flag is empty, and the name has an empty Key.
+type KV struct {
+	Key   string
+	Value string
+}
+
+// ParsePairs parses a tag treating all items as options and appends them to
+// dst in order, duplicates included, returning the extended slice like
+// strconv.AppendInt. Callers that never need a map can reuse one slice
+// across tags:
+//
+//	pairs := make([]tagparser.KV, 0, 8)
+//	for _, s := range tags {
+//	    pairs, err = tagparser.ParsePairs(pairs[:0], s)
+//	    // ...
+//	}
+//
+// On error dst is returned as it was.
+func ParsePairs(dst []KV, tag string) ([]KV, error) {
+	return defaultParser.ParsePairs(dst, tag)
+}
+
+// ParsePairsWithName is like ParsePairs but treats the first item without
+// equals as a name, appended first with an empty Key.
+func ParsePairsWithName(dst []KV, tag string) ([]KV, error) {
+	return defaultParser.ParsePairsWithName(dst, tag)
+}
+
+// ParsePairs appends the options of a tag to dst, like the package-level
+// ParsePairs. A lenient Parser appends the well-formed items and returns the
+// errors along with them.
+func (p *Parser) ParsePairs(dst []KV, tag string) ([]KV, error) {
+	return p.parsePairs(dst, tag, false)
+}
+
+// ParsePairsWithName is like ParsePairs but treats the first item without
+// equals as a name, like the package-level ParsePairsWithName.
+func (p *Parser) ParsePairsWithName(dst []KV, tag string) ([]KV, error) {
+	return p.parsePairs(dst, tag, true)
+}
+
+func (p *Parser) parsePairs(dst []KV, tag string, withName bool) ([]KV, error) {
+	n := len(dst)
+	ps := parser{cfg: p, tag: p.unquoteGo(tag), treatFirstAsName: withName}
+	err := ps.parse(func(key, value string) error {
+		dst = append(dst, KV{Key: key, Value: value})
+
+		return nil
+	})
+	if err != nil && !p.lenient {
+		return dst[:n], err
+	}
+
+	return dst, err
+}
@@ -0,0 +1,41 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePairs(t *testing.T) {
+	pairs, err := ParsePairs(nil, `min=1, omitempty,msg='a, b',min=2`)
+	require.NoError(t, err)
+	assert.Equal(t, []KV{{"min", "1"}, {"omitempty", ""}, {"msg", "a, b"}, {"min", "2"}}, pairs)
+
+	pairs, err = ParsePairsWithName(pairs[:0], `id,max=3`)
+	require.NoError(t, err)
+	assert.Equal(t, []KV{{"", "id"}, {"max", "3"}}, pairs)
+
+	// Pairs are appended, and dst is kept on error
+	pairs, err = ParsePairs(pairs, `a,=b`)
+	require.Error(t, err)
+	assert.Equal(t, []KV{{"", "id"}, {"max", "3"}}, pairs)
+
+	pairs, err = New(WithLenient()).ParsePairs(pairs, `a,=b,c`)
+	require.Error(t, err)
+	assert.Equal(t, []KV{{"", "id"}, {"max", "3"}, {"a", ""}, {"c", ""}}, pairs)
+
+	// Go string literals are unquoted, as by Parse
+	pairs, err = ParsePairs(nil, `"a,b"`)
+	require.NoError(t, err)
+	assert.Equal(t, []KV{{"a", ""}, {"b", ""}}, pairs)
+	pairs, err = ParsePairsWithName(nil, "`id,min='1'`")
+	require.NoError(t, err)
+	assert.Equal(t, []KV{{"", "id"}, {"min", "1"}}, pairs)
+
+	buf := make([]KV, 0, 4)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = ParsePairs(buf[:0], benchTagSimple)
+	})
+	assert.Zero(t, allocs)
+}
@@ -0,0 +1,856 @@
+package tagparser
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"unique"
+)
+
+// Parser parses tags using a fixed configuration.
+//
+// The package-level functions use a Parser with the default configuration.
+// Create a custom Parser with New when the tag dialect needs different rules.
+// A Parser is immutable after creation and safe for concurrent use.
+type Parser struct {
+	sep                byte // item separator
+	kvSep              byte // key/value separator, 0 if items have no value
+	foldKeys           bool
+	literalQuotes      bool
+	noEscapes          bool
+	quotedPairs        bool // any character can be escaped, see withHTTPSyntax
+	preserveWhitespace bool
+	continuation       bool
+	lenient            bool
+	strictName         bool
+	listSep            byte
+	bools              *boolValues // see WithBoolValues
+	negation           bool
+	strictChars        bool
+	groups             bool
+	alternatives       bool
+	interning          bool
+	quote              byte // see WithQuoteChar
+	escape             byte // see WithEscapeChar
+	limits             limits
+	simple             bool // no per-item checks, see fillSimple
+
+	knownKeys   map[string]bool                         // nil if all keys are known
+	unknownKey  func(key, value string, pos int) error  // see WithUnknownKeyHandler
+	validators  map[string]func(value string) error     // see WithValidator
+	transforms  map[string]func(string) (string, error) // see WithValueTransformer
+	deprecated  map[string]string                       // replacement of deprecated keys
+	aliases     map[string]string                       // canonical key of aliases, see WithAliases
+	migrated    map[string]int                          // version that renamed a key, see WithSchemaMigration
+	resolve     func(name string) (string, error)       // see WithPlaceholderResolver
+	hooks       *Hooks                                  // see WithHooks
+	metrics     MetricsCollector                        // see WithMetrics
+	errorFormat func(*Error) string                     // see WithErrorFormatter
+}
+
+// limits bounds the input accepted by a Parser. Zero values mean the
+// default: MaxTagLength for the tag and no limit for the others.
+type limits struct {
+	maxTagLength   int // negative for no limit
+	maxOptions     int
+	maxKeyLength   int
+	maxValueLength int
+	maxErrors      int
+}
+
+// maxTagLength returns the tag length limit, or -1 for no limit.
+func (p *Parser) maxTagLength() int {
+	switch {
+	case p.limits.maxTagLength == 0:
+		return MaxTagLength
+	case p.limits.maxTagLength < 0:
+		return -1
+	default:
+		return p.limits.maxTagLength
+	}
+}
+
+// Option configures a Parser.
+type Option func(*Parser)
+
+// defaultParser backs the package-level parse functions.
+var defaultParser = New()
+
+// New creates a Parser configured with the given options. It panics if the
+// options give the same character two meanings, such as a list separator
+// equal to the item separator.
+func New(opts ...Option) *Parser {
+	p := &Parser{sep: ',', kvSep: '=', quote: '\'', escape: '\\'}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.sep == p.kvSep || (p.listSep != 0 && (p.listSep == p.sep || p.listSep == p.kvSep)) {
+		panic("tagparser: conflicting separators")
+	}
+	if p.noEscapes && p.listSep != 0 {
+		panic("tagparser: list separator without escapes")
+	}
+	if p.groups && (isParen(p.sep) || isParen(p.kvSep) || isParen(p.listSep)) {
+		panic("tagparser: parentheses used as separators with groups")
+	}
+	if p.alternatives && (p.sep == altSep || p.kvSep == altSep || p.listSep == altSep) {
+		panic("tagparser: '|' used as a separator with alternatives")
+	}
+	if p.quote == p.escape || p.isSyntaxChar(p.quote) || p.isSyntaxChar(p.escape) {
+		panic("tagparser: conflicting quote or escape character")
+	}
+	if p.foldKeys {
+		p.knownKeys = foldMapKeys(p.knownKeys)
+		p.validators = foldMapKeys(p.validators)
+		p.transforms = foldMapKeys(p.transforms)
+		p.deprecated = foldMapKeys(p.deprecated)
+		p.aliases = foldMapKeys(p.aliases)
+		p.migrated = foldMapKeys(p.migrated)
+	}
+	p.simple = !p.negation && !p.groups && !p.alternatives && !p.strictChars &&
+		p.knownKeys == nil && p.validators == nil && p.transforms == nil && p.deprecated == nil &&
+		p.aliases == nil && p.resolve == nil &&
+		p.limits.maxOptions <= 0 && p.limits.maxKeyLength <= 0 && p.limits.maxValueLength <= 0
+
+	return p
+}
+
+// foldMapKeys returns m with its keys lower-cased, or nil for a nil m.
+func foldMapKeys[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return nil
+	}
+
+	folded := make(map[string]V, len(m))
+	for key, v := range m {
+		folded[strings.ToLower(key)] = v
+	}
+
+	return folded
+}
+
+// syntax returns a copy of p that only checks the syntax of tags: it stops
+// at the first error and keeps every item, for the lexer and the CST.
+func (p *Parser) syntax() Parser {
+	cfg := *p
+	cfg.lenient = false
+	cfg.knownKeys = nil
+	cfg.unknownKey = nil
+	cfg.validators = nil
+	cfg.transforms = nil
+	cfg.deprecated = nil
+	cfg.aliases = nil
+	cfg.resolve = nil
+
+	return cfg
+}
+
+// WithSeparator replaces the comma separating items, as in the `;` of
+// `column:id;primaryKey`. sep must be an ASCII punctuation character other
+// than the quote and backslash; otherwise WithSeparator panics. The comma
+// then becomes an ordinary character.
+func WithSeparator(sep rune) Option {
+	checkSyntaxChar(sep, "separator")
+
+	return func(p *Parser) {
+		p.sep = byte(sep)
+	}
+}
+
+// WithKeyValueSeparator replaces the equals sign between keys and values,
+// as in the `:` of `column:id`. sep must be an ASCII punctuation character
+// other than the quote and backslash; otherwise WithKeyValueSeparator
+// panics. Only the first separator of an item splits it, so values can
+// contain further separators.
+func WithKeyValueSeparator(sep rune) Option {
+	checkSyntaxChar(sep, "key/value separator")
+
+	return func(p *Parser) {
+		p.kvSep = byte(sep)
+	}
+}
+
+// checkSyntaxChar panics if c cannot be used as the separator named what.
+func checkSyntaxChar(c rune, what string) {
+	if c > 0x7f || !isPunct(byte(c)) || c == '\'' || c == '\\' {
+		panic("tagparser: invalid " + what + " " + strconv.QuoteRune(c))
+	}
+}
+
+// isSyntaxChar reports whether c separates items, keys and values, list
+// elements or alternatives, opens or closes a group or negates a flag.
+func (p *Parser) isSyntaxChar(c byte) bool {
+	return p.isSep(c) || c == p.kvSep || c == p.listSep ||
+		p.groups && isParen(c) || p.negation && c == '!'
+}
+
+// WithQuoteChar replaces the single quote around keys and values, for
+// dialects quoting them with another character, as in msg=`a, b` with
+// backticks. The single quote then becomes an ordinary character. quote
+// must be an ASCII punctuation character; otherwise WithQuoteChar panics.
+// New panics if it is also a separator or the escape character.
+func WithQuoteChar(quote rune) Option {
+	if quote > 0x7f || !isPunct(byte(quote)) {
+		panic("tagparser: invalid quote character " + strconv.QuoteRune(quote))
+	}
+
+	return func(p *Parser) {
+		p.quote = byte(quote)
+	}
+}
+
+// WithEscapeChar replaces the backslash escaping the next character, as in
+// the `^` of `pattern=a^,b`, for dialects written inside Go raw string
+// literals where backslashes are hard to read. The backslash then becomes
+// an ordinary character. escape must be an ASCII punctuation character;
+// otherwise WithEscapeChar panics. New panics if it is also a separator or
+// the quote character.
+func WithEscapeChar(escape rune) Option {
+	if escape > 0x7f || !isPunct(byte(escape)) {
+		panic("tagparser: invalid escape character " + strconv.QuoteRune(escape))
+	}
+
+	return func(p *Parser) {
+		p.escape = byte(escape)
+	}
+}
+
+// WithCaseInsensitiveKeys lower-cases option keys, so that `primaryKey` and
+// `PRIMARYKEY` both yield the key "primarykey". Names and values keep their
+// case.
+func WithCaseInsensitiveKeys() Option {
+	return func(p *Parser) {
+		p.foldKeys = true
+	}
+}
+
+// WithInterning makes the Parser return option keys from a process-wide
+// table of interned strings, so that the keys of all the tags it parses,
+// such as the omitempty, required, min and max that recur across a
+// program, share their memory. It is meant for long-lived caches of parsed
+// tags, which then no longer hold a copy of each key, at the cost of a table
+// lookup per option.
+func WithInterning() Option {
+	return func(p *Parser) {
+		p.interning = true
+	}
+}
+
+// intern returns the interned copy of key if p interns keys.
+func (p *Parser) intern(key string) string {
+	if !p.interning {
+		return key
+	}
+
+	return unique.Make(key).Value()
+}
+
+// WithNoEscapes makes backslashes ordinary characters, for dialects whose
+// values hold regular expressions or Windows paths, such as
+// `regexp=^\d+$`, which would otherwise fail with CodeInvalidEscape.
+// Separators and quotes in values can then only be written inside quotes,
+// as in `regexp='^(a|b),c$'`, and a value cannot contain both a separator
+// and a quote.
+//
+// List separators rely on escapes, so New panics if WithListSeparator is
+// also given.
+func WithNoEscapes() Option {
+	return func(p *Parser) {
+		p.noEscapes = true
+	}
+}
+
+// WithLiteralQuotes makes quotes ordinary characters, for tag corpora whose
+// apostrophes are data rather than syntax: `pattern='[a-z]'` then keeps its
+// quotes instead of yielding [a-z], and `name=O'Brien` no longer fails with
+// CodeUnterminatedQuote. Separators in keys and values must then be escaped.
+func WithLiteralQuotes() Option {
+	return func(p *Parser) {
+		p.literalQuotes = true
+	}
+}
+
+// withHTTPSyntax configures the syntax of HTTP header parameters: items are
+// separated by sep, values quoted with double quotes, in which a backslash
+// escapes any character, and keys are case-insensitive.
+func withHTTPSyntax(sep rune) Option {
+	return func(p *Parser) {
+		WithSeparator(sep)(p)
+		WithQuoteChar('"')(p)
+		WithCaseInsensitiveKeys()(p)
+		p.quotedPairs = true
+	}
+}
+
+// withStdlibSyntax configures the syntax of the struct tags of the standard
+// library: comma-separated items taken literally, without values, quotes,
+// escapes, whitespace trimming or length limit.
+func withStdlibSyntax() Option {
+	return func(p *Parser) {
+		p.kvSep = 0
+		p.literalQuotes = true
+		p.noEscapes = true
+		p.preserveWhitespace = true
+		p.limits.maxTagLength = -1
+	}
+}
+
+// WithPreserveWhitespace disables trimming of leading and trailing ASCII
+// whitespace around keys and values.
+//
+// With this option `sep= ; ` yields the value " ; " and quotes must start and
+// end the item exactly, since surrounding spaces are part of the value.
+func WithPreserveWhitespace() Option {
+	return func(p *Parser) {
+		p.preserveWhitespace = true
+	}
+}
+
+// WithLineContinuation lets long keys and values span lines, for tags
+// written by code generators that wrap them. A backslash at the end of a
+// line joins it to the next, dropping the line break and the spaces and
+// tabs indenting the next line:
+//
+//	oneof=red green \
+//	      blue
+//
+// yields the value "red green blue". Line breaks inside quotes are kept,
+// and line breaks and tabs are not reported by WithStrictChars. Errors in
+// multi-line tags carry a Line and Column with or without this option, but
+// only report them in their message with it, as in "unterminated quote (at
+// line 2, column 3)". It has no effect with WithNoEscapes.
+func WithLineContinuation() Option {
+	return func(p *Parser) {
+		p.continuation = true
+	}
+}
+
+// WithLenient makes the Parser recover from malformed items instead of
+// stopping at the first one.
+//
+// A malformed item is skipped up to the next separator and parsing continues.
+// Parse and ParseWithName return the successfully parsed Name and Options
+// together with every problem found. Callback errors are collected the same
+// way. An unterminated quote consumes the rest of the tag, and an oversized
+// tag is still rejected outright.
+//
+// The returned error is an *Errors listing each problem in order; like an
+// error built by errors.Join, it matches errors.Is and errors.As for any of
+// them.
+func WithLenient() Option {
+	return func(p *Parser) {
+		p.lenient = true
+	}
+}
+
+// WithStrictName makes ParseWithName and the other name-extracting functions
+// require the first item to be a name, for dialects where the name is
+// mandatory. A first item such as `foo=bar` is then reported with
+// CodeMissingName instead of being treated as an option. An empty name, as
+// in `,omitempty`, is still accepted.
+func WithStrictName() Option {
+	return func(p *Parser) {
+		p.strictName = true
+	}
+}
+
+// WithNegation enables the `!flag` shorthand: a flag prefixed with an
+// exclamation mark, such as `!omitempty`, is reported as the option
+// omitempty with the value "false", so that tag merging can turn off an
+// inherited flag. Read such flags with Tag.GetBoolFlag. A negated flag cannot
+// have a value, and `\!flag` escapes the prefix.
+func WithNegation() Option {
+	return func(p *Parser) {
+		p.negation = true
+	}
+}
+
+// WithStrictChars rejects invalid UTF-8 and ASCII control characters
+// (0x00-0x1F and 0x7F) in keys, values and names, reporting the offending
+// byte with CodeInvalidUTF8 or CodeControlChar. Whitespace trimmed around
+// items is not checked. Use it for tags coming from generated or external
+// sources.
+func WithStrictChars() Option {
+	return func(p *Parser) {
+		p.strictChars = true
+	}
+}
+
+// KeepUnknownKey is returned by an unknown key handler to keep the option.
+var KeepUnknownKey = errors.New("keep unknown key") //nolint:errname // mirrors SkipStruct
+
+// WithKnownKeys restricts options to the given keys. Options with other keys
+// are rejected with CodeUnknownKey at the position of the key, unless a
+// handler set with WithUnknownKeyHandler decides otherwise. The name is not
+// an option and is always accepted.
+func WithKnownKeys(keys ...string) Option {
+	return func(p *Parser) {
+		if p.knownKeys == nil {
+			p.knownKeys = make(map[string]bool, len(keys))
+		}
+		for _, key := range keys {
+			p.knownKeys[key] = true
+		}
+	}
+}
+
+// WithUnknownKeyHandler sets the function deciding what happens to options
+// whose key is not among those given to WithKnownKeys, during parsing. It
+// receives the key, the value and the 0-based position of the key, and
+// returns nil to drop the option, KeepUnknownKey to keep it, or another
+// error to reject it with CodeUnknownKey and the error as Cause:
+//
+//	p := tagparser.New(
+//	    tagparser.WithKnownKeys("min", "max"),
+//	    tagparser.WithUnknownKeyHandler(func(key, value string, pos int) error {
+//	        log.Printf("ignoring unknown key %q at %d", key, pos)
+//	        return nil
+//	    }),
+//	)
+//
+// The handler may be called concurrently when the Parser is shared.
+func WithUnknownKeyHandler(fn func(key, value string, pos int) error) Option {
+	return func(p *Parser) {
+		p.unknownKey = fn
+	}
+}
+
+// WithValidator registers a function validating the values of the options
+// with the given key during parsing, so that dialect rules live in the
+// Parser and are reported at exact positions:
+//
+//	p := tagparser.New(tagparser.WithValidator("min", func(value string) error {
+//	    n, err := strconv.Atoi(value)
+//	    if err == nil && n < 0 {
+//	        err = errors.New("must not be negative")
+//	    }
+//	    return err
+//	}))
+//
+// A value the function rejects is reported with CodeInvalidValue at the
+// position of the value, or of the key for a flag, and the returned error as
+// Cause. A later validator for the same key replaces an earlier one. The
+// function may be called concurrently when the Parser is shared.
+func WithValidator(key string, fn func(value string) error) Option {
+	return func(p *Parser) {
+		if p.validators == nil {
+			p.validators = make(map[string]func(string) error)
+		}
+		p.validators[key] = fn
+	}
+}
+
+// WithValueTransformer registers a function rewriting the values of the
+// options with the given key during parsing, so that consumers receive
+// normalized options without a second pass, as in
+//
+//	p := tagparser.New(tagparser.WithValueTransformer("type", func(value string) (string, error) {
+//	    return strings.ToLower(value), nil
+//	}))
+//
+// The function receives the unquoted value, empty for a flag, and its result
+// replaces it before any validator registered with WithValidator runs. An
+// error is reported like a rejected value, with CodeInvalidValue. A later
+// transformer for the same key replaces an earlier one. The function may be
+// called concurrently when the Parser is shared.
+func WithValueTransformer(key string, fn func(string) (string, error)) Option {
+	return func(p *Parser) {
+		if p.transforms == nil {
+			p.transforms = make(map[string]func(string) (string, error))
+		}
+		p.transforms[key] = fn
+	}
+}
+
+// WithPlaceholderResolver resolves the `{{name}}` placeholders of names and
+// values during parsing, for tags generated from templates:
+//
+//	p := tagparser.New(tagparser.WithPlaceholderResolver(func(name string) (string, error) {
+//	    return tenant.Lookup(name)
+//	}))
+//	tag, err := p.ParseWithName(`id,table={{tenant}}_users`)
+//
+// The function receives the placeholder name with surrounding whitespace
+// trimmed, and its result replaces the placeholder as is: it is not searched
+// for placeholders again. Placeholders are resolved in the unquoted value,
+// before transformers and validators run. An error, or a `{{` without its
+// `}}`, is reported with CodeInvalidValue at the position of the placeholder
+// in the tag. The function may be called concurrently when the Parser is
+// shared.
+func WithPlaceholderResolver(fn func(name string) (string, error)) Option {
+	return func(p *Parser) {
+		p.resolve = fn
+	}
+}
+
+// WithBoolValues makes Tag.GetBool and Tag.GetBoolFlag accept the values
+// truthy and falsy, compared ignoring case, besides those of
+// strconv.ParseBool, for tags written by hand with values such as `yes` or
+// `off`:
+//
+//	p := tagparser.New(tagparser.WithBoolValues(
+//	    []string{"yes", "y", "on"},
+//	    []string{"no", "n", "off"},
+//	))
+//
+// Without it, the getters keep the strict strconv.ParseBool syntax. Later
+// calls add to the values of earlier ones.
+func WithBoolValues(truthy, falsy []string) Option {
+	return func(p *Parser) {
+		b := &boolValues{}
+		if p.bools != nil {
+			*b = *p.bools
+		}
+		b.truthy = append(slices.Clip(b.truthy), truthy...)
+		b.falsy = append(slices.Clip(b.falsy), falsy...)
+		p.bools = b
+	}
+}
+
+// WithTruthyBools is WithBoolValues for the common forms yes, y and on,
+// and no, n and off.
+func WithTruthyBools() Option {
+	return WithBoolValues([]string{"yes", "y", "on"}, []string{"no", "n", "off"})
+}
+
+// WithDeprecatedKey marks key as deprecated in favor of replacement, for a
+// migration period where old spellings keep working but warn. Options with
+// the key are reported under the replacement key, and ParseDiag reports
+// them with a DiagDeprecatedKey warning such as
+// `"colunm" is deprecated, use "column"`. An empty replacement keeps the
+// key as it is. Known keys, validators and transformers apply to the
+// replacement key.
+func WithDeprecatedKey(key, replacement string) Option {
+	return func(p *Parser) {
+		if p.deprecated == nil {
+			p.deprecated = make(map[string]string)
+		}
+		p.deprecated[key] = replacement
+	}
+}
+
+// WithAliases maps alternative spellings of keys to their canonical key,
+// so that options written `omit_empty` or `omitEmpty` are reported as
+// `omitempty` and consumers look up a single key:
+//
+//	p := tagparser.New(tagparser.WithAliases(map[string]string{
+//	    "omit_empty": "omitempty",
+//	    "omitEmpty":  "omitempty",
+//	}))
+//
+// Unlike WithDeprecatedKey, aliases are accepted silently. Known keys,
+// validators and transformers apply to the canonical key, and an alias of a
+// deprecated key is resolved after its replacement. Aliases are not
+// chained: the canonical key is used as is. Repeated calls add to the
+// aliases, a later alias for the same key replacing an earlier one.
+func WithAliases(aliases map[string]string) Option {
+	return func(p *Parser) {
+		if p.aliases == nil {
+			p.aliases = make(map[string]string, len(aliases))
+		}
+		for alias, key := range aliases {
+			p.aliases[alias] = key
+		}
+	}
+}
+
+// WithErrorFormatter sets the function formatting the messages of the
+// errors the Parser returns, so that applications can localize or reword
+// them before showing them to end users:
+//
+//	p := New(WithErrorFormatter(func(e *Error) string {
+//	    return fmt.Sprintf("%s (position %d)", messages[e.Code], e.Pos+1)
+//	}))
+//
+// fn receives the *Error whose Error method is called and returns the whole
+// message; an empty result keeps the default message. fn must not call
+// e.Error. Errors joined in an *Errors are formatted one by one.
+func WithErrorFormatter(fn func(*Error) string) Option {
+	return func(p *Parser) {
+		p.errorFormat = fn
+	}
+}
+
+// WithMaxTagLength replaces MaxTagLength as the limit on the length of tags
+// in bytes. Longer tags are rejected with CodeTagTooLarge before parsing,
+// even by a lenient Parser. n <= 0 removes the limit.
+func WithMaxTagLength(n int) Option {
+	if n <= 0 {
+		n = -1
+	}
+
+	return func(p *Parser) {
+		p.limits.maxTagLength = n
+	}
+}
+
+// WithMaxOptions limits the number of options in a tag, not counting the
+// name. The first option over the limit stops parsing, even for a lenient
+// Parser, with CodeTooManyOptions. n <= 0 removes the limit, the default.
+func WithMaxOptions(n int) Option {
+	return func(p *Parser) {
+		p.limits.maxOptions = n
+	}
+}
+
+// WithMaxKeyLength limits the length of keys in bytes, after unescaping.
+// Longer keys are reported with CodeKeyTooLong. n <= 0 removes the limit,
+// the default.
+func WithMaxKeyLength(n int) Option {
+	return func(p *Parser) {
+		p.limits.maxKeyLength = n
+	}
+}
+
+// WithMaxValueLength limits the length of values and of the name in bytes,
+// after unescaping. Longer values are reported with CodeValueTooLong.
+// n <= 0 removes the limit, the default.
+func WithMaxValueLength(n int) Option {
+	return func(p *Parser) {
+		p.limits.maxValueLength = n
+	}
+}
+
+// WithMaxErrors limits the number of errors a lenient Parser, or ParseAll,
+// collects in a tag, so that adversarial input cannot make it build an
+// unbounded list. Reaching the limit stops parsing: the Errors returned list
+// the n errors followed by one with CodeTooManyErrors at the position of the
+// last. n <= 0 removes the limit, the default.
+func WithMaxErrors(n int) Option {
+	return func(p *Parser) {
+		p.limits.maxErrors = n
+	}
+}
+
+// Parse parses a tag treating all items as options, like the package-level Parse.
+func (p *Parser) Parse(tag string) (*Tag, error) {
+	return p.result(p.parseTag(tag, false))
+}
+
+// ParseWithName parses a tag treating the first item without equals as a name,
+// like the package-level ParseWithName.
+func (p *Parser) ParseWithName(tag string) (*Tag, error) {
+	return p.result(p.parseTag(tag, true))
+}
+
+// ParseAll parses a tag treating all items as options and reports every
+// malformed item instead of stopping at the first one, like the package-level
+// ParseAll. It behaves as if p had been created with WithLenient.
+func (p *Parser) ParseAll(tag string) (*Tag, *Errors) {
+	return p.parseAll(tag, false)
+}
+
+// ParseAllWithName is like ParseAll but treats the first item without equals
+// as a name.
+func (p *Parser) ParseAllWithName(tag string) (*Tag, *Errors) {
+	return p.parseAll(tag, true)
+}
+
+// Validate reports whether a tag is well-formed, like the package-level
+// Validate.
+func (p *Parser) Validate(tag string) error {
+	return p.validate(tag, false)
+}
+
+// ValidateWithName is like Validate but treats the first item without
+// equals as a name.
+func (p *Parser) ValidateWithName(tag string) error {
+	return p.validate(tag, true)
+}
+
+func (p *Parser) validate(tag string, withName bool) error {
+	ps := parser{cfg: p, tag: p.unquoteGo(tag), treatFirstAsName: withName}
+	if err := ps.begin(); err != nil {
+		return err
+	}
+	for {
+		_, _, ok, err := ps.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ps.err()
+		}
+	}
+}
+
+// parseTag parses tag into a Tag, returning whatever was parsed along with
+// any error.
+func (p *Parser) parseTag(tag string, withName bool) (*Tag, error) {
+	return p.parseUnquoted(p.unquoteGo(tag), withName)
+}
+
+// unquoteGo handles the Go struct tag quoting convention: it unquotes tag if
+// it looks like a Go string literal. A tag in backticks is left as it is when
+// backticks are the quotes of p.
+func (p *Parser) unquoteGo(tag string) string {
+	if p.quote == '`' && strings.HasPrefix(tag, "`") {
+		return tag
+	}
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		return unquoted
+	}
+
+	return tag
+}
+
+// inlineBuffer is the size of the unescaping buffer allocated along with
+// the Tag by Parse, which fits most tags with escapes.
+const inlineBuffer = 128
+
+// bufferedTag is a Tag allocated together with the buffer its escaped
+// values are unescaped into, see parseUnquoted.
+type bufferedTag struct {
+	Tag
+	buf [inlineBuffer]byte
+}
+
+// parseUnquoted is like parseTag for a tag that is known not to be a quoted
+// Go string literal.
+//
+// A short tag with escapes is parsed into a bufferedTag, so that the Tag and
+// the buffer holding its unescaped values take a single allocation besides
+// the Options map. Values keep the whole bufferedTag alive, as they would a
+// separate buffer.
+func (p *Parser) parseUnquoted(tag string, withName bool) (*Tag, error) {
+	options := make(map[string]string, p.countItems(tag))
+	if p.hooks == nil && p.metrics == nil && len(tag) <= inlineBuffer && p.hasEscapes(tag) {
+		result := &bufferedTag{Tag: Tag{Options: options}}
+		err := p.fillBuffer(&result.Tag, tag, withName, result.buf[:0])
+
+		return &result.Tag, err
+	}
+
+	result := &Tag{Options: options}
+	err := p.fill(result, tag, withName)
+
+	return result, err
+}
+
+// countItems returns an upper bound of the number of items in tag, used to
+// size the Options map so that tags with many options do not grow it item by
+// item. Separators inside quotes or escaped are counted too.
+func (p *Parser) countItems(tag string) int {
+	return strings.Count(tag, string(rune(p.sep))) + 1
+}
+
+// hasEscapes reports whether tag has escapes to process.
+func (p *Parser) hasEscapes(tag string) bool {
+	return !p.noEscapes && strings.IndexByte(tag, p.escape) >= 0
+}
+
+// fill parses an unquoted tag into t, reusing its maps.
+func (p *Parser) fill(t *Tag, tag string, withName bool) error {
+	if p.hooks != nil || p.metrics != nil {
+		return p.fillObserved(t, tag, withName)
+	}
+
+	return p.fillTag(t, tag, withName)
+}
+
+// fillTag is fill without the hooks and metrics.
+func (p *Parser) fillTag(t *Tag, tag string, withName bool) error {
+	return p.fillBuffer(t, tag, withName, nil)
+}
+
+// fillBuffer is fillTag unescaping values into buf while it has room.
+func (p *Parser) fillBuffer(t *Tag, tag string, withName bool, buf []byte) error {
+	t.reset(p)
+	if p.isSimple(tag) {
+		if p.fillSimple(t, tag, withName) {
+			return nil
+		}
+		t.reset(p)
+	}
+	ps := parser{cfg: p, tag: tag, treatFirstAsName: withName, buf: buf}
+
+	return ps.parse(func(key, value string) error {
+		if key == "" {
+			t.Name = value
+		} else {
+			// Allow duplicates, last value wins
+			t.setOption(key, value, ps.inValue)
+			if ps.escapedSeps != nil {
+				t.setList(key, value, ps.escapedSeps)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ParseInto parses a tag treating all items as options into dst, like the
+// package-level ParseInto.
+func (p *Parser) ParseInto(dst *Tag, tag string) error {
+	return p.fill(dst, p.unquoteGo(tag), false)
+}
+
+// ParseIntoWithName is like ParseInto but treats the first item without
+// equals as a name.
+func (p *Parser) ParseIntoWithName(dst *Tag, tag string) error {
+	return p.fill(dst, p.unquoteGo(tag), true)
+}
+
+// result discards a partially parsed tag on error unless p is lenient.
+func (p *Parser) result(tag *Tag, err error) (*Tag, error) {
+	if err != nil && !p.lenient {
+		return nil, err
+	}
+
+	return tag, err
+}
+
+func (p *Parser) parseAll(tag string, withName bool) (*Tag, *Errors) {
+	lenient := *p
+	lenient.lenient = true
+
+	result, err := lenient.parseTag(tag, withName)
+	if err == nil {
+		return result, nil
+	}
+
+	var errs *Errors
+	if errors.As(err, &errs) {
+		return result, errs
+	}
+
+	// Errors that stop even a lenient parse, such as an oversized tag
+	var parseErr *Error
+	errors.As(err, &parseErr)
+
+	return result, &Errors{Tag: parseErr.Tag, List: []*Error{parseErr}}
+}
+
+// ParseFunc enumerates fields of a tag treating all items as options,
+// like the package-level ParseFunc.
+func (p *Parser) ParseFunc(tag string, callback func(key, value string) error) error {
+	ps := parser{cfg: p, tag: tag, treatFirstAsName: false}
+
+	return ps.parse(callback)
+}
+
+// ParseFuncWithName enumerates fields of a tag treating the first item as a name,
+// like the package-level ParseFuncWithName.
+func (p *Parser) ParseFuncWithName(tag string, callback func(key, value string) error) error {
+	ps := parser{cfg: p, tag: tag, treatFirstAsName: true}
+
+	return ps.parse(callback)
+}
+
+// ParseFuncPos enumerates fields of a tag with their positions, like the
+// package-level ParseFuncPos.
+func (p *Parser) ParseFuncPos(tag string, callback func(key, value string, keyPos, valPos int) error) error {
+	ps := parser{cfg: p, tag: tag, treatFirstAsName: false}
+
+	return ps.parsePos(callback)
+}
+
+// ParseFuncWithNamePos enumerates fields of a tag with their positions,
+// treating the first item as a name, like the package-level
+// ParseFuncWithNamePos.
+func (p *Parser) ParseFuncWithNamePos(tag string, callback func(key, value string, keyPos, valPos int) error) error {
+	ps := parser{cfg: p, tag: tag, treatFirstAsName: true}
+
+	return ps.parsePos(callback)
+}
@@ -0,0 +1,729 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_DefaultMatchesPackageFuncs(t *testing.T) {
+	p := New()
+
+	tag, err := p.ParseWithName(` alfa , bravo = charlie `)
+	require.NoError(t, err)
+	assert.Equal(t, "alfa", tag.Name)
+	assert.Equal(t, M{"bravo": "charlie"}, tag.Options)
+
+	tag, err = p.Parse(`"alfa,bravo=charlie"`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"alfa": "", "bravo": "charlie"}, tag.Options)
+}
+
+func TestWithPreserveWhitespace(t *testing.T) {
+	p := New(WithPreserveWhitespace())
+
+	tests := []struct {
+		testName string
+		tag      string
+		name     string
+		opts     map[string]string
+		error    string
+	}{
+		{`comma still separates`, `sep= , `, "", M{"sep": " ", " ": ""}, ``},
+		{`spaced value`, `sep= ; `, "", M{"sep": " ; "}, ``},
+		{`quoted value`, `sep=' , '`, "", M{"sep": " , "}, ``},
+		{`spaced name`, ` alfa ,bravo`, " alfa ", M{"bravo": ""}, ``},
+		{`spaced key`, ` alfa =bravo`, "", M{" alfa ": "bravo"}, ``},
+		{`quote after space`, `sep= ' '`, "", nil, `quotes must enclose the entire value (at 6)`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			tag, err := p.ParseWithName(test.tag)
+
+			if test.error != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.error, err.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.name, tag.Name)
+				if test.opts == nil {
+					assert.Empty(t, tag.Options)
+				} else {
+					assert.Equal(t, test.opts, tag.Options)
+				}
+			}
+		})
+	}
+}
+
+func TestWithLenient(t *testing.T) {
+	p := New(WithLenient())
+
+	tests := []struct {
+		testName string
+		tag      string
+		name     string
+		opts     map[string]string
+		errors   []string
+	}{
+		{`no errors`, `alfa,bravo=charlie`, "alfa", M{"bravo": "charlie"}, nil},
+		{`empty key`, `alfa,=bravo,charlie`, "alfa", M{"charlie": ""}, []string{`empty key (at 6)`}},
+		{`invalid escape`, `alfa,b\ravo,charlie=delta`, "alfa", M{"charlie": "delta"}, []string{`invalid escape character (at 8)`}},
+		{`bad name`, `al'fa',bravo`, "", M{"bravo": ""}, []string{`quotes must enclose the entire value (at 3)`}},
+		{`bad key and value`, `a'b'=c,d=e'f',g`, "", M{"g": ""}, []string{
+			`quotes must enclose the entire value (at 2)`,
+			`quotes must enclose the entire value (at 11)`,
+		}},
+		{`unterminated quote`, `alfa,bravo,charlie='delta,echo`, "alfa", M{"bravo": ""}, []string{`unterminated quote (at 20)`}},
+		{`every problem`, `alfa,=x,y=\z,w`, "alfa", M{"w": ""}, []string{`empty key (at 6)`, `invalid escape character (at 12)`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			tag, err := p.ParseWithName(test.tag)
+			require.NotNil(t, tag)
+			assert.Equal(t, test.name, tag.Name)
+			assert.Equal(t, test.opts, tag.Options)
+
+			if test.errors == nil {
+				require.NoError(t, err)
+
+				return
+			}
+			require.Error(t, err)
+			var joined interface{ Unwrap() []error }
+			require.ErrorAs(t, err, &joined, "lenient errors should be joined")
+			var msgs []string
+			for _, e := range joined.Unwrap() {
+				var parseErr *Error
+				require.ErrorAs(t, e, &parseErr)
+				msgs = append(msgs, e.Error())
+			}
+			assert.Equal(t, test.errors, msgs)
+		})
+	}
+}
+
+func TestWithLenient_CallbackErrors(t *testing.T) {
+	p := New(WithLenient())
+
+	var keys []string
+	err := p.ParseFunc(`alfa,bravo,charlie`, func(key, value string) error {
+		if key == "bravo" {
+			return errSimulated
+		}
+		keys = append(keys, key)
+
+		return nil
+	})
+	require.ErrorIs(t, err, errSimulated)
+	assert.Equal(t, []string{"alfa", "charlie"}, keys)
+}
+
+func TestWithLenient_TagTooLarge(t *testing.T) {
+	tag, err := New(WithLenient()).Parse(strings.Repeat("a", MaxTagLength+1))
+	require.ErrorIs(t, err, ErrTagTooLarge)
+	require.NotNil(t, tag)
+	assert.Empty(t, tag.Options)
+}
+
+func TestWithStrictName(t *testing.T) {
+	p := New(WithStrictName())
+
+	tag, err := p.ParseWithName(`json,omitempty`)
+	require.NoError(t, err)
+	assert.Equal(t, &Tag{Name: "json", Options: M{"omitempty": ""}}, tag)
+
+	tag, err = p.ParseWithName(`,omitempty`)
+	require.NoError(t, err)
+	assert.Equal(t, &Tag{Options: M{"omitempty": ""}}, tag)
+
+	_, err = p.ParseWithName(` foo=bar,baz`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeMissingName, parseErr.Code)
+	assert.Equal(t, 1, parseErr.Pos)
+	assert.Equal(t, " foo=bar", parseErr.Segment)
+	assert.Equal(t, "missing name before key=value pair (at 2)", err.Error())
+
+	// Options-only parsing is unaffected
+	tag, err = p.Parse(`foo=bar`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"foo": "bar"}, tag.Options)
+
+	// Lenient parsing drops the pair and keeps going
+	tag, err = New(WithStrictName(), WithLenient()).ParseWithName(`foo=bar,baz`)
+	require.Error(t, err)
+	assert.Equal(t, &Tag{Options: M{"baz": ""}}, tag)
+}
+
+func TestWithNegation(t *testing.T) {
+	p := New(WithNegation())
+
+	tag, err := p.ParseWithName(`name, !omitempty ,inline,\!bang,a=!b`)
+	require.NoError(t, err)
+	assert.Equal(t, &Tag{Name: "name", Options: M{"omitempty": "false", "inline": "", "!bang": "", "a": "!b"}}, tag)
+
+	var keyPos []int
+	err = p.ParseFuncPos(`a, !b`, func(_, _ string, kp, _ int) error {
+		keyPos = append(keyPos, kp)
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 3}, keyPos)
+
+	_, err = p.Parse(`!min=5`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeUnexpectedValue, parseErr.Code)
+	assert.Equal(t, "negated flag cannot have a value (at 5)", err.Error())
+
+	_, err = p.Parse(`a,!`)
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeEmptyKey, parseErr.Code)
+
+	// Without the option the prefix is part of the key
+	tag, err = Parse(`!omitempty`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"!omitempty": ""}, tag.Options)
+}
+
+func TestWithMaxTagLength(t *testing.T) {
+	p := New(WithMaxTagLength(8))
+	_, err := p.Parse(`a,b,c,de`)
+	require.NoError(t, err)
+
+	_, err = p.Parse(`a,b,c,def`)
+	require.ErrorIs(t, err, ErrTagTooLarge)
+
+	_, errs := p.ParseAll(`a,b,c,def`)
+	require.NotNil(t, errs)
+	assert.Equal(t, CodeTagTooLarge, errs.List[0].Code)
+
+	// Relaxed and removed limits
+	long := strings.Repeat("a", MaxTagLength+1)
+	_, err = New(WithMaxTagLength(MaxTagLength + 1)).Parse(long)
+	require.NoError(t, err)
+	_, err = New(WithMaxTagLength(0)).Parse(long)
+	require.NoError(t, err)
+	_, err = Parse(long)
+	require.ErrorIs(t, err, ErrTagTooLarge)
+}
+
+func TestWithMaxOptions(t *testing.T) {
+	p := New(WithMaxOptions(2))
+	tag, err := p.ParseWithName(`name,a,b=1`)
+	require.NoError(t, err)
+	assert.Len(t, tag.Options, 2)
+
+	_, err = p.ParseWithName(`name,a,b=1,c`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeTooManyOptions, parseErr.Code)
+	assert.Equal(t, 11, parseErr.Pos)
+
+	// The limit stops lenient parsing too
+	tag, err = New(WithMaxOptions(1), WithLenient()).Parse(`=x,a,b,c`)
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeTooManyOptions, parseErr.Code)
+	assert.Equal(t, M{"a": ""}, tag.Options)
+}
+
+func TestWithMaxErrors(t *testing.T) {
+	p := New(WithLenient(), WithMaxErrors(2))
+
+	tag, err := p.Parse(`=a,b,=c,=d,e`)
+	assert.Equal(t, M{"b": ""}, tag.Options, "parsing stops at the limit")
+	var errs *Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs.List, 3)
+	assert.Equal(t, CodeEmptyKey, errs.List[1].Code)
+	assert.Equal(t, CodeTooManyErrors, errs.List[2].Code)
+	assert.Equal(t, 5, errs.List[2].Pos)
+	assert.Equal(t, "empty key (at 1)\nempty key (at 6)\ntoo many errors, stopped after 2 (at 6)", err.Error())
+
+	// Fewer errors are reported as usual
+	tag, err = p.Parse(`=a,b,c`)
+	assert.Equal(t, M{"b": "", "c": ""}, tag.Options)
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs.List, 1)
+
+	// ParseAll honors the limit of a strict Parser
+	_, errs = New(WithMaxErrors(1)).ParseAll(`a,=b,=c`)
+	require.Len(t, errs.List, 2)
+	assert.Equal(t, CodeTooManyErrors, errs.List[1].Code)
+}
+
+func TestWithMaxKeyValueLength(t *testing.T) {
+	p := New(WithMaxKeyLength(3), WithMaxValueLength(4))
+
+	tag, err := p.ParseWithName(`name,abc='a\,bc',k`)
+	require.NoError(t, err)
+	assert.Equal(t, &Tag{Name: "name", Options: M{"abc": "a,bc", "k": ""}}, tag)
+
+	_, err = p.Parse(`ok,abcd=1`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeKeyTooLong, parseErr.Code)
+	assert.Equal(t, 3, parseErr.Pos)
+
+	_, err = p.Parse(`a=12345`)
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeValueTooLong, parseErr.Code)
+	assert.Equal(t, 2, parseErr.Pos)
+
+	_, err = p.ParseWithName(`names`)
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeValueTooLong, parseErr.Code)
+
+	// Lenient parsing skips offending items
+	tag, err = New(WithMaxKeyLength(3), WithLenient()).Parse(`long=1,ok=2`)
+	require.Error(t, err)
+	assert.Equal(t, M{"ok": "2"}, tag.Options)
+}
+
+func TestWithLineContinuation(t *testing.T) {
+	p := New(WithLineContinuation(), WithStrictChars())
+
+	tag, err := p.ParseWithName("name,\n\toneof=red green \\\n\t      blue,\r\n\tmsg='two\nlines',\n\tlong\\\r\n  key=x\\\n")
+	require.NoError(t, err)
+	assert.Equal(t, "name", tag.Name)
+	assert.Equal(t, M{"oneof": "red green blue", "msg": "two\nlines", "longkey": "x"}, tag.Options)
+
+	// Other escapes are unchanged
+	tag, err = p.Parse(`a=b\,c\ d`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"a": "b,c d"}, tag.Options)
+
+	// Without the option an escaped line break is kept
+	tag, err = Parse("a=b\\\n  c")
+	require.NoError(t, err)
+	assert.Equal(t, M{"a": "b\n  c"}, tag.Options)
+
+	_, err = p.Parse("a=1,\nb='x")
+	assert.EqualError(t, err, "unterminated quote (at line 2, column 3)")
+	_, err = p.Parse("a=1,\nb=x\x01")
+	assert.EqualError(t, err, "control character (at line 2, column 4)")
+}
+
+func TestWithStrictChars(t *testing.T) {
+	p := New(WithStrictChars())
+
+	tag, err := p.ParseWithName(" naïve ,\tkey='日本 語'\n")
+	require.NoError(t, err)
+	assert.Equal(t, &Tag{Name: "naïve", Options: M{"key": "日本 語"}}, tag)
+
+	tests := []struct {
+		tag  string
+		code ErrorCode
+		pos  int
+	}{
+		{"a,b\x00c", CodeControlChar, 3},
+		{"a,k\x7f=v", CodeControlChar, 3},
+		{"a,k=v\tw", CodeControlChar, 5},
+		{"a,k='\x1b'", CodeControlChar, 5},
+		{"a,k=\xff", CodeInvalidUTF8, 4},
+		{"a,\xe6\x97=v", CodeInvalidUTF8, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			_, err := p.Parse(tt.tag)
+			var parseErr *Error
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tt.code, parseErr.Code)
+			assert.Equal(t, tt.pos, parseErr.Pos)
+
+			// Accepted without the option
+			_, err = Parse(tt.tag)
+			require.NoError(t, err)
+		})
+	}
+
+	// Preserved whitespace is checked
+	_, err = New(WithStrictChars(), WithPreserveWhitespace()).Parse("a=\tb")
+	require.Error(t, err)
+
+	// Lenient parsing skips offending items
+	tag, err = New(WithStrictChars(), WithLenient()).Parse("a=\x01,b=2")
+	require.Error(t, err)
+	assert.Equal(t, M{"b": "2"}, tag.Options)
+}
+
+func TestWithSeparators(t *testing.T) {
+	p := New(WithSeparator(';'), WithKeyValueSeparator(':'))
+
+	tag, err := p.ParseWithName(`name; a:1 ; b:x=y,z ; c:'d;e';f\;g`)
+	require.NoError(t, err)
+	assert.Equal(t, &Tag{Name: "name", Options: M{"a": "1", "b": "x=y,z", "c": "d;e", "f;g": ""}}, tag)
+
+	// Errors delimit items with the custom separator
+	_, err = p.Parse(`a:1;:2;b`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeEmptyKey, parseErr.Code)
+	assert.Equal(t, 4, parseErr.Pos)
+	assert.Equal(t, ":2", parseErr.Segment)
+
+	// Only the first key/value separator splits an item
+	tag, err = p.Parse(`dsn:host:5432`)
+	require.NoError(t, err)
+	assert.Equal(t, "host:5432", tag.Options["dsn"])
+}
+
+func TestWithSeparators_Invalid(t *testing.T) {
+	for _, c := range []rune{'a', '7', ' ', '\'', '\\', 'é', 0x7f} {
+		assert.Panics(t, func() { WithSeparator(c) }, "%q", c)
+		assert.Panics(t, func() { WithKeyValueSeparator(c) }, "%q", c)
+	}
+	assert.Panics(t, func() { New(WithSeparator('=')) })
+	assert.Panics(t, func() { New(WithKeyValueSeparator(',')) })
+	assert.Panics(t, func() { New(WithSeparator('|'), WithListSeparator('|')) })
+}
+
+func TestWithCaseInsensitiveKeys(t *testing.T) {
+	p := New(WithCaseInsensitiveKeys())
+
+	tag, err := p.ParseWithName(`Name,OmitEmpty,MAX=Ten`)
+	require.NoError(t, err)
+	assert.Equal(t, &Tag{Name: "Name", Options: M{"omitempty": "", "max": "Ten"}}, tag)
+}
+
+func TestWithKnownKeys(t *testing.T) {
+	p := New(WithKnownKeys("min", "max"))
+
+	tag, err := p.ParseWithName(`name,min=1,max=2`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"min": "1", "max": "2"}, tag.Options)
+
+	_, err = p.ParseWithName(`name,min=1, size=2`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeUnknownKey, parseErr.Code)
+	assert.Equal(t, 12, parseErr.Pos)
+	assert.Equal(t, `unknown key "size" (at 13)`, err.Error())
+
+	// Lenient parsing drops the option
+	tag, err = New(WithKnownKeys("min"), WithLenient()).Parse(`x,min=1`)
+	require.Error(t, err)
+	assert.Equal(t, M{"min": "1"}, tag.Options)
+
+	// Keys are folded like the options
+	tag, err = New(WithKnownKeys("primaryKey"), WithCaseInsensitiveKeys()).Parse(`PRIMARYKEY`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"primarykey": ""}, tag.Options)
+}
+
+func TestWithUnknownKeyHandler(t *testing.T) {
+	var seen []string
+	handler := func(key, value string, pos int) error {
+		seen = append(seen, fmt.Sprintf("%s=%s@%d", key, value, pos))
+		switch key {
+		case "keep":
+			return KeepUnknownKey
+		case "bad":
+			return errors.New("not allowed")
+		default:
+			return nil
+		}
+	}
+	p := New(WithKnownKeys("min"), WithUnknownKeyHandler(handler))
+
+	tag, err := p.Parse(`min=1,drop=2,keep`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"min": "1", "keep": ""}, tag.Options)
+	assert.Equal(t, []string{"drop=2@6", "keep=@13"}, seen)
+
+	_, err = p.Parse(`min=1,bad=x`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeUnknownKey, parseErr.Code)
+	assert.Equal(t, 6, parseErr.Pos)
+	assert.EqualError(t, err, `unknown key "bad": not allowed (at 7)`)
+
+	// The lexer and the CST keep every item
+	cst, err := p.ParseCST(`min=1,drop=2`)
+	require.NoError(t, err)
+	assert.Len(t, cst.Items, 2)
+}
+
+func TestWithValidator(t *testing.T) {
+	nonNegative := func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err == nil && n < 0 {
+			err = errors.New("must not be negative")
+		}
+
+		return err
+	}
+	p := New(
+		WithValidator("min", nonNegative),
+		WithValidator("Format", func(value string) error {
+			if value != "email" && value != "url" {
+				return errors.New("must be email or url")
+			}
+
+			return nil
+		}),
+		WithCaseInsensitiveKeys(),
+	)
+
+	tag, err := p.ParseWithName(`name,min=0,FORMAT=url,other=-1`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"min": "0", "format": "url", "other": "-1"}, tag.Options)
+
+	tests := []struct {
+		tag string
+		pos int
+		msg string
+	}{
+		{`min=-1`, 4, `invalid value "-1" for "min": must not be negative (at 5)`},
+		{`a, min = x`, 9, `invalid value "x" for "min": strconv.Atoi: parsing "x": invalid syntax (at 10)`},
+		{`a,format`, 2, `invalid value "" for "format": must be email or url (at 3)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			_, err := p.Parse(tt.tag)
+			var parseErr *Error
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, CodeInvalidValue, parseErr.Code)
+			assert.Equal(t, tt.pos, parseErr.Pos)
+			assert.EqualError(t, err, tt.msg)
+		})
+	}
+
+	// Lenient parsing drops the option
+	tag, err = New(WithValidator("min", nonNegative), WithLenient()).Parse(`min=-1,max=2`)
+	require.Error(t, err)
+	assert.Equal(t, M{"max": "2"}, tag.Options)
+}
+
+func TestWithValueTransformer(t *testing.T) {
+	aliases := map[string]string{"str": "string", "int": "integer"}
+	p := New(
+		WithValueTransformer("type", func(value string) (string, error) {
+			value = strings.ToLower(value)
+			if full, ok := aliases[value]; ok {
+				return full, nil
+			}
+
+			return value, nil
+		}),
+		WithValueTransformer("size", func(value string) (string, error) {
+			if value == "" {
+				return "", errors.New("size needs a value")
+			}
+
+			return value, nil
+		}),
+		WithValidator("type", func(value string) error {
+			if value != "string" && value != "integer" {
+				return errors.New("unknown type")
+			}
+
+			return nil
+		}),
+	)
+
+	tag, err := p.ParseWithName(`name,type=STR,other=STR`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"type": "string", "other": "STR"}, tag.Options)
+
+	// Validators see the transformed value
+	_, err = p.Parse(`type=Float`)
+	assert.EqualError(t, err, `invalid value "float" for "type": unknown type (at 6)`)
+
+	_, err = p.Parse(`a,size`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeInvalidValue, parseErr.Code)
+	assert.Equal(t, 2, parseErr.Pos)
+}
+
+func TestWithAliases(t *testing.T) {
+	p := New(
+		WithAliases(map[string]string{"omit_empty": "omitempty", "omitEmpty": "omitempty"}),
+		WithAliases(map[string]string{"len": "size"}),
+		WithDeprecatedKey("colunm", "col"),
+		WithAliases(map[string]string{"col": "column"}),
+		WithKnownKeys("omitempty", "size", "column"),
+		WithValidator("size", func(value string) error {
+			if value == "" {
+				return errors.New("size needs a value")
+			}
+
+			return nil
+		}),
+	)
+
+	tag, err := p.ParseWithName(`omit_empty,omit_empty,len=10,colunm=id`)
+	require.NoError(t, err)
+	assert.Equal(t, "omit_empty", tag.Name, "the name is not a key")
+	assert.Equal(t, M{"omitempty": "", "size": "10", "column": "id"}, tag.Options)
+
+	tag, err = p.Parse(`omitEmpty`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"omitempty": ""}, tag.Options)
+
+	// Validators apply to the canonical key
+	_, err = p.Parse(`len`)
+	assert.EqualError(t, err, `invalid value "" for "size": size needs a value (at 1)`)
+
+	// Aliases are not known keys themselves
+	_, err = New(WithAliases(map[string]string{"a": "b"}), WithKnownKeys("a")).Parse(`a`)
+	assert.EqualError(t, err, `unknown key "b" (at 1)`)
+
+	// Keys are folded along with the aliases
+	folded := New(WithCaseInsensitiveKeys(), WithAliases(map[string]string{"omitEmpty": "omitempty"}))
+	tag, err = folded.Parse(`OMITEMPTY,OmitEmpty`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"omitempty": ""}, tag.Options)
+
+	// Duplicates are detected on the canonical key
+	_, diags, err := p.ParseDiag(`omitempty,omit_empty`)
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, DiagDuplicateKey, diags[0].Code)
+}
+
+func TestWithErrorFormatter(t *testing.T) {
+	messages := map[ErrorCode]string{
+		CodeUnterminatedQuote: "guillemet non fermé",
+	}
+	p := New(WithLenient(), WithErrorFormatter(func(e *Error) string {
+		if msg, ok := messages[e.Code]; ok {
+			return fmt.Sprintf("%s (position %d)", msg, e.Pos+1)
+		}
+
+		return ""
+	}))
+
+	_, err := p.Parse(`a='b`)
+	assert.EqualError(t, err, "guillemet non fermé (position 3)")
+
+	// Unhandled codes keep the default message
+	_, err = p.Parse(`a,=b,c='d`)
+	assert.EqualError(t, err, "empty key (at 3)\nguillemet non fermé (position 8)")
+
+	_, err = New(WithMaxTagLength(2), WithErrorFormatter(func(e *Error) string {
+		return "trop long"
+	})).Parse(`abc`)
+	assert.EqualError(t, err, "trop long")
+}
+
+func TestWithNoEscapes(t *testing.T) {
+	p := New(WithNoEscapes())
+
+	tag, err := p.Parse(`regexp=^\d+$,path=C:\dir\,sep='a,b\'`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"regexp": `^\d+$`, "path": `C:\dir\`, "sep": `a,b\`}, tag.Options)
+
+	_, err = Parse(`regexp=^\d+$`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeInvalidEscape, parseErr.Code)
+
+	// Quotes are still needed for separators, and cannot be escaped
+	s, err := p.Canonicalize(`a='x,y',b=c\d`)
+	require.NoError(t, err)
+	assert.Equal(t, `a='x,y',b=c\d`, s)
+
+	assert.Panics(t, func() { New(WithNoEscapes(), WithListSeparator(';')) })
+}
+
+func TestWithQuoteChar(t *testing.T) {
+	p := New(WithQuoteChar('`'), WithEscapeChar('^'))
+
+	tag, err := p.Parse("msg=`a, b`,pattern=a^,b,path=C:\\dir,it='s")
+	require.NoError(t, err)
+	assert.Equal(t, M{"msg": "a, b", "pattern": "a,b", "path": `C:\dir`, "it": "'s"}, tag.Options)
+
+	// A tag in backticks is quoted by the dialect, not by Go
+	tag, err = p.Parse("`x, y`")
+	require.NoError(t, err)
+	assert.Equal(t, M{"x, y": ""}, tag.Options)
+
+	_, err = p.Parse(`a=b^c`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeInvalidEscape, parseErr.Code)
+	assert.Equal(t, 4, parseErr.Pos)
+
+	s, err := p.Canonicalize("b=' ^`x^` ',a=`c,d`")
+	require.NoError(t, err)
+	assert.Equal(t, "a=`c,d`,b=' ^`x^` '", s)
+	tag, err = p.Parse(s)
+	require.NoError(t, err)
+	assert.Equal(t, M{"a": "c,d", "b": "' `x` '"}, tag.Options)
+
+	assert.Panics(t, func() { WithQuoteChar('a') })
+	assert.Panics(t, func() { WithEscapeChar(' ') })
+	assert.Panics(t, func() { New(WithQuoteChar(',')) })
+	assert.Panics(t, func() { New(WithEscapeChar('\'')) })
+	assert.Panics(t, func() { New(WithEscapeChar('|'), WithAlternatives()) })
+}
+
+func TestWithEscapeChar_ListSeparator(t *testing.T) {
+	p := New(WithEscapeChar('^'), WithListSeparator(';'))
+
+	tag, err := p.Parse(`oneof=a^;b;c\d;e^^`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a;b", `c\d`, "e^"}, tag.GetSlice("oneof"))
+
+	s, err := p.Canonicalize(`oneof=a^;b;c\d;e^^`)
+	require.NoError(t, err)
+	assert.Equal(t, `oneof=a^;b;c\d;e^^`, s)
+}
+
+func TestWithLiteralQuotes(t *testing.T) {
+	p := New(WithLiteralQuotes())
+
+	tag, err := p.Parse(`pattern='[a-z]',name=O'Brien,sep='a\,b'`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"pattern": "'[a-z]'", "name": "O'Brien", "sep": "'a,b'"}, tag.Options)
+
+	_, err = Parse(`name=O'Brien`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeUnterminatedQuote, parseErr.Code)
+
+	// Separators are escaped rather than quoted
+	s, err := p.Canonicalize(`b=it's,a=x\,y`)
+	require.NoError(t, err)
+	assert.Equal(t, `a=x\,y,b=it's`, s)
+}
+
+func TestWithInterning(t *testing.T) {
+	p := New(WithInterning())
+
+	a, err := p.Parse(`omitempty,'min'=1`)
+	require.NoError(t, err)
+	b, err := p.Parse(strings.Repeat(" ", 3) + `omitempty, min=2`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"omitempty": "", "min": "1"}, a.Options)
+
+	// Keys of different tags share their memory
+	keyData := func(tag *Tag, key string) *byte {
+		for k := range tag.Options {
+			if k == key {
+				return unsafe.StringData(k)
+			}
+		}
+
+		return nil
+	}
+	assert.Same(t, keyData(a, "omitempty"), keyData(b, "omitempty"))
+	assert.Same(t, keyData(a, "min"), keyData(b, "min"))
+
+	// Without the option keys point into their tags
+	c, err := Parse(`omitempty`)
+	require.NoError(t, err)
+	assert.NotSame(t, keyData(a, "omitempty"), keyData(c, "omitempty"))
+}
@@ -0,0 +1,58 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errUnterminatedPlaceholder is the Cause of a `{{` without its `}}`.
+var errUnterminatedPlaceholder = errors.New("unterminated placeholder")
+
+// resolvePlaceholders replaces the `{{name}}` placeholders of the value of
+// the current item with the results of the resolver set with
+// WithPlaceholderResolver.
+func (p *parser) resolvePlaceholders(key, value string) (string, *Error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+
+	var b strings.Builder
+	rest := value
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start+2:], "}}")
+		if end < 0 {
+			return "", p.placeholderError(key, value, rest[start:], errUnterminatedPlaceholder)
+		}
+		end += start + 4
+		resolved, err := p.cfg.resolve(strings.TrimSpace(rest[start+2 : end-2]))
+		if err != nil {
+			return "", p.placeholderError(key, value, rest[start:end], err)
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(resolved)
+		rest = rest[end:]
+	}
+	b.WriteString(rest)
+
+	return b.String(), nil
+}
+
+// placeholderError reports the placeholder of the current item that could
+// not be resolved, at its position in the tag when it is written there as
+// is, and otherwise at the position of the value.
+func (p *parser) placeholderError(key, value, placeholder string, err error) *Error {
+	valueErr := p.invalidValue(key, value, err)
+	if key == "" {
+		valueErr.Msg = fmt.Sprintf("invalid name %q", value)
+	}
+	if i := strings.Index(p.tag[valueErr.Pos:p.pos], placeholder); i >= 0 {
+		valueErr.Pos += i
+	}
+
+	return valueErr.locate()
+}
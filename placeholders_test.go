@@ -0,0 +1,80 @@
+package tagparser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPlaceholderResolver(t *testing.T) {
+	vars := map[string]string{"tenant": "acme", "region": "eu"}
+	var calls int
+	p := New(
+		WithPlaceholderResolver(func(name string) (string, error) {
+			calls++
+			value, ok := vars[name]
+			if !ok {
+				return "", errors.New("undefined")
+			}
+
+			return value, nil
+		}),
+		WithValidator("table", func(value string) error {
+			if value == "" {
+				return errors.New("empty table")
+			}
+
+			return nil
+		}),
+	)
+
+	tag, err := p.ParseWithName(`{{tenant}}_id,table={{ tenant }}_{{region}}_users,omitempty,note='a {{region}}'`)
+	require.NoError(t, err)
+	assert.Equal(t, "acme_id", tag.Name)
+	assert.Equal(t, M{"table": "acme_eu_users", "omitempty": "", "note": "a eu"}, tag.Options)
+	assert.Equal(t, 4, calls)
+
+	// Resolved values are not searched again
+	vars["loop"] = "{{loop}}"
+	tag, err = p.Parse(`x={{loop}}`)
+	require.NoError(t, err)
+	assert.Equal(t, "{{loop}}", tag.Options["x"])
+
+	tests := []struct {
+		tag string
+		msg string
+		pos int
+	}{
+		{`a=1,table={{tenant}}_{{zone}}`, `invalid value "{{tenant}}_{{zone}}" for "table": undefined (at 22)`, 21},
+		{`table=x{{tenant`, `invalid value "x{{tenant" for "table": unterminated placeholder (at 8)`, 7},
+		{`table='{{zone}}'`, `invalid value "{{zone}}" for "table": undefined (at 8)`, 7},
+		{`table={{}}`, `invalid value "{{}}" for "table": undefined (at 7)`, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			_, err := p.Parse(tt.tag)
+			require.EqualError(t, err, tt.msg)
+			var parseErr *Error
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, CodeInvalidValue, parseErr.Code)
+			assert.Equal(t, tt.pos, parseErr.Pos)
+		})
+	}
+
+	_, err = p.ParseWithName(`{{zone}},a`)
+	assert.EqualError(t, err, `invalid name "{{zone}}": undefined (at 1)`)
+
+	// Validators see the resolved value
+	vars["empty"] = ""
+	_, err = p.Parse(`table={{empty}}`)
+	assert.EqualError(t, err, `invalid value "" for "table": empty table (at 7)`)
+
+	lenient := New(WithLenient(), WithPlaceholderResolver(func(name string) (string, error) {
+		return "", errors.New("undefined")
+	}))
+	tag, err = lenient.Parse(`a={{x}},b=1`)
+	assert.Equal(t, M{"b": "1"}, tag.Options)
+	assert.EqualError(t, err, `invalid value "{{x}}" for "a": undefined (at 3)`)
+}
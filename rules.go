@@ -0,0 +1,108 @@
+package tagparser
+
+// Rule is an item of a tag read as a validation rule, such as `required`,
+// `min=5`, `oneof=a|b|c` or, with WithGroups, `between(1,10)`.
+type Rule struct {
+	Name   string   // Key of the item
+	Params []string // Parameters, nil for a rule without any
+	Pos    int      // 0-based offset of Name in the tag
+	Alt    bool     // The rule follows a '|', an alternative to the rule before it, see WithAlternatives
+}
+
+// ParseRules parses a tag treating every item as a rule and returns the
+// rules in order, repetitions included, which the map of a Tag cannot
+// represent. This is the representation rule engines work with:
+//
+//	rules, _ := tagparser.ParseRules(`required,min=1,oneof=a|b,min=2`)
+//	// rules[2] == tagparser.Rule{Name: "oneof", Params: []string{"a", "b"}, Pos: 15}
+//
+// The value of a rule is split into Params like Tag.GetSlice splits it.
+// With WithGroups, the parameters of a group such as `between(1, 10)` are
+// its items, unquoted and trimmed; nested groups are kept as written.
+func ParseRules(tag string) ([]Rule, error) {
+	return defaultParser.ParseRules(tag)
+}
+
+// ParseRules returns the rules of a tag in order, like the package-level
+// ParseRules. A lenient Parser returns the well-formed rules along with the
+// errors. With WithAlternatives, every alternative is a rule of its own,
+// with Alt set; use ParseAlternatives to keep them together.
+func (p *Parser) ParseRules(tag string) ([]Rule, error) {
+	alts, err := p.parseRules(tag)
+	if alts == nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	for _, alt := range alts {
+		rules = append(rules, alt...)
+	}
+
+	return rules, err
+}
+
+// parseRules returns the rules of a tag as lists of alternatives.
+func (p *Parser) parseRules(tag string) ([][]Rule, error) {
+	ps := parser{cfg: p, tag: tag}
+	list := newListFormat(p.listSep)
+	var alts [][]Rule
+	err := ps.check(func(key, value string) *Error {
+		keyPos, valPos := ps.positions(key)
+		rule := Rule{Name: key, Pos: keyPos, Alt: ps.alternative}
+		switch {
+		case ps.group:
+			params, err := ps.groupParams(valPos)
+			if err != nil {
+				return err
+			}
+			rule.Params = params
+		case value == "":
+		case p.alternatives && p.listSep == 0:
+			rule.Params = []string{value}
+		default:
+			list.escaped = ps.escapedSeps
+			rule.Params = list.split(value)
+		}
+		if ps.alternative && len(alts) > 0 {
+			alts[len(alts)-1] = append(alts[len(alts)-1], rule)
+		} else {
+			alts = append(alts, []Rule{rule})
+		}
+
+		return nil
+	})
+	if err == nil {
+		err = ps.err()
+	}
+	if err != nil && !p.lenient {
+		return nil, err
+	}
+
+	return alts, err
+}
+
+// groupParams returns the items of the group last returned by next, whose
+// value starts at valPos.
+func (p *parser) groupParams(valPos int) ([]string, *Error) {
+	cfg := p.cfg.syntax()
+	cfg.kvSep, cfg.foldKeys, cfg.negation, cfg.alternatives, cfg.limits = 0, false, false, false, limits{}
+	sub := parser{cfg: &cfg, tag: p.tag[:p.groupEnd], pos: valPos, start: valPos, itemStart: valPos, keyStart: valPos}
+
+	var params []string
+	for {
+		key, _, ok, err := sub.next()
+		if err != nil {
+			subErr := err.(*Error) //nolint:errorlint,forcetypeassert // the scanner only returns *Error
+			subErr.Tag = p.tag
+
+			return nil, subErr
+		}
+		if !ok {
+			return params, nil
+		}
+		if sub.group {
+			key = sub.raw(sub.itemStart, sub.pos)
+		}
+		params = append(params, key)
+	}
+}
@@ -0,0 +1,51 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(`required,min=1,oneof=a|b,min=2,msg=`)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Name: "required", Pos: 0},
+		{Name: "min", Params: []string{"1"}, Pos: 9},
+		{Name: "oneof", Params: []string{"a", "b"}, Pos: 15},
+		{Name: "min", Params: []string{"2"}, Pos: 25},
+		{Name: "msg", Pos: 31},
+	}, rules)
+
+	rules, err = New(WithListSeparator(';')).ParseRules(`oneof=a\;b;c`)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{{Name: "oneof", Params: []string{"a;b", "c"}}}, rules)
+
+	rules, err = ParseRules(``)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+}
+
+func TestParseRules_Groups(t *testing.T) {
+	p := New(WithGroups(), WithCaseInsensitiveKeys())
+
+	rules, err := p.ParseRules(`Between( 1 , '2,3' ),x(A(b,c),,d),e()`)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Name: "between", Params: []string{"1", "2,3"}, Pos: 0},
+		{Name: "x", Params: []string{"A(b,c)", "d"}, Pos: 21},
+		{Name: "e", Pos: 34},
+	}, rules)
+
+	_, err = p.ParseRules(`a,b(x'y')`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeQuoteInMiddle, parseErr.Code)
+	assert.Equal(t, 5, parseErr.Pos)
+	assert.Equal(t, `a,b(x'y')`, parseErr.Tag)
+
+	rules, err = New(WithGroups(), WithLenient()).ParseRules(`a(x'y'),b=1`)
+	require.Error(t, err)
+	assert.Equal(t, []Rule{{Name: "b", Params: []string{"1"}, Pos: 8}}, rules)
+}
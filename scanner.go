@@ -0,0 +1,120 @@
+package tagparser
+
+import "errors"
+
+// errScannerClosed is returned by Scanner.Feed after Close.
+var errScannerClosed = errors.New("tagparser: Feed after Close")
+
+// Scanner parses a tag arriving in chunks, such as option strings read from
+// the network, reporting each item to its callback as soon as the item is
+// complete:
+//
+//	s := tagparser.NewScanner(func(key, value string) error {
+//	    log.Printf("%s=%s", key, value)
+//	    return nil
+//	})
+//	for chunk := range chunks {
+//	    if err := s.Feed(chunk); err != nil {
+//	        return err
+//	    }
+//	}
+//	return s.Close()
+//
+// Items are the same, with the same errors at the same positions, as those
+// ParseFunc reports for the whole input, except that a Go string literal is
+// not unquoted. The input fed so far counts towards the tag length limit.
+// A Scanner is not safe for concurrent use.
+type Scanner struct {
+	ps       parser
+	buf      []byte // input fed so far, never modified once appended
+	callback func(key, value string) error
+	err      error // error that stopped the scan
+	closed   bool
+}
+
+// NewScanner returns a Scanner treating all items as options, like
+// ParseFunc.
+func NewScanner(callback func(key, value string) error) *Scanner {
+	return defaultParser.NewScanner(callback)
+}
+
+// NewScannerWithName is like NewScanner but treats the first item without
+// equals as a name, reported with an empty key like ParseFuncWithName.
+func NewScannerWithName(callback func(key, value string) error) *Scanner {
+	return defaultParser.NewScannerWithName(callback)
+}
+
+// NewScanner returns a Scanner parsing with the rules of p, like the
+// package-level NewScanner.
+func (p *Parser) NewScanner(callback func(key, value string) error) *Scanner {
+	return &Scanner{ps: parser{cfg: p, partial: true}, callback: callback}
+}
+
+// NewScannerWithName is like NewScanner but treats the first item without
+// equals as a name.
+func (p *Parser) NewScannerWithName(callback func(key, value string) error) *Scanner {
+	return &Scanner{ps: parser{cfg: p, treatFirstAsName: true, partial: true}, callback: callback}
+}
+
+// Feed appends b to the input and reports the items it completes. The last
+// item is held back until a separator or Close ends it. Feed returns the
+// error stopping the scan, and keeps returning it; a lenient Parser instead
+// collects errors for Close. The Scanner keeps its own copy of b, and the
+// strings passed to the callback do not share memory with b.
+func (s *Scanner) Feed(b []byte) error {
+	switch {
+	case s.err != nil:
+		return s.err
+	case s.closed:
+		return errScannerClosed
+	}
+
+	s.buf = append(s.buf, b...)
+	s.ps.tag = bytesView(s.buf)
+	if err := s.ps.checkLength(); err != nil {
+		s.err = err
+
+		return err
+	}
+
+	return s.scan()
+}
+
+// Close ends the input and reports its last item. It returns the error
+// stopping the scan or, for a lenient Parser, the errors collected along
+// the way, as ParseFunc would for the whole input.
+func (s *Scanner) Close() error {
+	if s.err != nil || s.closed {
+		return s.err
+	}
+	s.closed = true
+	s.ps.partial = false
+	if err := s.scan(); err != nil {
+		return err
+	}
+	s.err = s.ps.err()
+
+	return s.err
+}
+
+// scan reports the items completed by the input fed so far.
+func (s *Scanner) scan() error {
+	for {
+		key, value, ok, err := s.ps.next()
+		if err != nil {
+			s.err = err
+
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := s.callback(key, value); err != nil {
+			if err := s.ps.collect(s.ps.callbackError(err)); err != nil {
+				s.err = err
+
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package tagparser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var scannerParsers = []*Parser{
+	defaultParser,
+	New(WithLenient()),
+	New(WithGroups(), WithNegation()),
+	New(WithAlternatives(), WithLenient()),
+	New(WithListSeparator('|'), WithEscapeChar('^'), WithQuoteChar('`')),
+	New(WithSeparator(';'), WithKeyValueSeparator(':'), WithMaxOptions(2)),
+}
+
+// checkScanner feeds tag to a Scanner in chunks of every size and checks
+// that it reports the items and errors ParseFunc reports.
+func checkScanner(t *testing.T, p *Parser, tag string, withName bool) {
+	t.Helper()
+
+	var want []KV
+	parse := p.ParseFunc
+	newScanner := p.NewScanner
+	if withName {
+		parse, newScanner = p.ParseFuncWithName, p.NewScannerWithName
+	}
+	wantErr := parse(tag, func(key, value string) error {
+		want = append(want, KV{key, value})
+
+		return nil
+	})
+
+	for size := 1; size <= max(len(tag), 1); size++ {
+		var got []KV
+		s := newScanner(func(key, value string) error {
+			got = append(got, KV{key, value})
+
+			return nil
+		})
+		var err error
+		for i := 0; i < len(tag) && err == nil; i += size {
+			err = s.Feed([]byte(tag[i:min(i+size, len(tag))]))
+		}
+		if err == nil {
+			err = s.Close()
+		}
+		assert.Equal(t, want, got, "tag %q in chunks of %d", tag, size)
+		if wantErr == nil {
+			assert.NoError(t, err, "tag %q in chunks of %d", tag, size)
+		} else {
+			assert.EqualError(t, err, wantErr.Error(), "tag %q in chunks of %d", tag, size)
+		}
+	}
+}
+
+func TestScanner(t *testing.T) {
+	tags := []string{
+		``, `,`, `a`, `a,b=c`, `name,omitempty,min=5`, `a='b,c',d=\,e`, `a='b`, `a=b\`, `a\x`,
+		`=a,b`, `a='b'c,d`, `a=b\\,c`, `a=(x,y),b`, `a=(x,'y)'),b`, `a=(x,b`, `!a,b`, `!a=1`,
+		`a|b,c`, "a=`x;y`^`,b=x|y^|z", `a:1;b:'x;y';c:2`, `a=x^,y`,
+	}
+	for _, p := range scannerParsers {
+		for _, tag := range tags {
+			checkScanner(t, p, tag, false)
+			checkScanner(t, p, tag, true)
+		}
+	}
+}
+
+func TestScanner_Stream(t *testing.T) {
+	var got []KV
+	s := NewScannerWithName(func(key, value string) error {
+		got = append(got, KV{key, value})
+
+		return nil
+	})
+	chunk := []byte(`msg,id=4`)
+	require.NoError(t, s.Feed(chunk))
+	assert.Equal(t, []KV{{"", "msg"}}, got, "the last item waits for its end")
+
+	// The Scanner keeps its own copy of the input
+	copy(chunk, "XXXXXXXX")
+	require.NoError(t, s.Feed([]byte(`2,note='a,`)))
+	assert.Equal(t, []KV{{"", "msg"}, {"id", "42"}}, got)
+	require.NoError(t, s.Feed([]byte(`b'`)))
+	require.NoError(t, s.Close())
+	assert.Equal(t, []KV{{"", "msg"}, {"id", "42"}, {"note", "a,b"}}, got)
+	require.NoError(t, s.Close())
+	assert.ErrorIs(t, s.Feed([]byte(`x`)), errScannerClosed)
+
+	// Errors stop the scan
+	s = NewScanner(func(key, value string) error {
+		if key == "bad" {
+			return errors.New("rejected")
+		}
+
+		return nil
+	})
+	err := s.Feed([]byte(`a,bad,c`))
+	assert.EqualError(t, err, `rejected (at 3)`)
+	assert.Equal(t, err, s.Feed([]byte(`d`)))
+	assert.Equal(t, err, s.Close())
+
+	// The input fed so far counts towards the length limit
+	s = New(WithMaxTagLength(4)).NewScanner(func(string, string) error { return nil })
+	require.NoError(t, s.Feed([]byte(`ab,`)))
+	assert.ErrorIs(t, s.Feed([]byte(`cd`)), ErrTagTooLarge)
+}
@@ -0,0 +1,438 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknownValueType is returned when decoding an invalid ValueType.
+var ErrUnknownValueType = errors.New("unknown value type")
+
+// ValueType is the type of value a Schema key expects.
+type ValueType int
+
+// Value types accepted by KeySpec.Type.
+const (
+	TypeString   ValueType = iota // Any value
+	TypeBool                      // strconv.ParseBool syntax; the value may be omitted
+	TypeInt                       // strconv.ParseInt syntax, base prefixes allowed
+	TypeFloat                     // strconv.ParseFloat syntax
+	TypeDuration                  // time.ParseDuration syntax
+	TypeEnum                      // One of KeySpec.Enum
+)
+
+var valueTypeNames = [...]string{
+	TypeString:   "string",
+	TypeBool:     "bool",
+	TypeInt:      "int",
+	TypeFloat:    "float",
+	TypeDuration: "duration",
+	TypeEnum:     "enum",
+}
+
+// String returns the name of the type, such as "duration".
+func (t ValueType) String() string {
+	if t < 0 || int(t) >= len(valueTypeNames) {
+		return fmt.Sprintf("ValueType(%d)", int(t))
+	}
+
+	return valueTypeNames[t]
+}
+
+// MarshalText encodes the type as its name, so that schemas can be stored
+// as JSON.
+func (t ValueType) MarshalText() ([]byte, error) {
+	if t < 0 || int(t) >= len(valueTypeNames) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownValueType, int(t))
+	}
+
+	return []byte(valueTypeNames[t]), nil
+}
+
+// UnmarshalText decodes a type name such as "int".
+func (t *ValueType) UnmarshalText(text []byte) error {
+	i := slices.Index(valueTypeNames[:], string(text))
+	if i < 0 {
+		return fmt.Errorf("%w: %q", ErrUnknownValueType, text)
+	}
+	*t = ValueType(i)
+
+	return nil
+}
+
+// KeySpec describes one option key of a Schema.
+type KeySpec struct {
+	Required bool      // The key must be present
+	Flag     bool      // The key takes no value, such as omitempty
+	Type     ValueType // Type of the value unless Flag is set
+	Enum     []string  // Valid values for TypeEnum, or restricting those of another type
+	Default  string    // Value of an absent key, see Schema.ApplyDefaults
+
+	// Relations with other keys, checked by Validate when the key is present
+	RequiredWith []string // Keys that must be present too
+	Conflicts    []string // Keys that must be absent, as for mutually exclusive options
+	AtMost       string   // Key whose value this one must not exceed, as for min and max
+
+	// Documentation, see Schema.Describe
+	Description string // What the key does
+	Deprecated  string // Why the key is deprecated or what replaces it, empty if it is not
+}
+
+// Schema describes the options a tag dialect accepts, so that tags can be
+// checked once, for instance at startup, instead of failing later:
+//
+//	var validate = tagparser.Schema{
+//	    Keys: map[string]tagparser.KeySpec{
+//	        "required": {Flag: true},
+//	        "min":      {Type: tagparser.TypeInt},
+//	        "max":      {Type: tagparser.TypeInt},
+//	        "format":   {Type: tagparser.TypeEnum, Enum: []string{"email", "url"}},
+//	    },
+//	}
+//
+//	err := validate.Validate(`required,min=5,omitempy`)
+//
+// A Schema must not be modified while it is in use.
+type Schema struct {
+	WithName     bool               // The first item is a name, as for ParseWithName
+	Keys         map[string]KeySpec // Accepted option keys
+	AllowUnknown bool               // Accept keys missing from Keys
+
+	Version    int         // Version of the dialect, see Migrations
+	Migrations []Migration // Keys renamed by earlier versions, see WithSchemaMigration
+}
+
+// schemaParser parses the tags validated by schemas.
+var schemaParser = New(WithLenient())
+
+// Validate parses tag and checks it against the schema. It reports syntax
+// errors and every violation, ordered by position, as *Errors:
+// unknown keys (CodeUnknownKey), values given to flags (CodeUnexpectedValue),
+// missing values (CodeMissingValue), values of the wrong type
+// (CodeInvalidValue) and, at the end of the tag, missing required keys
+// (CodeMissingKey). It returns nil if the tag is valid.
+//
+// The relations of keys are checked too, at the position of the key
+// declaring them: a key missing from the RequiredWith of a present key is
+// reported with CodeMissingKey, a present key listed in its Conflicts with
+// CodeConflictingKey, and a value exceeding that of its AtMost key with
+// CodeInvalidValue. AtMost compares the values of TypeInt, TypeFloat and
+// TypeDuration keys and is skipped for other types or invalid values.
+func (s *Schema) Validate(tag string) error {
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		tag = unquoted
+	}
+
+	seen := make(map[string]schemaItem, len(s.Keys))
+	ps := parser{cfg: schemaParser, tag: tag, treatFirstAsName: s.WithName}
+	if err := ps.check(func(key, value string) *Error {
+		if key == "" {
+			return nil
+		}
+		keyPos, valPos := ps.positions(key)
+		seen[key] = schemaItem{keyPos: keyPos, valPos: valPos, value: value, start: ps.itemStart, end: ps.itemEnd()}
+
+		return s.checkItem(&ps, key, value)
+	}); err != nil {
+		return err
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(s.Keys)) {
+		if s.Keys[key].Required {
+			if _, ok := seen[key]; !ok {
+				ps.errs = append(ps.errs, (&Error{
+					Tag:    tag,
+					Pos:    len(tag),
+					Msg:    fmt.Sprintf("%s %q", errMissingKey, key),
+					Offset: len(tag),
+					Code:   CodeMissingKey,
+				}).locate())
+			}
+		}
+	}
+	if errs := s.checkRelations(tag, seen); errs != nil {
+		ps.errs = append(ps.errs, errs...)
+		slices.SortStableFunc(ps.errs, func(a, b *Error) int { return a.Pos - b.Pos })
+	}
+
+	return ps.err()
+}
+
+// schemaItem is the last option with a given key found by Validate.
+type schemaItem struct {
+	keyPos, valPos int    // see parser.positions
+	value          string // unquoted value
+	start, end     int    // bounds of the item in the tag
+}
+
+// errorAt returns an error with the given code at pos within the item.
+func (it schemaItem) errorAt(tag string, pos int, code ErrorCode, msg string) *Error {
+	return (&Error{
+		Tag:     tag,
+		Pos:     pos,
+		Msg:     msg,
+		Segment: tag[it.start:it.end],
+		Offset:  it.start,
+		Len:     it.end - it.start,
+		Code:    code,
+	}).locate()
+}
+
+// checkRelations checks the RequiredWith, Conflicts and AtMost relations of
+// the keys found in tag. A conflict declared on both keys is reported once.
+func (s *Schema) checkRelations(tag string, seen map[string]schemaItem) []*Error {
+	var errs []*Error
+	for _, key := range slices.Sorted(maps.Keys(seen)) {
+		spec, it := s.Keys[key], seen[key]
+		for _, other := range spec.RequiredWith {
+			if _, ok := seen[other]; !ok {
+				msg := fmt.Sprintf("%s %q, required with %q", errMissingKey, other, key)
+				errs = append(errs, it.errorAt(tag, it.keyPos, CodeMissingKey, msg))
+			}
+		}
+		for _, other := range spec.Conflicts {
+			otherIt, ok := seen[other]
+			if !ok || other == key || other < key && slices.Contains(s.Keys[other].Conflicts, key) {
+				continue
+			}
+			// Reported at the later of the two options
+			at, later, earlier := it, key, other
+			if otherIt.keyPos > it.keyPos {
+				at, later, earlier = otherIt, other, key
+			}
+			msg := fmt.Sprintf("%q conflicts with %q", later, earlier)
+			errs = append(errs, at.errorAt(tag, at.keyPos, CodeConflictingKey, msg))
+		}
+		if limit, ok := seen[spec.AtMost]; ok && spec.AtMost != "" && it.valPos >= 0 {
+			if exceeds(spec.Type, it.value, limit.value) {
+				msg := fmt.Sprintf("%s %q for %q, exceeds %q", errInvalidValue, it.value, key, spec.AtMost)
+				errs = append(errs, it.errorAt(tag, it.valPos, CodeInvalidValue, msg))
+			}
+		}
+	}
+
+	return errs
+}
+
+// exceeds reports whether value is greater than limit for a numeric or
+// duration type. Values that do not parse are not compared.
+func exceeds(typ ValueType, value, limit string) bool {
+	switch typ {
+	case TypeInt:
+		v, err1 := strconv.ParseInt(value, 0, 64)
+		l, err2 := strconv.ParseInt(limit, 0, 64)
+
+		return err1 == nil && err2 == nil && v > l
+	case TypeFloat:
+		v, err1 := strconv.ParseFloat(value, 64)
+		l, err2 := strconv.ParseFloat(limit, 64)
+
+		return err1 == nil && err2 == nil && v > l
+	case TypeDuration:
+		v, err1 := time.ParseDuration(value)
+		l, err2 := time.ParseDuration(limit)
+
+		return err1 == nil && err2 == nil && v > l
+	default:
+		return false
+	}
+}
+
+// KeyDoc documents one key of a Schema, as returned by Describe.
+type KeyDoc struct {
+	Key         string
+	Type        string   // "flag" or the name of the ValueType, such as "int"
+	Required    bool     // The key must be present
+	Enum        []string // Valid values, if restricted
+	Default     string   // Value of an absent key
+	Description string   // What the key does
+	Deprecated  string   // Why the key is deprecated, empty if it is not
+}
+
+// Describe returns the documentation of the keys of the schema in key
+// order, so that reference pages and --help output for a tag dialect can be
+// generated from the schema instead of being maintained separately:
+//
+//	for _, doc := range schema.Describe() {
+//	    fmt.Printf("  %-10s %-8s %s\n", doc.Key, doc.Type, doc.Description)
+//	}
+func (s *Schema) Describe() []KeyDoc {
+	docs := make([]KeyDoc, 0, len(s.Keys))
+	for _, key := range slices.Sorted(maps.Keys(s.Keys)) {
+		spec := s.Keys[key]
+		typ := spec.Type.String()
+		if spec.Flag {
+			typ = "flag"
+		}
+		docs = append(docs, KeyDoc{
+			Key:         key,
+			Type:        typ,
+			Required:    spec.Required,
+			Enum:        slices.Clone(spec.Enum),
+			Default:     spec.Default,
+			Description: spec.Description,
+			Deprecated:  spec.Deprecated,
+		})
+	}
+
+	return docs
+}
+
+// ApplyDefaults returns a copy of tag with the Default of every key of the
+// schema that tag lacks, so that code reading a validated tag finds every
+// option with a default:
+//
+//	tag, _ := tagparser.ParseWithName(`id,min=5`)
+//	withDefaults := schema.ApplyDefaults(*tag) // adds max=100 if declared
+//
+// Keys whose Default is empty are left absent, and a flag is set by any
+// non-empty Default. tag is not modified.
+func (s *Schema) ApplyDefaults(tag Tag) Tag {
+	out := tag.Filter(func(string, string) bool { return true })
+	for key, spec := range s.Keys {
+		if _, ok := out.Options[key]; ok || spec.Default == "" {
+			continue
+		}
+		if spec.Flag {
+			out.setOption(key, "", false)
+		} else {
+			out.setOption(key, spec.Default, true)
+		}
+	}
+
+	return out
+}
+
+// Coerce converts the options of tag to the types the schema declares, for
+// code that wants typed values once instead of calling strconv everywhere:
+//
+//	opts, err := schema.Coerce(*tag)
+//	limit := opts["max"].(int64)
+//
+// Values are int64 for TypeInt, float64 for TypeFloat, time.Duration for
+// TypeDuration, bool for TypeBool and strings otherwise. Flags and boolean
+// options without a value are true. The name is not an option and is left
+// out. Keys missing from the schema are kept as strings when it allows
+// unknown keys. Every option that cannot be converted is reported, in key
+// order, in an error joining one error per option; absent required keys
+// are left for Validate.
+func (s *Schema) Coerce(tag Tag) (map[string]any, error) {
+	out := make(map[string]any, len(tag.Options))
+	var errs []error
+	for _, key := range tag.Keys() {
+		value, hasValue, _ := tag.Lookup(key)
+		spec, ok := s.Keys[key]
+		switch {
+		case !ok && s.AllowUnknown:
+			out[key] = value
+		case !ok:
+			errs = append(errs, fmt.Errorf("%s %q", errUnknownKey, key))
+		case spec.Flag && hasValue:
+			errs = append(errs, fmt.Errorf("%s for %q", errUnexpectedValue, key))
+		case spec.Flag || (!hasValue && spec.Type == TypeBool):
+			out[key] = true
+		case !hasValue:
+			errs = append(errs, fmt.Errorf("%s for %q", errMissingValue, key))
+		default:
+			v, err := spec.coerce(value)
+			switch {
+			case errors.Is(err, errNotInEnum):
+				errs = append(errs, errors.New(spec.invalid(key, value, err)))
+
+				continue
+			case err != nil:
+				errs = append(errs, fmt.Errorf("%s: %w", spec.invalid(key, value, err), err))
+
+				continue
+			}
+			out[key] = v
+		}
+	}
+	if errs != nil {
+		return nil, errors.Join(errs...)
+	}
+
+	return out, nil
+}
+
+// checkItem checks the item last returned by ps.next against the schema.
+func (s *Schema) checkItem(ps *parser, key, value string) *Error {
+	keyPos, valPos := ps.positions(key)
+
+	spec, ok := s.Keys[key]
+	switch {
+	case !ok && s.AllowUnknown:
+		return nil
+	case !ok:
+		err := ps.errorAt(keyPos, CodeUnknownKey)
+		err.Msg = fmt.Sprintf("%s %q", errUnknownKey, key)
+
+		return err
+	case spec.Flag && valPos >= 0:
+		err := ps.errorAt(valPos, CodeUnexpectedValue)
+		err.Msg = fmt.Sprintf("%s for %q", errUnexpectedValue, key)
+
+		return err
+	case spec.Flag || (valPos < 0 && spec.Type == TypeBool):
+		return nil
+	case valPos < 0:
+		err := ps.errorAt(keyPos, CodeMissingValue)
+		err.Msg = fmt.Sprintf("%s for %q", errMissingValue, key)
+
+		return err
+	}
+
+	if _, cerr := spec.coerce(value); cerr != nil {
+		err := ps.errorAt(valPos, CodeInvalidValue)
+		err.Msg = spec.invalid(key, value, cerr)
+
+		return err
+	}
+
+	return nil
+}
+
+// errNotInEnum is the cause of a value missing from KeySpec.Enum.
+var errNotInEnum = errors.New("not one of the allowed values")
+
+// invalid returns the message of an invalid value of the key, listing the
+// valid values when err is errNotInEnum.
+func (k KeySpec) invalid(key, value string, err error) string {
+	if errors.Is(err, errNotInEnum) {
+		return fmt.Sprintf("%s %q for %q (valid: %s)", errInvalidValue, value, key, strings.Join(k.Enum, ", "))
+	}
+
+	return fmt.Sprintf("%s %q for %q, expected %s", errInvalidValue, value, key, k.Type)
+}
+
+// coerce converts value to the type the key expects, then checks it
+// against Enum. Values of TypeEnum must be listed in Enum, even when it is
+// empty.
+func (k KeySpec) coerce(value string) (any, error) {
+	var v any = value
+	var err error
+	switch k.Type {
+	case TypeBool:
+		v, err = strconv.ParseBool(value)
+	case TypeInt:
+		v, err = strconv.ParseInt(value, 0, 64)
+	case TypeFloat:
+		v, err = strconv.ParseFloat(value, 64)
+	case TypeDuration:
+		v, err = time.ParseDuration(value)
+	case TypeEnum, TypeString:
+	}
+	if err != nil {
+		return nil, err
+	}
+	if (k.Type == TypeEnum || k.Enum != nil) && !slices.Contains(k.Enum, value) {
+		return nil, errNotInEnum
+	}
+
+	return v, nil
+}
@@ -0,0 +1,296 @@
+package tagparser
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSchema = Schema{
+	Keys: map[string]KeySpec{
+		"required": {Flag: true},
+		"min":      {Type: TypeInt},
+		"max":      {Type: TypeInt},
+		"ratio":    {Type: TypeFloat},
+		"timeout":  {Type: TypeDuration},
+		"trim":     {Type: TypeBool},
+		"format":   {Type: TypeEnum, Enum: []string{"email", "url"}},
+		"label":    {},
+		"type":     {Required: true},
+	},
+}
+
+func TestSchema_Validate(t *testing.T) {
+	tests := []string{
+		`type=x`,
+		`type=x,required,min=5,max=0x10,ratio=.5,timeout=1m,trim,format=url,label=`,
+		`type=x,trim=false,label='a, b'`,
+		`"type=x"`,
+	}
+	for _, tag := range tests {
+		t.Run(tag, func(t *testing.T) {
+			assert.NoError(t, testSchema.Validate(tag))
+		})
+	}
+}
+
+func TestSchema_Violations(t *testing.T) {
+	tag := `omitempy,required=yes,min,max=ten,format=utf16,timeout=5`
+	err := testSchema.Validate(tag)
+
+	var errs *Errors
+	require.ErrorAs(t, err, &errs)
+
+	type violation struct {
+		Code ErrorCode
+		Pos  int
+		Msg  string
+	}
+	got := make([]violation, len(errs.List))
+	for i, e := range errs.List {
+		got[i] = violation{e.Code, e.Pos, e.Msg}
+	}
+	assert.Equal(t, []violation{
+		{CodeUnknownKey, 0, `unknown key "omitempy"`},
+		{CodeUnexpectedValue, 18, `unexpected value for "required"`},
+		{CodeMissingValue, 22, `missing value for "min"`},
+		{CodeInvalidValue, 30, `invalid value "ten" for "max", expected int`},
+		{CodeInvalidValue, 41, `invalid value "utf16" for "format" (valid: email, url)`},
+		{CodeInvalidValue, 55, `invalid value "5" for "timeout", expected duration`},
+		{CodeMissingKey, len(tag), `missing required key "type"`},
+	}, got)
+	assert.Equal(t, "omitempy", errs.List[0].Segment)
+}
+
+func TestSchema_SyntaxErrors(t *testing.T) {
+	err := testSchema.Validate(`type=x,=1,min=2`)
+
+	var errs *Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs.List, 1)
+	assert.Equal(t, CodeEmptyKey, errs.List[0].Code)
+}
+
+func TestSchema_WithName(t *testing.T) {
+	s := Schema{WithName: true, Keys: map[string]KeySpec{"omitempty": {Flag: true}}}
+	require.NoError(t, s.Validate(`email,omitempty`))
+	require.NoError(t, s.Validate(`,omitempty`))
+
+	err := s.Validate(`email,string`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeUnknownKey, parseErr.Code)
+	assert.Equal(t, 6, parseErr.Pos)
+}
+
+func TestSchema_AllowUnknown(t *testing.T) {
+	s := Schema{AllowUnknown: true, Keys: map[string]KeySpec{"min": {Type: TypeInt}}}
+	require.NoError(t, s.Validate(`anything=goes,min=1`))
+	require.Error(t, s.Validate(`anything=goes,min=x`))
+}
+
+func TestSchema_ApplyDefaults(t *testing.T) {
+	schema := Schema{
+		WithName: true,
+		Keys: map[string]KeySpec{
+			"min":       {Type: TypeInt, Default: "0"},
+			"max":       {Type: TypeInt, Default: "100"},
+			"omitempty": {Flag: true, Default: "true"},
+			"label":     {},
+		},
+	}
+
+	tag := MustParseWithName(`id,min=5,label=`)
+	out := schema.ApplyDefaults(*tag)
+	assert.Equal(t, "id", out.Name)
+	assert.Equal(t, M{"min": "5", "max": "100", "omitempty": "", "label": ""}, out.Options)
+	_, hasValue, _ := out.Lookup("omitempty")
+	assert.False(t, hasValue, "flags are set as flags")
+	_, hasValue, _ = out.Lookup("label")
+	assert.True(t, hasValue)
+	assert.Equal(t, M{"min": "5", "label": ""}, tag.Options, "tag is not modified")
+
+	assert.Equal(t, M{"min": "0", "max": "100", "omitempty": ""}, schema.ApplyDefaults(Tag{}).Options)
+}
+
+func TestSchema_Coerce(t *testing.T) {
+	tag := MustParse(`type=x,required,min=5,max=0x10,ratio=.5,timeout=1m,trim,format=url,label=`)
+	opts, err := testSchema.Coerce(*tag)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"type":     "x",
+		"required": true,
+		"min":      int64(5),
+		"max":      int64(16),
+		"ratio":    0.5,
+		"timeout":  time.Minute,
+		"trim":     true,
+		"format":   "url",
+		"label":    "",
+	}, opts)
+
+	opts, err = testSchema.Coerce(*MustParse(`trim=false`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"trim": false}, opts)
+
+	_, err = testSchema.Coerce(*MustParse(`omitempy,required=yes,min,max=ten,format=utf16`))
+	require.Error(t, err)
+	assert.Equal(t, strings.Join([]string{
+		`invalid value "utf16" for "format" (valid: email, url)`,
+		`invalid value "ten" for "max", expected int: strconv.ParseInt: parsing "ten": invalid syntax`,
+		`missing value for "min"`,
+		`unknown key "omitempy"`,
+		`unexpected value for "required"`,
+	}, "\n"), err.Error())
+	var numErr *strconv.NumError
+	assert.ErrorAs(t, err, &numErr)
+
+	lax := Schema{AllowUnknown: true, Keys: map[string]KeySpec{"n": {Type: TypeInt}}}
+	opts, err = lax.Coerce(*MustParseWithName(`name,n=1,other=x`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"n": int64(1), "other": "x"}, opts)
+}
+
+func TestSchema_Enum(t *testing.T) {
+	schema := Schema{
+		Keys: map[string]KeySpec{
+			"encoding": {Enum: []string{"utf8", "ascii", "latin1"}},
+			"level":    {Type: TypeInt, Enum: []string{"1", "2", "3"}},
+			"none":     {Type: TypeEnum},
+		},
+	}
+
+	assert.NoError(t, schema.Validate(`encoding=ascii,level=2`))
+
+	tests := []struct {
+		tag string
+		msg string
+	}{
+		{`encoding=utf16`, `invalid value "utf16" for "encoding" (valid: utf8, ascii, latin1) (at 10)`},
+		{`level=4`, `invalid value "4" for "level" (valid: 1, 2, 3) (at 7)`},
+		{`level=x`, `invalid value "x" for "level", expected int (at 7)`},
+		{`none=x`, `invalid value "x" for "none" (valid: ) (at 6)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			err := schema.Validate(tt.tag)
+			require.Error(t, err)
+			assert.Equal(t, tt.msg, err.Error())
+		})
+	}
+
+	opts, err := schema.Coerce(*MustParse(`level=3`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"level": int64(3)}, opts)
+
+	_, err = schema.Coerce(*MustParse(`encoding=utf16`))
+	assert.EqualError(t, err, `invalid value "utf16" for "encoding" (valid: utf8, ascii, latin1)`)
+}
+
+func TestSchema_Relations(t *testing.T) {
+	schema := Schema{
+		Keys: map[string]KeySpec{
+			"min":      {Type: TypeInt, AtMost: "max"},
+			"max":      {Type: TypeInt},
+			"from":     {Type: TypeDuration, AtMost: "to"},
+			"to":       {Type: TypeDuration},
+			"user":     {RequiredWith: []string{"password"}},
+			"password": {},
+			"inline":   {Flag: true, Conflicts: []string{"ref"}},
+			"ref":      {Conflicts: []string{"inline"}},
+		},
+	}
+
+	for _, tag := range []string{`min=1,max=2`, `min=2,max=2`, `min=5`, `from=1s,to=1m`, `user=a,password=b`, `password=b`, `inline`} {
+		assert.NoError(t, schema.Validate(tag), tag)
+	}
+
+	tag := `ref=a,min=10,user=u,max=0x9,inline,from=1h,to=1m`
+	err := schema.Validate(tag)
+
+	var errs *Errors
+	require.ErrorAs(t, err, &errs)
+
+	type violation struct {
+		Code ErrorCode
+		Pos  int
+		Msg  string
+	}
+	got := make([]violation, len(errs.List))
+	for i, e := range errs.List {
+		got[i] = violation{e.Code, e.Pos, e.Msg}
+	}
+	assert.Equal(t, []violation{
+		{CodeInvalidValue, 10, `invalid value "10" for "min", exceeds "max"`},
+		{CodeMissingKey, 13, `missing required key "password", required with "user"`},
+		{CodeConflictingKey, 28, `"inline" conflicts with "ref"`},
+		{CodeInvalidValue, 40, `invalid value "1h" for "from", exceeds "to"`},
+	}, got)
+	assert.Equal(t, "inline", errs.List[2].Segment)
+	assert.Equal(t, "ConflictingKey", CodeConflictingKey.String())
+}
+
+func TestSchema_Describe(t *testing.T) {
+	schema := Schema{
+		Keys: map[string]KeySpec{
+			"omitempty": {Flag: true, Description: "Skip empty values"},
+			"size":      {Type: TypeInt, Deprecated: "use max"},
+			"max":       {Type: TypeInt, Default: "100", Description: "Maximum length"},
+			"format":    {Type: TypeEnum, Enum: []string{"email", "url"}, Required: true},
+		},
+	}
+	assert.Equal(t, []KeyDoc{
+		{Key: "format", Type: "enum", Required: true, Enum: []string{"email", "url"}},
+		{Key: "max", Type: "int", Default: "100", Description: "Maximum length"},
+		{Key: "omitempty", Type: "flag", Description: "Skip empty values"},
+		{Key: "size", Type: "int", Deprecated: "use max"},
+	}, schema.Describe())
+	assert.Empty(t, (&Schema{}).Describe())
+
+	doc, err := schema.JSONSchema()
+	require.NoError(t, err)
+	var parsed struct {
+		Properties struct {
+			Options struct {
+				Properties map[string]map[string]any
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(doc, &parsed))
+	props := parsed.Properties.Options.Properties
+	assert.Equal(t, "Maximum length", props["max"]["description"])
+	assert.Equal(t, true, props["size"]["deprecated"])
+	assert.NotContains(t, props["max"], "deprecated")
+}
+
+func TestValueType_String(t *testing.T) {
+	assert.Equal(t, "duration", TypeDuration.String())
+	assert.Equal(t, "ValueType(42)", ValueType(42).String())
+}
+
+func TestValueType_Text(t *testing.T) {
+	var s Schema
+	err := json.Unmarshal([]byte(`{"withName": true, "keys": {"min": {"type": "int", "required": true}, "omitempty": {"flag": true}}}`), &s)
+	require.NoError(t, err)
+	assert.Equal(t, Schema{
+		WithName: true,
+		Keys: map[string]KeySpec{
+			"min":       {Type: TypeInt, Required: true},
+			"omitempty": {Flag: true},
+		},
+	}, s)
+
+	b, err := json.Marshal(KeySpec{Type: TypeDuration})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Required": false, "Flag": false, "Type": "duration", "Enum": null, "Default": "", "RequiredWith": null, "Conflicts": null, "AtMost": "", "Description": "", "Deprecated": ""}`, string(b))
+
+	var typ ValueType
+	require.ErrorIs(t, typ.UnmarshalText([]byte("decimal")), ErrUnknownValueType)
+	_, err = ValueType(42).MarshalText()
+	require.ErrorIs(t, err, ErrUnknownValueType)
+}
@@ -0,0 +1,127 @@
+package tagparser
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// isSimple reports whether tag can be split by fillSimple rather than the
+// scanner: p checks nothing beyond the syntax and tag is ASCII without
+// quotes or escapes, like most json and validate tags.
+func (p *Parser) isSimple(tag string) bool {
+	if limit := p.maxTagLength(); !p.simple || limit >= 0 && len(tag) > limit {
+		return false
+	}
+	// Non-ASCII bytes are rejected anyway, so RuneSelf matches nothing more
+	quote, escape := p.quote, p.escape
+	if p.literalQuotes {
+		quote = utf8.RuneSelf
+	}
+	if p.noEscapes {
+		escape = utf8.RuneSelf
+	}
+	for i := 0; i < len(tag); i++ {
+		if c := tag[i]; c >= utf8.RuneSelf || c == quote || c == escape {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fillSimple fills t from a tag isSimple accepts by splitting it on the
+// separators. It reports false, leaving t partly filled, if an item needs
+// the scanner to report an error.
+func (p *Parser) fillSimple(t *Tag, tag string, withName bool) bool {
+	for first := true; ; first = false {
+		i := strings.IndexByte(tag, p.sep)
+		if i < 0 {
+			return p.simpleItem(t, tag, withName && first)
+		}
+		if !p.simpleItem(t, tag[:i], withName && first) {
+			return false
+		}
+		tag = tag[i+1:]
+	}
+}
+
+// simpleItem adds an item of a simple tag to t, as the name if name is set
+// and the item has no value.
+func (p *Parser) simpleItem(t *Tag, item string, name bool) bool {
+	if item == "" {
+		return true
+	}
+
+	eq := -1
+	if p.kvSep != 0 {
+		eq = strings.IndexByte(item, p.kvSep)
+	}
+	switch {
+	case eq < 0 && name:
+		t.Name = p.trimSimple(item)
+
+		return true
+	case eq >= 0 && name && p.strictName:
+		return false
+	}
+
+	key, value := item, ""
+	if eq >= 0 {
+		key, value = p.trimSimple(item[:eq]), p.trimSimple(item[eq+1:])
+	} else {
+		key = p.trimSimple(item)
+	}
+	if key == "" {
+		return false
+	}
+	if p.foldKeys {
+		key = strings.ToLower(key)
+	}
+	t.setOption(p.intern(key), value, eq >= 0)
+
+	return true
+}
+
+// trimSimple trims the whitespace around s unless it is preserved.
+func (p *Parser) trimSimple(s string) string {
+	if p.preserveWhitespace {
+		return s
+	}
+	start, end := trimWhitespace(s, p.escape)
+
+	return s[start:end]
+}
+
+// nextSimple is next for a tag isSimple accepts: it finds the separators of
+// an item with strings.IndexByte rather than scanning the item byte by byte,
+// leaving the parser state as next would.
+func (p *parser) nextSimple() (key, value string, ok bool, err error) {
+	for !p.done {
+		if p.atSeparator {
+			p.nextItem()
+		}
+
+		end := len(p.tag)
+		if i := strings.IndexByte(p.tag[p.pos:], p.cfg.sep); i >= 0 {
+			end = p.pos + i
+			p.atSeparator = true
+		} else {
+			p.done = true
+		}
+		if p.cfg.kvSep != 0 {
+			if i := strings.IndexByte(p.tag[p.pos:end], p.cfg.kvSep); i >= 0 {
+				p.pos += i
+				if err := p.setKey(); err != nil {
+					return "", "", false, err
+				}
+			}
+		}
+		p.pos = end
+
+		if key, value, ok, err = p.endItem(); ok || err != nil {
+			return key, value, ok, err
+		}
+	}
+
+	return "", "", false, nil
+}
@@ -0,0 +1,113 @@
+package tagparser
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkSimple compares fillSimple with the scanner on a simple tag.
+func checkSimple(t *testing.T, p *Parser, tag string, withName bool) {
+	t.Helper()
+
+	var fast Tag
+	fast.reset(p)
+	if !p.isSimple(tag) || !p.fillSimple(&fast, tag, withName) {
+		return
+	}
+	var slow Tag
+	slow.reset(p)
+	ps := parser{cfg: p, tag: tag, treatFirstAsName: withName}
+	for {
+		key, value, ok, err := ps.next()
+		require.NoError(t, err, "scanner rejects %q", tag)
+		if !ok {
+			break
+		}
+		if key == "" {
+			slow.Name = value
+		} else {
+			slow.setOption(key, value, ps.inValue)
+		}
+	}
+	assert.Equal(t, slow, fast, "tag %q, withName %v", tag, withName)
+}
+
+var simpleParsers = []*Parser{
+	defaultParser,
+	jsonParser,
+	New(WithSeparator(';'), WithKeyValueSeparator(':'), WithCaseInsensitiveKeys()),
+	New(WithPreserveWhitespace(), WithStrictName()),
+	New(WithListSeparator('|'), WithEscapeChar('^'), WithQuoteChar('`')),
+}
+
+func TestFillSimple(t *testing.T) {
+	tags := []string{
+		``, `,`, `,,`, `a`, `a,`, `,a`, `a,,b`, ` a , b = c `, `a=b=c`, `a=`, `=a`, ` `, `a, ,b`,
+		`name,omitempty,min=5`, `a=1,a,a=`, `Col:ID;NOT NULL`, `x;;y`, `a=b|c`, `k= ;v`, "\ta\t=\tb",
+	}
+	for _, p := range simpleParsers {
+		for _, tag := range tags {
+			for _, withName := range []bool{false, true} {
+				checkSimple(t, p, tag, withName)
+			}
+		}
+	}
+
+	// Tags the scanner must handle
+	assert.False(t, defaultParser.isSimple(`a='b'`))
+	assert.False(t, defaultParser.isSimple(`a=\,`))
+	assert.False(t, defaultParser.isSimple(`a=é`))
+	assert.False(t, New(WithNegation()).isSimple(`a`))
+	assert.True(t, jsonParser.isSimple(`a='b'`))
+}
+
+// scanItems lists the items of tag with their positions, found by
+// nextSimple if split is set and by the scanner otherwise, and the error
+// ending the parse.
+func scanItems(p *Parser, tag string, withName, split bool) ([]string, error) {
+	ps := parser{cfg: p, tag: tag, treatFirstAsName: withName, split: split}
+	var items []string
+	for {
+		key, value, ok, err := ps.next()
+		if err != nil {
+			return items, err
+		}
+		if !ok {
+			return items, ps.err()
+		}
+		keyPos, valPos := ps.positions(key)
+		items = append(items, fmt.Sprintf("%q=%q@%d,%d", key, value, keyPos, valPos))
+	}
+}
+
+// checkNextSimple compares nextSimple with the scanner on a simple tag.
+func checkNextSimple(t *testing.T, p *Parser, tag string, withName bool) {
+	t.Helper()
+
+	if !p.isSimple(tag) {
+		return
+	}
+	want, wantErr := scanItems(p, tag, withName, false)
+	got, gotErr := scanItems(p, tag, withName, true)
+	assert.Equal(t, want, got, "tag %q, withName %v", tag, withName)
+	assert.Equal(t, wantErr, gotErr, "tag %q, withName %v", tag, withName)
+}
+
+func TestNextSimple(t *testing.T) {
+	tags := []string{
+		``, `,`, `a`, `a,`, `,a`, `a,,b`, ` a , b = c `, `a=b=c`, `a=`, `=a`, ` `, `a, ,b`,
+		`a,=b,c`, ` =1, ,x`, `name,omitempty,min=5`, `Col:ID;NOT NULL`, `x;;y`, `:a;b`,
+	}
+	parsers := append(slices.Clone(simpleParsers), New(WithLenient()), New(WithStrictName(), WithLenient()))
+	for _, p := range parsers {
+		for _, tag := range tags {
+			for _, withName := range []bool{false, true} {
+				checkNextSimple(t, p, tag, withName)
+			}
+		}
+	}
+}
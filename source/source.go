@@ -0,0 +1,275 @@
+// Package source extracts and parses the struct tags of Go source files.
+//
+// ParseFile walks the struct types of a parsed file and returns the parsed
+// value of one struct tag namespace for every field, with its position in
+// the source, so that tag linters and migration tools do not need their own
+// AST walking and unquoting:
+//
+//	fset := token.NewFileSet()
+//	file, _ := parser.ParseFile(fset, "user.go", nil, 0)
+//	fields, err := source.ParseFile(fset, file, "json")
+//	for _, f := range fields {
+//	    fmt.Println(fset.Position(f.Pos), f.Type, f.Name, f.Tag.Name)
+//	}
+package source
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/talav/tagparser"
+)
+
+// Field is a struct field with the namespace in its tag.
+type Field struct {
+	Type   string        // Declared type the field belongs to, "" for struct types outside type declarations
+	Name   string        // Field name, or the type name for embedded fields
+	Tag    tagparser.Tag // Value of the namespace, parsed with tagparser.ParseWithName or the dialect
+	Value  string        // Value of the namespace, unquoted
+	Pos    token.Pos     // Position of the first byte of Value in the source
+	Lit    *ast.BasicLit // Struct tag literal
+	Offset int           // Offset of the quoted Value in the unquoted tag, as the valuePos of tagparser.SplitStructTag
+}
+
+// ErrorPos returns the position in the source of the problem reported by
+// err for Value, such as an error from parsing Value with another Parser.
+func (f *Field) ErrorPos(err *tagparser.Error) token.Pos {
+	return ValuePos(f.Lit, f.Offset, err.Pos)
+}
+
+// Pos returns the position in the source of byte offset of the unquoted
+// struct tag literal lit, as in the Pos of an *Error from
+// tagparser.SplitStructTag. It accounts for the opening quote and, in
+// double-quoted literals, for escape sequences. Offsets past the end of the
+// tag map to the closing quote.
+func Pos(lit *ast.BasicLit, offset int) token.Pos {
+	return lit.Pos() + token.Pos(sourceOffset(lit.Value, offset))
+}
+
+// ValuePos returns the position in the source of byte offset of the value
+// of a key in the struct tag literal lit, as in the Pos of an *Error from
+// parsing the value, given the valuePos reported for the key by
+// tagparser.SplitStructTag:
+//
+//	tagparser.SplitStructTag(tag, func(key, value string, valuePos int) error {
+//	    if _, err := tagparser.ParseWithName(value); err != nil {
+//	        var parseErr *tagparser.Error
+//	        if errors.As(err, &parseErr) {
+//	            fmt.Println(fset.Position(source.ValuePos(lit, valuePos, parseErr.Pos)))
+//	        }
+//	    }
+//	    return nil
+//	})
+//
+// It returns lit.Pos() if lit is not a valid struct tag literal.
+func ValuePos(lit *ast.BasicLit, valuePos, offset int) token.Pos {
+	tag, err := strconv.Unquote(lit.Value)
+	if err != nil || valuePos < 0 || valuePos > len(tag) {
+		return lit.Pos()
+	}
+
+	return Pos(lit, valuePos+sourceOffset(tag[valuePos:], offset))
+}
+
+// Error is a malformed struct tag, at the position of the problem in the
+// source.
+type Error struct {
+	Pos   token.Position // Position of the problem
+	Field string         // Field name, or the type name for embedded fields
+	Err   error          // The *tagparser.Error describing the problem
+}
+
+// Error returns the position and the description of the problem.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Pos, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying *tagparser.Error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ParseFile returns the fields of the struct types of file whose tag has the
+// namespace, such as "json", in source order. A field declared with several
+// names is returned once per name. As with reflect.StructTag.Lookup, the
+// first occurrence of the namespace in a tag is used.
+//
+// Malformed tags are skipped and reported in the returned error, which joins
+// an *Error for each of them. Tags that are not valid Go strings are left to
+// the compiler and ignored.
+func ParseFile(fset *token.FileSet, file *ast.File, namespace string) ([]Field, error) {
+	return parseFile(fset, file, namespace, tagparser.ParseWithName)
+}
+
+// ParseFileDialect is like ParseFile but parses the values with the dialect
+// registered under name, as tagparser.ParseDialect does. It returns an error
+// wrapping tagparser.ErrUnknownDialect if no dialect has that name.
+func ParseFileDialect(fset *token.FileSet, file *ast.File, namespace, dialect string) ([]Field, error) {
+	if _, ok := tagparser.LookupDialect(dialect); !ok {
+		return nil, fmt.Errorf("%w %q", tagparser.ErrUnknownDialect, dialect)
+	}
+
+	return parseFile(fset, file, namespace, func(tag string) (*tagparser.Tag, error) {
+		return tagparser.ParseDialect(dialect, tag)
+	})
+}
+
+func parseFile(fset *token.FileSet, file *ast.File, namespace string, parse func(string) (*tagparser.Tag, error)) ([]Field, error) {
+	var fields []Field
+	var errs []error
+	types := []string{""} // enclosing declared type of each visited node
+	ast.Inspect(file, func(n ast.Node) bool {
+		typeName := types[len(types)-1]
+		switch n := n.(type) {
+		case nil:
+			types = types[:len(types)-1]
+
+			return true
+		case *ast.TypeSpec:
+			typeName = n.Name.Name
+		case *ast.FuncDecl, *ast.FuncLit:
+			typeName = ""
+		case *ast.StructType:
+			for _, field := range n.Fields.List {
+				if field.Tag == nil {
+					continue
+				}
+				f, err := parseField(fset, field, namespace, parse)
+				if err != nil {
+					errs = append(errs, err)
+
+					continue
+				}
+				if f.Lit == nil {
+					continue
+				}
+				f.Type = typeName
+				for _, name := range fieldNames(field) {
+					f.Name = name
+					fields = append(fields, f)
+				}
+			}
+		}
+		types = append(types, typeName)
+
+		return true
+	})
+
+	return fields, errors.Join(errs...)
+}
+
+// parseField parses the namespace in the tag of field. It returns a Field
+// without Lit when the namespace is absent.
+func parseField(fset *token.FileSet, field *ast.Field, namespace string, parse func(string) (*tagparser.Tag, error)) (Field, error) {
+	lit := field.Tag
+	tag, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return Field{}, nil //nolint:nilerr // not valid Go, reported by the compiler
+	}
+
+	// errorAt returns the error at pos
+	errorAt := func(pos token.Pos, err error) error {
+		return &Error{Pos: fset.Position(pos), Field: fieldNames(field)[0], Err: err}
+	}
+
+	var f Field
+	err = tagparser.SplitStructTag(tag, func(key, value string, valuePos int) error {
+		if key != namespace || f.Lit != nil {
+			return nil
+		}
+		parsed, err := parse(value)
+		if err != nil {
+			var parseErr *tagparser.Error
+			if errors.As(err, &parseErr) {
+				return errorAt(ValuePos(lit, valuePos, parseErr.Pos), fmt.Errorf("%s tag: %w", key, err))
+			}
+
+			return errorAt(Pos(lit, valuePos), fmt.Errorf("%s tag: %w", key, err))
+		}
+
+		f = Field{
+			Tag:    *parsed,
+			Value:  value,
+			Pos:    ValuePos(lit, valuePos, 0),
+			Lit:    lit,
+			Offset: valuePos,
+		}
+
+		return nil
+	})
+
+	var parseErr *tagparser.Error
+	if errors.As(err, &parseErr) && parseErr.Code == tagparser.CodeStructTagSyntax {
+		return Field{}, errorAt(Pos(lit, parseErr.Pos), err)
+	}
+
+	return f, err
+}
+
+// fieldNames returns the names of a field, or the type name of an embedded
+// field.
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) == 0 {
+		return []string{embeddedName(field.Type)}
+	}
+
+	names := make([]string, len(field.Names))
+	for i, name := range field.Names {
+		names[i] = name.Name
+	}
+
+	return names
+}
+
+// embeddedName returns the name of the embedded type expr, such as "Base"
+// for *pkg.Base[T].
+func embeddedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(e.X)
+	case *ast.IndexListExpr:
+		return embeddedName(e.X)
+	default:
+		return ""
+	}
+}
+
+// sourceOffset returns the offset in the Go string literal quoted of byte n
+// of its unquoted value. Offsets past the end of the value map to the
+// closing quote.
+func sourceOffset(quoted string, n int) int {
+	if quoted == "" {
+		return 0
+	}
+
+	q := quoted[0]
+	if q == '`' {
+		return min(1+n, len(quoted)-1)
+	}
+
+	s := quoted[1:]
+	for n > 0 && s != "" && s[0] != q {
+		r, multibyte, tail, err := strconv.UnquoteChar(s, q)
+		if err != nil {
+			break
+		}
+		if multibyte {
+			n -= utf8.RuneLen(r)
+		} else {
+			n--
+		}
+		s = tail
+	}
+
+	return len(quoted) - len(s)
+}
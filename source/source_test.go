@@ -0,0 +1,141 @@
+package source_test
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talav/tagparser"
+	"github.com/talav/tagparser/source"
+)
+
+const src = `package a
+
+type User struct {
+	ID          int    ` + "`json:\"id\" db:\"user_id\"`" + `
+	First, Last string ` + "`json:\"name,omitempty\"`" + `
+	*Base              ` + "`json:\",inline\" json:\"ignored\"`" + `
+	Plain       string
+	Other       string ` + "`db:\"other\"`" + `
+	Inner       struct {
+		Value int ` + "\"json:\\\"value,min=1\\\"\"" + `
+	}
+}
+
+func f() {
+	var x struct {
+		Local int ` + "`json:\"local\"`" + `
+	}
+	_ = x
+}
+`
+
+func TestParseFile(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "a.go", src, 0)
+	require.NoError(t, err)
+
+	fields, err := source.ParseFile(fset, file, "json")
+	require.NoError(t, err)
+
+	var got []string
+	for _, f := range fields {
+		p := fset.Position(f.Pos)
+		got = append(got, fmt.Sprintf("%d:%d %s.%s %q %v", p.Line, p.Column, f.Type, f.Name, f.Tag.Name, f.Tag.Options))
+	}
+	assert.Equal(t, []string{
+		`4:28 User.ID "id" map[]`,
+		`5:28 User.First "name" map[omitempty:]`,
+		`5:28 User.Last "name" map[omitempty:]`,
+		`6:28 User.Base "" map[inline:]`,
+		`10:21 User.Value "value" map[min:1]`,
+		`16:20 .Local "local" map[]`,
+	}, got)
+	assert.Equal(t, "value,min=1", fields[4].Value)
+	assert.Equal(t, "`json:\"id\" db:\"user_id\"`", fields[0].Lit.Value)
+}
+
+func TestField_ErrorPos(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "a.go", src, 0)
+	require.NoError(t, err)
+	fields, err := source.ParseFile(fset, file, "json")
+	require.NoError(t, err)
+
+	// Double-quoted literal, with escaped quotes before the value
+	f := fields[4]
+	_, err = tagparser.New(tagparser.WithKnownKeys()).ParseWithName(f.Value)
+	var parseErr *tagparser.Error
+	require.ErrorAs(t, err, &parseErr)
+	p := fset.Position(f.ErrorPos(parseErr))
+	assert.Equal(t, "10:27", fmt.Sprintf("%d:%d", p.Line, p.Column))
+
+	lit := fields[0].Lit
+	p = fset.Position(source.Pos(lit, 0))
+	assert.Equal(t, "4:22", fmt.Sprintf("%d:%d", p.Line, p.Column))
+	assert.Equal(t, f.Pos, source.ValuePos(f.Lit, f.Offset, 0))
+	assert.Equal(t, lit.Pos(), source.ValuePos(lit, 100, 0))
+}
+
+func TestParseFile_Errors(t *testing.T) {
+	const src = `package a
+
+type T struct {
+	A string ` + "`json:\"a,=x\"`" + `
+	B string ` + "\"json:\\\"b,c='d\\\"\"" + `
+	C string ` + "`json:\"c`" + `
+	D string ` + "`json:\"d\"`" + `
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "a.go", src, 0)
+	require.NoError(t, err)
+
+	fields, err := source.ParseFile(fset, file, "json")
+	require.Len(t, fields, 1)
+	assert.Equal(t, "D", fields[0].Name)
+
+	var errs []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() { //nolint:forcetypeassert,errorlint // joined by ParseFile
+		var srcErr *source.Error
+		require.ErrorAs(t, e, &srcErr)
+		errs = append(errs, fmt.Sprintf("%d:%d %s", srcErr.Pos.Line, srcErr.Pos.Column, srcErr.Field))
+	}
+	assert.Equal(t, []string{"4:20 A", "5:23 B", "6:17 C"}, errs)
+
+	var parseErr *tagparser.Error
+	require.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, tagparser.CodeEmptyKey, parseErr.Code)
+	assert.Contains(t, err.Error(), "a.go:4:20: A: json tag: ")
+}
+
+func TestParseFileDialect(t *testing.T) {
+	const src = `package a
+
+type T struct {
+	A string ` + "`gorm:\"column:a;NOT NULL\"`" + `
+	B string ` + "`xml:\"b,attr,chardata\"`" + `
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "a.go", src, 0)
+	require.NoError(t, err)
+
+	fields, err := source.ParseFileDialect(fset, file, "gorm", "gorm")
+	require.NoError(t, err)
+	require.Len(t, fields, 1)
+	assert.Equal(t, map[string]string{"column": "a", "not null": ""}, fields[0].Tag.Options)
+
+	_, err = source.ParseFileDialect(fset, file, "xml", "xml")
+	var srcErr *source.Error
+	require.ErrorAs(t, err, &srcErr)
+	assert.Equal(t, 5, srcErr.Pos.Line)
+
+	_, err = source.ParseFileDialect(fset, file, "gorm", "nope")
+	require.ErrorIs(t, err, tagparser.ErrUnknownDialect)
+}
@@ -0,0 +1,337 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// ErrNotStruct is returned when a struct type is expected but another type
+// is given.
+var ErrNotStruct = errors.New("not a struct type")
+
+// SkipStruct can be returned by a WalkStruct callback for a struct-typed
+// field to skip walking its fields. It is not returned as an error by
+// WalkStruct.
+var SkipStruct = errors.New("skip this struct") //nolint:errname // mirrors fs.SkipDir
+
+type structCacheKey struct {
+	typ    reflect.Type
+	tagKey string
+}
+
+type structCacheEntry struct {
+	tags map[string]Tag
+	err  error
+}
+
+// structCache holds ParseStruct results per type and tag key.
+var structCache sync.Map // map[structCacheKey]*structCacheEntry
+
+// ParseStruct parses the tagKey struct tag of every exported field of t like
+// ParseStructTag, with ParseWithName, and returns the tags keyed by field
+// name. Fields without the tag are omitted. t may be a struct type or a
+// pointer to one.
+//
+// Results are cached per type and tag key, so the returned map is shared
+// between callers and must be treated as read-only. The error names the
+// struct and field whose tag is malformed.
+func ParseStruct(t reflect.Type, tagKey string) (map[string]Tag, error) {
+	t = indirectType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %v", ErrNotStruct, t)
+	}
+
+	key := structCacheKey{typ: t, tagKey: tagKey}
+	if cached, ok := structCache.Load(key); ok {
+		entry := cached.(*structCacheEntry) //nolint:forcetypeassert // only *structCacheEntry is stored
+
+		return entry.tags, entry.err
+	}
+
+	tags, err := parseStructFields(t, tagKey)
+	cached, _ := structCache.LoadOrStore(key, &structCacheEntry{tags: tags, err: err})
+	entry := cached.(*structCacheEntry) //nolint:forcetypeassert // only *structCacheEntry is stored
+
+	return entry.tags, entry.err
+}
+
+// Fields is ParseStruct for the struct type T, or the struct type T points
+// to, and shares its cache:
+//
+//	tags, err := tagparser.Fields[User]("json")
+func Fields[T any](tagKey string) (map[string]Tag, error) {
+	return ParseStruct(reflect.TypeFor[T](), tagKey)
+}
+
+// WalkStruct calls fn for every exported field of the struct type of v,
+// descending into nested and embedded struct fields, including through
+// pointers. v may be a struct, a pointer to one, or a reflect.Type.
+// Embedded structs of unexported types are not reported themselves, but
+// their exported fields are.
+//
+// The path holds the field names from the outermost struct to the field and
+// is only valid during the call. tag is the parsed tagKey tag of the field,
+// obtained with ParseStruct; its Options are nil when the field has no such
+// tag. It is a copy, which fn may modify with Set, Delete or Rename without
+// affecting the tags ParseStruct returns. Fields are visited in declaration
+// order, a struct field before its own fields. Returning SkipStruct from fn
+// for a struct field skips its fields; any other error stops the walk and
+// is returned.
+//
+// Recursive types are walked once per path: a struct type is not entered
+// again while walking its own fields.
+//
+// By default embedded structs are walked like named struct fields. Use
+// WithFlattenEmbedded to promote their fields instead.
+func WalkStruct(v any, tagKey string, fn func(path []string, field reflect.StructField, tag Tag) error, opts ...WalkOption) error {
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	t = indirectType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %v", ErrNotStruct, t)
+	}
+
+	w := structWalker{tagKey: tagKey, fn: fn}
+	for _, opt := range opts {
+		opt(&w)
+	}
+
+	return w.walk(t)
+}
+
+// WalkOption configures WalkStruct.
+type WalkOption func(*structWalker)
+
+// MergePolicy combines the tag of an embedded struct field (outer) with the
+// tag of a field it promotes (inner) into the tag reported for the promoted
+// field. It must not modify either tag.
+type MergePolicy func(outer, inner Tag) Tag
+
+// WithFlattenEmbedded makes WalkStruct flatten embedded structs: their fields
+// are reported as fields of the embedding struct, following Go's promotion
+// rules, and the embedded fields themselves are not reported. Promoted fields
+// have paths without the embedded struct names and an Index relative to the
+// embedding struct, as returned by reflect.VisibleFields.
+//
+// When the embedded field carries a tag, it is merged into the tag of every
+// field it promotes using policy, from the innermost embedding outwards.
+// A nil policy means OuterOverrides.
+func WithFlattenEmbedded(policy MergePolicy) WalkOption {
+	if policy == nil {
+		policy = OuterOverrides
+	}
+
+	return func(w *structWalker) {
+		w.merge = policy
+	}
+}
+
+// OuterOverrides is a MergePolicy where options set on the embedded field
+// override the same options of the promoted field.
+func OuterOverrides(outer, inner Tag) Tag {
+	return mergeOptions(&inner, &inner, &outer)
+}
+
+// InnerOverrides is a MergePolicy where options of the embedded field act as
+// defaults that the promoted field's own options override.
+func InnerOverrides(outer, inner Tag) Tag {
+	return mergeOptions(&inner, &outer, &inner)
+}
+
+// NoInheritance is a MergePolicy that ignores the embedded field's tag.
+func NoInheritance(_, inner Tag) Tag {
+	return inner
+}
+
+// mergeOptions returns a Tag with the name and settings of inner and the
+// options of base overridden by those of override, along with how they
+// were written.
+func mergeOptions(inner, base, override *Tag) Tag {
+	out := Tag{
+		Name:    inner.Name,
+		Options: make(map[string]string, len(base.Options)+len(override.Options)),
+		listSep: inner.listSep,
+		groups:  inner.groups,
+		bools:   inner.bools,
+	}
+	for key := range base.Options {
+		out.copyOption(base, key)
+	}
+	for key := range override.Options {
+		out.copyOption(override, key)
+	}
+
+	return out
+}
+
+type structWalker struct {
+	tagKey string
+	fn     func(path []string, field reflect.StructField, tag Tag) error
+	merge  MergePolicy // non-nil when flattening embedded structs
+	path   []string
+	active []reflect.Type // struct types being walked, to stop recursion
+}
+
+func (w *structWalker) walk(t reflect.Type) error {
+	if w.merge != nil {
+		return w.walkFlat(t)
+	}
+
+	tags, err := ParseStruct(t, w.tagKey)
+	if err != nil {
+		return err
+	}
+
+	w.active = append(w.active, t)
+	defer func() { w.active = w.active[:len(w.active)-1] }()
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		// Unexported embedded structs are walked for their promoted fields
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		w.path = append(w.path, field.Name)
+		err := w.visit(field, tags[field.Name])
+		w.path = w.path[:len(w.path)-1]
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *structWalker) visit(field reflect.StructField, tag Tag) error {
+	if field.IsExported() {
+		// The maps of tag may belong to the ParseStruct cache
+		err := w.fn(slices.Clip(w.path), field, tag.clone())
+		if errors.Is(err, SkipStruct) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	ft := indirectType(field.Type)
+	if ft.Kind() != reflect.Struct || slices.Contains(w.active, ft) {
+		return nil
+	}
+
+	return w.walk(ft)
+}
+
+func (w *structWalker) walkFlat(t reflect.Type) error {
+	w.active = append(w.active, t)
+	defer func() { w.active = w.active[:len(w.active)-1] }()
+
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || (field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct) {
+			continue
+		}
+
+		tag, err := w.promotedTag(t, field.Index)
+		if err != nil {
+			return err
+		}
+
+		w.path = append(w.path, field.Name)
+		err = w.visit(field, tag)
+		w.path = w.path[:len(w.path)-1]
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// promotedTag returns the tag of the field of t at index, merged with the
+// tags of the embedded fields it is promoted through.
+func (w *structWalker) promotedTag(t reflect.Type, index []int) (Tag, error) {
+	outer := make([]Tag, 0, len(index)-1)
+	for _, i := range index[:len(index)-1] {
+		embedded := t.Field(i)
+		tag, err := w.fieldTag(t, embedded)
+		if err != nil {
+			return Tag{}, err
+		}
+		outer = append(outer, tag)
+		t = indirectType(embedded.Type)
+	}
+
+	tag, err := w.fieldTag(t, t.Field(index[len(index)-1]))
+	if err != nil {
+		return Tag{}, err
+	}
+	for i := len(outer) - 1; i >= 0; i-- {
+		if outer[i].Options != nil {
+			tag = w.merge(outer[i], tag)
+		}
+	}
+
+	return tag, nil
+}
+
+// fieldTag returns the parsed tag of a field of t, which may be an unexported
+// embedded field.
+func (w *structWalker) fieldTag(t reflect.Type, field reflect.StructField) (Tag, error) {
+	tags, err := ParseStruct(t, w.tagKey)
+	if err != nil {
+		return Tag{}, err
+	}
+	if field.IsExported() {
+		return tags[field.Name], nil
+	}
+
+	// ParseStruct skips unexported fields, but embedded ones still pass
+	// their tags on to promoted fields, parsed as by ParseStruct
+	raw, ok := field.Tag.Lookup(w.tagKey)
+	if !ok {
+		return Tag{}, nil
+	}
+	tag, err := defaultParser.result(defaultParser.parseUnquoted(raw, true))
+	if err != nil {
+		return Tag{}, &FieldError{Struct: t.Name(), Field: field.Name, Key: w.tagKey, Err: err}
+	}
+
+	return *tag, nil
+}
+
+// indirectType dereferences pointer types.
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+func parseStructFields(t reflect.Type, tagKey string) (map[string]Tag, error) {
+	tags := make(map[string]Tag)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		raw, ok := field.Tag.Lookup(tagKey)
+		if !ok {
+			continue
+		}
+		// Lookup has unquoted the value already, see ParseStructTag
+		tag, err := defaultParser.result(defaultParser.parseUnquoted(raw, true))
+		if err != nil {
+			return nil, &FieldError{Struct: t.Name(), Field: field.Name, Key: tagKey, Err: err}
+		}
+		tags[field.Name] = *tag
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,304 @@
+package tagparser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type structUser struct {
+	ID       int    `json:"id" db:"user_id,pk"`
+	Email    string `json:"email,omitempty" validate:"required,email"`
+	Nickname string
+	password string `db:"password"` //nolint:unused // verifies unexported fields are skipped
+}
+
+type structBroken struct {
+	Name string `json:"name,=x"`
+}
+
+func TestParseStruct(t *testing.T) {
+	tags, err := ParseStruct(reflect.TypeOf(structUser{}), "json")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Tag{
+		"ID":    {Name: "id", Options: M{}},
+		"Email": {Name: "email", Options: M{"omitempty": ""}},
+	}, tags)
+
+	tags, err = ParseStruct(reflect.TypeOf(&structUser{}), "db")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Tag{"ID": {Name: "user_id", Options: M{"pk": ""}}}, tags)
+}
+
+func TestParseStruct_EscapedQuote(t *testing.T) {
+	// The value from StructTag.Lookup is not unquoted a second time
+	type quoted struct {
+		Name string `json:"\"x,y\""`
+	}
+	tags, err := ParseStruct(reflect.TypeOf(quoted{}), "json")
+	require.NoError(t, err)
+	want, err := ParseStructTag(`json:"\"x,y\""`)
+	require.NoError(t, err)
+	assert.Equal(t, want["json"], tags["Name"])
+	assert.Equal(t, Tag{Name: `"x`, Options: M{`y"`: ""}}, tags["Name"])
+}
+
+func TestFields(t *testing.T) {
+	tags, err := Fields[structUser]("validate")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Tag{"Email": {Name: "required", Options: M{"email": ""}}}, tags)
+
+	cached, err := Fields[*structUser]("validate")
+	require.NoError(t, err)
+	assert.Equal(t, reflect.ValueOf(tags).Pointer(), reflect.ValueOf(cached).Pointer())
+
+	_, err = Fields[structBroken]("json")
+	require.Error(t, err)
+	_, err = Fields[int]("json")
+	require.ErrorIs(t, err, ErrNotStruct)
+}
+
+func TestParseStruct_Cached(t *testing.T) {
+	tags1, err := ParseStruct(reflect.TypeOf(structUser{}), "validate")
+	require.NoError(t, err)
+	tags2, err := ParseStruct(reflect.TypeOf(structUser{}), "validate")
+	require.NoError(t, err)
+	assert.Equal(t, reflect.ValueOf(tags1).Pointer(), reflect.ValueOf(tags2).Pointer())
+}
+
+func TestParseStruct_Errors(t *testing.T) {
+	_, err := ParseStruct(reflect.TypeOf(structBroken{}), "json")
+	require.Error(t, err)
+	assert.Equal(t, "structBroken.Name: json tag: empty key (at 6)", err.Error())
+	var parseErr *Error
+	assert.ErrorAs(t, err, &parseErr)
+	var fieldErr *FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "structBroken", fieldErr.Struct)
+	assert.Equal(t, "Name", fieldErr.Field)
+	assert.Equal(t, "json", fieldErr.Key)
+
+	_, err = ParseStruct(reflect.TypeOf(42), "json")
+	require.ErrorIs(t, err, ErrNotStruct)
+
+	_, err = ParseStruct(nil, "json")
+	require.ErrorIs(t, err, ErrNotStruct)
+}
+
+type walkAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type walkBase struct {
+	ID int `json:"id"`
+}
+
+type walkNode struct {
+	Value int       `json:"value"`
+	Next  *walkNode `json:"next,omitempty"`
+}
+
+type walkUser struct {
+	walkBase
+	Name    string       `json:"name"`
+	Home    walkAddress  `json:"home"`
+	Work    *walkAddress `json:"work,omitempty"`
+	Tree    walkNode
+	private walkAddress //nolint:unused // verifies unexported fields are skipped
+}
+
+func TestWalkStruct(t *testing.T) {
+	type visit struct {
+		path string
+		name string
+		has  bool
+	}
+	var visits []visit
+	err := WalkStruct(&walkUser{}, "json", func(path []string, field reflect.StructField, tag Tag) error {
+		visits = append(visits, visit{strings.Join(path, "."), tag.Name, tag.Options != nil})
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []visit{
+		{"walkBase.ID", "id", true},
+		{"Name", "name", true},
+		{"Home", "home", true},
+		{"Home.City", "city", true},
+		{"Home.Zip", "zip", true},
+		{"Work", "work", true},
+		{"Work.City", "city", true},
+		{"Work.Zip", "zip", true},
+		{"Tree", "", false},
+		{"Tree.Value", "value", true},
+		{"Tree.Next", "next", true},
+	}, visits)
+}
+
+func TestWalkStruct_TagsAreCopies(t *testing.T) {
+	type copied struct {
+		Email string `copy:"email,omitempty"`
+	}
+	for range 2 {
+		err := WalkStruct(copied{}, "copy", func(path []string, field reflect.StructField, tag Tag) error {
+			assert.Equal(t, M{"omitempty": ""}, tag.Options)
+			tag.Set("min", "1")
+			tag.Delete("omitempty")
+
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	tags, err := ParseStruct(reflect.TypeOf(copied{}), "copy")
+	require.NoError(t, err)
+	assert.Equal(t, M{"omitempty": ""}, tags["Email"].Options)
+}
+
+func TestWalkStruct_SkipStruct(t *testing.T) {
+	var paths []string
+	err := WalkStruct(reflect.TypeOf(walkUser{}), "json", func(path []string, field reflect.StructField, tag Tag) error {
+		paths = append(paths, strings.Join(path, "."))
+		if field.Name == "Home" || field.Name == "Tree" {
+			return SkipStruct
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"walkBase.ID", "Name", "Home", "Work", "Work.City", "Work.Zip", "Tree"}, paths)
+}
+
+func TestWalkStruct_Errors(t *testing.T) {
+	err := WalkStruct(walkUser{}, "json", func(path []string, field reflect.StructField, tag Tag) error {
+		if field.Name == "Zip" {
+			return errSimulated
+		}
+
+		return nil
+	})
+	require.ErrorIs(t, err, errSimulated)
+
+	type nested struct {
+		Broken structBroken
+	}
+	err = WalkStruct(nested{}, "json", func([]string, reflect.StructField, Tag) error { return nil })
+	require.Error(t, err)
+	assert.Equal(t, "structBroken.Name: json tag: empty key (at 6)", err.Error())
+
+	err = WalkStruct("not a struct", "json", func([]string, reflect.StructField, Tag) error { return nil })
+	require.ErrorIs(t, err, ErrNotStruct)
+}
+
+type flatAudit struct {
+	CreatedBy string `db:"created_by,index"`
+	UpdatedBy string `db:"updated_by"`
+}
+
+type flatModel struct {
+	ID        int    `db:"id,pk,index"`
+	CreatedBy string `db:"created_by"` // shadowed by the outer struct
+	flatAudit `db:",readonly"`
+}
+
+type flatUser struct {
+	flatModel `db:",index=false"`
+	CreatedBy string `db:"author"`
+	Email     string `db:"email"`
+}
+
+func TestWalkStruct_FlattenEmbedded(t *testing.T) {
+	walk := func(policy MergePolicy) map[string]Tag {
+		tags := make(map[string]Tag)
+		err := WalkStruct(flatUser{}, "db", func(path []string, field reflect.StructField, tag Tag) error {
+			name := strings.Join(path, ".")
+			assert.NotContains(t, tags, name)
+			tags[name] = tag
+
+			return nil
+		}, WithFlattenEmbedded(policy))
+		require.NoError(t, err)
+
+		return tags
+	}
+
+	assert.Equal(t, map[string]Tag{
+		"ID":        {Name: "id", Options: M{"pk": "", "index": "false"}},
+		"CreatedBy": {Name: "author", Options: M{}},
+		"UpdatedBy": {Name: "updated_by", Options: M{"readonly": "", "index": "false"}},
+		"Email":     {Name: "email", Options: M{}},
+	}, walk(nil))
+
+	assert.Equal(t, map[string]Tag{
+		"ID":        {Name: "id", Options: M{"pk": "", "index": ""}},
+		"CreatedBy": {Name: "author", Options: M{}},
+		"UpdatedBy": {Name: "updated_by", Options: M{"readonly": "", "index": "false"}},
+		"Email":     {Name: "email", Options: M{}},
+	}, walk(InnerOverrides))
+
+	assert.Equal(t, map[string]Tag{
+		"ID":        {Name: "id", Options: M{"pk": "", "index": ""}},
+		"CreatedBy": {Name: "author", Options: M{}},
+		"UpdatedBy": {Name: "updated_by", Options: M{}},
+		"Email":     {Name: "email", Options: M{}},
+	}, walk(NoInheritance))
+}
+
+func TestWalkStruct_FlattenEmbeddedIndex(t *testing.T) {
+	u := flatUser{}
+	u.UpdatedBy = "bob"
+
+	var got any
+	err := WalkStruct(u, "db", func(path []string, field reflect.StructField, tag Tag) error {
+		if field.Name == "UpdatedBy" {
+			got = reflect.ValueOf(u).FieldByIndex(field.Index).Interface()
+		}
+
+		return nil
+	}, WithFlattenEmbedded(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "bob", got)
+
+	// Cached tags are not modified by merging
+	tags, err := ParseStruct(reflect.TypeOf(flatModel{}), "db")
+	require.NoError(t, err)
+	assert.Equal(t, M{"pk": "", "index": ""}, tags["ID"].Options)
+}
+
+func TestWalkStruct_FlattenEmbeddedEscapedQuote(t *testing.T) {
+	// The tag of the unexported embedded field is parsed as by ParseStruct
+	type quotedUser struct {
+		flatAudit `db:"\",readonly\""`
+	}
+	var got Tag
+	err := WalkStruct(quotedUser{}, "db", func(path []string, field reflect.StructField, tag Tag) error {
+		got = tag
+
+		return nil
+	}, WithFlattenEmbedded(nil))
+	require.NoError(t, err)
+	assert.Equal(t, Tag{Name: "updated_by", Options: M{`readonly"`: ""}}, got)
+}
+
+func TestMergePolicy_KeepsOptionDetails(t *testing.T) {
+	p := New(WithListSeparator(';'))
+	outer, err := p.Parse(`default=,oneof=a\;b;c`)
+	require.NoError(t, err)
+	inner, err := p.Parse(`default=x,min=1`)
+	require.NoError(t, err)
+
+	merged := OuterOverrides(*outer, *inner)
+	_, hasValue, present := merged.Lookup("default")
+	assert.True(t, hasValue)
+	assert.True(t, present)
+	assert.Equal(t, []string{"a;b", "c"}, merged.GetSlice("oneof"))
+	assert.Equal(t, "1", merged.Options["min"])
+
+	merged = InnerOverrides(*outer, *inner)
+	assert.Equal(t, "x", merged.Options["default"])
+	assert.Equal(t, []string{"a;b", "c"}, merged.GetSlice("oneof"))
+}
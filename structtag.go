@@ -0,0 +1,137 @@
+package tagparser
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// ParseStructTag parses a complete struct tag literal such as
+// `json:"name,omitempty" validate:"required,min=5"` and returns the parsed
+// value of every namespace, keyed by namespace.
+//
+// The literal is split following the reflect.StructTag convention: key:"value"
+// pairs separated by spaces, with Go-quoted values. Each value is then parsed
+// with ParseWithName. When a namespace appears more than once the first one
+// is used, as with reflect.StructTag.Lookup.
+//
+// Malformed literals are reported as *Error with code CodeStructTagSyntax and
+// the position in the literal; errors in a value are prefixed with the
+// namespace.
+func ParseStructTag(tag string) (map[string]Tag, error) {
+	return defaultParser.ParseStructTag(tag)
+}
+
+// ParseStructTag parses a complete struct tag literal, like the package-level
+// ParseStructTag, using p to parse each value.
+func (p *Parser) ParseStructTag(tag string) (map[string]Tag, error) {
+	tags := make(map[string]Tag)
+	err := SplitStructTag(tag, func(key, value string, _ int) error {
+		if _, seen := tags[key]; seen {
+			return nil
+		}
+		parsed, err := p.result(p.parseUnquoted(value, true))
+		if err != nil {
+			return fmt.Errorf("%s tag: %w", key, err)
+		}
+		tags[key] = *parsed
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// LookupChain looks up the namespaces keys in order in a struct tag and
+// parses the first one present like ParseStructTag. It returns the parsed tag
+// and the namespace that matched, or an empty namespace when none of them is
+// present, so callers can fall back to the field name:
+//
+//	tag, ns, err := tagparser.LookupChain(field.Tag, "db", "json")
+//	name := tag.Name
+//	if ns == "" || name == "" {
+//	    name = field.Name
+//	}
+func LookupChain(st reflect.StructTag, keys ...string) (Tag, string, error) {
+	for _, key := range keys {
+		raw, ok := st.Lookup(key)
+		if !ok {
+			continue
+		}
+		tag, err := defaultParser.result(defaultParser.parseUnquoted(raw, true))
+		if err != nil {
+			return Tag{}, key, fmt.Errorf("%s tag: %w", key, err)
+		}
+
+		return *tag, key, nil
+	}
+
+	return Tag{}, "", nil
+}
+
+// SplitStructTag enumerates the key:"value" pairs of a struct tag literal
+// without parsing the values. It passes each unquoted value to fn with the
+// offset of its opening quote in tag, so that tools can map positions in
+// the value back to the literal. Iteration stops at the first error returned
+// by fn, which is returned as is; malformed literals are reported like by
+// ParseStructTag.
+func SplitStructTag(tag string, fn func(key, value string, valuePos int) error) error {
+	pos := 0
+	for pos < len(tag) {
+		// Skip leading space
+		if tag[pos] == ' ' {
+			pos++
+
+			continue
+		}
+
+		// Scan to colon. A space, a quote or a control character is a syntax error.
+		keyStart := pos
+		for pos < len(tag) && tag[pos] > ' ' && tag[pos] != ':' && tag[pos] != '"' && tag[pos] != 0x7f {
+			pos++
+		}
+		if pos == keyStart || pos+1 >= len(tag) || tag[pos] != ':' || tag[pos+1] != '"' {
+			return structTagError(tag, pos, "bad syntax for struct tag pair")
+		}
+		key := tag[keyStart:pos]
+		pos++
+
+		// Scan quoted string to find value
+		valueStart := pos
+		pos++
+		for pos < len(tag) && tag[pos] != '"' {
+			if tag[pos] == '\\' {
+				pos++
+			}
+			pos++
+		}
+		if pos >= len(tag) {
+			return structTagError(tag, valueStart, "bad syntax for struct tag value")
+		}
+		pos++
+
+		value, err := strconv.Unquote(tag[valueStart:pos])
+		if err != nil {
+			return structTagError(tag, valueStart, "bad syntax for struct tag value")
+		}
+		if err := fn(key, value, valueStart); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func structTagError(tag string, pos int, msg string) *Error {
+	return (&Error{
+		Tag:     tag,
+		Pos:     pos,
+		Msg:     msg,
+		Segment: tag,
+		Len:     len(tag),
+		Code:    CodeStructTagSyntax,
+	}).locate()
+}
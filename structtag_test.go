@@ -0,0 +1,127 @@
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStructTag(t *testing.T) {
+	tags, err := ParseStructTag(`json:"name,omitempty" validate:"required,min=5"  db:"user_name"`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Tag{
+		"json":     {Name: "name", Options: M{"omitempty": ""}},
+		"validate": {Name: "required", Options: M{"min": "5"}},
+		"db":       {Name: "user_name", Options: M{}},
+	}, tags)
+}
+
+func TestParseStructTag_Values(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want map[string]Tag
+	}{
+		{"empty", ``, map[string]Tag{}},
+		{"escaped quote", `msg:"say \"hi\",x"`, map[string]Tag{"msg": {Name: `say "hi"`, Options: M{"x": ""}}}},
+		{"quoted value kept", `msg:"\"a\""`, map[string]Tag{"msg": {Name: `"a"`, Options: M{}}}},
+		{"first wins", `json:"a" json:"b"`, map[string]Tag{"json": {Name: "a", Options: M{}}}},
+		{"empty value", `json:""`, map[string]Tag{"json": {Options: M{}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := ParseStructTag(tt.tag)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tags)
+		})
+	}
+}
+
+func TestParseStructTag_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		err  string
+	}{
+		{"missing colon", `json "name"`, `bad syntax for struct tag pair (at 5)`},
+		{"unquoted value", `json:name`, `bad syntax for struct tag pair (at 5)`},
+		{"unterminated value", `json:"name`, `bad syntax for struct tag value (at 6)`},
+		{"bad escape", `json:"\q"`, `bad syntax for struct tag value (at 6)`},
+		{"value error", `json:"name" validate:"a,=b"`, `validate tag: empty key (at 3)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseStructTag(tt.tag)
+			require.Error(t, err)
+			assert.Equal(t, tt.err, err.Error())
+			var parseErr *Error
+			require.ErrorAs(t, err, &parseErr)
+		})
+	}
+
+	_, err := ParseStructTag(`json name`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeStructTagSyntax, parseErr.Code)
+}
+
+func TestLookupChain(t *testing.T) {
+	st := reflect.StructTag(`json:"email,omitempty" xml:"mail"`)
+
+	tag, ns, err := LookupChain(st, "db", "json", "xml")
+	require.NoError(t, err)
+	assert.Equal(t, "json", ns)
+	assert.Equal(t, Tag{Name: "email", Options: M{"omitempty": ""}}, tag)
+
+	tag, ns, err = LookupChain(st, "xml", "json")
+	require.NoError(t, err)
+	assert.Equal(t, "xml", ns)
+	assert.Equal(t, "mail", tag.Name)
+
+	tag, ns, err = LookupChain(st, "db", "yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "", ns)
+	assert.Equal(t, Tag{}, tag)
+
+	_, ns, err = LookupChain(`db:"a,=b" json:"c"`, "db", "json")
+	require.Error(t, err)
+	assert.Equal(t, "db", ns)
+	assert.Equal(t, "db tag: empty key (at 3)", err.Error())
+}
+
+func TestLookupChain_EscapedQuote(t *testing.T) {
+	// The value from StructTag.Lookup is not unquoted a second time
+	st := reflect.StructTag(`json:"\"x,y\""`)
+	tag, ns, err := LookupChain(st, "db", "json")
+	require.NoError(t, err)
+	assert.Equal(t, "json", ns)
+	assert.Equal(t, Tag{Name: `"x`, Options: M{`y"`: ""}}, tag)
+
+	tags, err := ParseStructTag(string(st))
+	require.NoError(t, err)
+	assert.Equal(t, tags["json"], tag)
+}
+
+func TestSplitStructTag(t *testing.T) {
+	tag := `json:"name,omitempty"  db:"a\"b"`
+
+	type pair struct {
+		Key, Value string
+		Pos        int
+	}
+	var got []pair
+	err := SplitStructTag(tag, func(key, value string, valuePos int) error {
+		got = append(got, pair{key, value, valuePos})
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []pair{{"json", "name,omitempty", 5}, {"db", `a"b`, 26}}, got)
+
+	err = SplitStructTag(tag, func(string, string, int) error { return errSimulated })
+	require.ErrorIs(t, err, errSimulated)
+}
@@ -0,0 +1,314 @@
+package tagparser
+
+import (
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Keys returns the option keys of t in sorted order.
+func (t *Tag) Keys() []string {
+	return slices.Sorted(maps.Keys(t.Options))
+}
+
+// All returns an iterator over the options of t in key order, for code that
+// renders or hashes tags and needs a deterministic order.
+func (t *Tag) All() iter.Seq2[string, string] {
+	return func(yield func(key, value string) bool) {
+		for _, key := range t.Keys() {
+			if !yield(key, t.Options[key]) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of options of t.
+func (t *Tag) Len() int {
+	return len(t.Options)
+}
+
+// IsEmpty reports whether t has neither a name nor options.
+func (t *Tag) IsEmpty() bool {
+	return t.Name == "" && len(t.Options) == 0
+}
+
+// GetBoolFlag reports whether the flag key is set. present is false if the
+// key is absent. Otherwise val is true for a bare flag such as `omitempty`
+// and for values strconv.ParseBool accepts as true, or those declared with
+// WithBoolValues, and false for any other value, including the "false" of
+// a negated flag (see WithNegation).
+func (t *Tag) GetBoolFlag(key string) (val, present bool) {
+	value, ok := t.Options[key]
+	if !ok {
+		return false, false
+	}
+	b, err := t.bools.parse(value)
+
+	return err == nil && b, true
+}
+
+// GetBool returns the boolean value of the option key: true for a bare flag
+// and for the true values of strconv.ParseBool, false for an absent key and
+// for its false values. A Parser with WithBoolValues also accepts the
+// values it declares, such as `yes` and `off`. Other values are reported
+// with an error, unlike GetBoolFlag.
+func (t *Tag) GetBool(key string) (bool, error) {
+	value, ok := t.Options[key]
+	if !ok {
+		return false, nil
+	}
+	b, err := t.bools.parse(value)
+	if err != nil {
+		return false, fmt.Errorf("%s %q for %q, expected bool", errInvalidValue, value, key)
+	}
+
+	return b, nil
+}
+
+// boolValues are the boolean values accepted besides those of
+// strconv.ParseBool, see WithBoolValues.
+type boolValues struct {
+	truthy, falsy []string
+}
+
+// parse returns the boolean value of an option, true for a flag. b may be
+// nil for strconv.ParseBool alone.
+func (b *boolValues) parse(value string) (bool, error) {
+	if value == "" {
+		return true, nil
+	}
+	v, err := strconv.ParseBool(value)
+	if err == nil || b == nil {
+		return v, err
+	}
+	switch {
+	case slices.ContainsFunc(b.truthy, func(s string) bool { return strings.EqualFold(s, value) }):
+		return true, nil
+	case slices.ContainsFunc(b.falsy, func(s string) bool { return strings.EqualFold(s, value) }):
+		return false, nil
+	}
+
+	return false, err
+}
+
+// Lookup returns the value of the option key and reports how it was
+// written: hasValue is false for a flag such as `default`, and true for an
+// option with a value, even an empty one as in `default=`, which Options
+// cannot tell apart. present is false if the key is absent.
+func (t *Tag) Lookup(key string) (value string, hasValue, present bool) {
+	value, present = t.Options[key]
+
+	return value, value != "" || t.empty[key], present
+}
+
+// Equal reports whether t and u have the same name and options, whatever
+// their order. A flag such as `default` differs from an empty value as in
+// `default=`, as for Lookup, and an escaped list separator differs from a
+// separator, as for GetSlice.
+func (t *Tag) Equal(u *Tag) bool {
+	if t.Name != u.Name || len(t.Options) != len(u.Options) {
+		return false
+	}
+	for key, value := range t.Options {
+		other, hasValue, ok := u.Lookup(key)
+		if !ok || other != value || hasValue != (value != "" || t.empty[key]) ||
+			!slices.Equal(t.escapedSeps(key, value), u.escapedSeps(key, other)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setOption sets the option key while parsing, remembering whether an
+// empty value was written.
+func (t *Tag) setOption(key, value string, hasValue bool) {
+	t.Options[key] = value
+	delete(t.lists, key)
+	switch {
+	case value == "" && hasValue:
+		if t.empty == nil {
+			t.empty = make(map[string]bool)
+		}
+		t.empty[key] = true
+	case t.empty != nil:
+		delete(t.empty, key)
+	}
+}
+
+// copyOption sets the option key of t as it is set in src.
+func (t *Tag) copyOption(src *Tag, key string) {
+	t.setOption(key, src.Options[key], src.empty[key])
+	if list, ok := src.lists[key]; ok {
+		t.setList(key, list.value, list.escaped)
+	}
+}
+
+// clone returns a copy of t that shares no maps with it. A nil Options
+// stays nil.
+func (t *Tag) clone() Tag {
+	out := *t
+	out.Options = maps.Clone(t.Options)
+	out.empty = maps.Clone(t.empty)
+	out.lists = maps.Clone(t.lists)
+
+	return out
+}
+
+// reset empties t for a parse by p, keeping its maps for reuse.
+func (t *Tag) reset(p *Parser) {
+	if t.Options == nil {
+		t.Options = make(map[string]string)
+	} else {
+		clear(t.Options)
+	}
+	clear(t.empty)
+	clear(t.lists)
+	t.Name, t.listSep, t.groups, t.bools = "", p.listSep, p.groupParser(), p.bools
+}
+
+// Set sets the option key to value, replacing any previous value. Every
+// list separator of value separates elements for GetSlice.
+func (t *Tag) Set(key, value string) {
+	if t.Options == nil {
+		t.Options = make(map[string]string)
+	}
+	t.Options[key] = value
+	delete(t.empty, key)
+	delete(t.lists, key)
+}
+
+// SetFlag sets the flag key, as in `omitempty`. It replaces any value the
+// key had.
+func (t *Tag) SetFlag(key string) {
+	t.Set(key, "")
+}
+
+// Delete removes the option key. It is a no-op if key is absent.
+func (t *Tag) Delete(key string) {
+	delete(t.Options, key)
+	delete(t.empty, key)
+	delete(t.lists, key)
+}
+
+// Rename moves the value of option oldKey to newKey, replacing any value
+// newKey had, and reports whether oldKey was present. When it is absent t is
+// left unchanged.
+func (t *Tag) Rename(oldKey, newKey string) bool {
+	value, ok := t.Options[oldKey]
+	if !ok {
+		return false
+	}
+	empty, list := t.empty[oldKey], t.lists[oldKey]
+	t.Delete(oldKey)
+	t.setOption(newKey, value, empty)
+	if list.escaped != nil {
+		t.setList(newKey, list.value, list.escaped)
+	}
+
+	return true
+}
+
+// Filter returns a copy of t holding only the options for which keep
+// returns true. t is not modified.
+func (t *Tag) Filter(keep func(key, value string) bool) Tag {
+	out := Tag{Name: t.Name, Options: make(map[string]string), listSep: t.listSep, groups: t.groups, bools: t.bools}
+	for key, value := range t.Options {
+		if keep(key, value) {
+			out.copyOption(t, key)
+		}
+	}
+
+	return out
+}
+
+// Only returns a copy of t holding only the options of t whose keys are
+// among keys. t is not modified.
+func (t *Tag) Only(keys ...string) Tag {
+	out := Tag{Name: t.Name, Options: make(map[string]string, len(keys)), listSep: t.listSep, groups: t.groups, bools: t.bools}
+	for _, key := range keys {
+		if _, ok := t.Options[key]; ok {
+			out.copyOption(t, key)
+		}
+	}
+
+	return out
+}
+
+// Without returns a copy of t without the options whose keys are present in
+// other, whatever their values, such as the options of a field that a
+// policy does not allow:
+//
+//	denied := field.Without(allowed)
+//
+// t is not modified.
+func (t *Tag) Without(other Tag) Tag {
+	return t.Filter(func(key, _ string) bool {
+		_, ok := other.Options[key]
+
+		return !ok
+	})
+}
+
+// Intersect returns a copy of t holding only the options whose keys are
+// also present in other, with their values in t. t is not modified.
+func (t *Tag) Intersect(other Tag) Tag {
+	return t.Filter(func(key, _ string) bool {
+		_, ok := other.Options[key]
+
+		return ok
+	})
+}
+
+// Nested returns the options of t as a tree, splitting keys on dots, so
+// that hierarchical configuration like `db.host=localhost,db.port=5432`
+// yields
+//
+//	map[string]any{"db": map[string]any{"host": "localhost", "port": "5432"}}
+//
+// Leaves are strings and inner nodes are map[string]any. When a key is both
+// set and used as a prefix, as in `db=x,db.port=1`, its own value is kept
+// under the empty key of its node. The returned tree does not share maps
+// with t.
+func (t *Tag) Nested() map[string]any {
+	root := make(map[string]any)
+	for key, value := range t.Options {
+		node := root
+		path := strings.Split(key, ".")
+		for _, seg := range path[:len(path)-1] {
+			node = childNode(node, seg)
+		}
+
+		last := path[len(path)-1]
+		if child, ok := node[last].(map[string]any); ok {
+			child[""] = value
+		} else {
+			node[last] = value
+		}
+	}
+
+	return root
+}
+
+// childNode returns the inner node under key, creating it or turning a leaf
+// into a node holding the leaf under the empty key.
+func childNode(node map[string]any, key string) map[string]any {
+	switch v := node[key].(type) {
+	case map[string]any:
+		return v
+	case string:
+		child := map[string]any{"": v}
+		node[key] = child
+
+		return child
+	default:
+		child := make(map[string]any)
+		node[key] = child
+
+		return child
+	}
+}
@@ -0,0 +1,228 @@
+package tagparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTag_Nested(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want map[string]any
+	}{
+		{``, map[string]any{}},
+		{`a=1,b`, map[string]any{"a": "1", "b": ""}},
+		{`a.b=1,a.c=2`, map[string]any{"a": map[string]any{"b": "1", "c": "2"}}},
+		{`db.host=h,db.pool.max=10,db.pool.min=1,name=x`, map[string]any{
+			"db":   map[string]any{"host": "h", "pool": map[string]any{"max": "10", "min": "1"}},
+			"name": "x",
+		}},
+		{`a=1,a.b=2`, map[string]any{"a": map[string]any{"": "1", "b": "2"}}},
+		{`a.b.c=3,a=1,a.b=2`, map[string]any{"a": map[string]any{"": "1", "b": map[string]any{"": "2", "c": "3"}}}},
+		{`a\.b=1`, map[string]any{"a": map[string]any{"b": "1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			assert.Equal(t, tt.want, MustParse(tt.tag).Nested())
+		})
+	}
+}
+
+func TestTag_GetBoolFlag(t *testing.T) {
+	tag, err := New(WithNegation()).Parse(`a,!b,c=true,d=0,e=maybe`)
+	require.NoError(t, err)
+
+	type result struct{ val, present bool }
+	check := func(key string) result {
+		val, present := tag.GetBoolFlag(key)
+
+		return result{val, present}
+	}
+	assert.Equal(t, result{true, true}, check("a"))
+	assert.Equal(t, result{false, true}, check("b"))
+	assert.Equal(t, result{true, true}, check("c"))
+	assert.Equal(t, result{false, true}, check("d"))
+	assert.Equal(t, result{false, true}, check("e"))
+	assert.Equal(t, result{false, false}, check("missing"))
+}
+
+func TestTag_GetBool(t *testing.T) {
+	const tag = `a,b=true,c=0,d=yes,e=Off,f=maybe`
+	strict := MustParse(tag)
+	truthy, err := New(WithTruthyBools()).Parse(tag)
+	require.NoError(t, err)
+	custom, err := New(WithBoolValues([]string{"ja"}, nil), WithBoolValues(nil, []string{"nein"})).Parse(`a=JA,b=nein,c=yes`)
+	require.NoError(t, err)
+
+	type result struct {
+		val bool
+		err string
+	}
+	check := func(tag *Tag, key string) result {
+		val, err := tag.GetBool(key)
+		if err != nil {
+			return result{val, err.Error()}
+		}
+
+		return result{val, ""}
+	}
+	assert.Equal(t, result{true, ""}, check(strict, "a"))
+	assert.Equal(t, result{true, ""}, check(strict, "b"))
+	assert.Equal(t, result{false, ""}, check(strict, "c"))
+	assert.Equal(t, result{false, `invalid value "yes" for "d", expected bool`}, check(strict, "d"))
+	assert.Equal(t, result{false, ""}, check(strict, "missing"))
+
+	assert.Equal(t, result{true, ""}, check(truthy, "d"))
+	assert.Equal(t, result{false, ""}, check(truthy, "e"))
+	assert.Equal(t, result{false, `invalid value "maybe" for "f", expected bool`}, check(truthy, "f"))
+	val, present := truthy.GetBoolFlag("d")
+	assert.True(t, val)
+	assert.True(t, present)
+	only := truthy.Only("d")
+	val, _ = only.GetBoolFlag("d")
+	assert.True(t, val)
+
+	assert.Equal(t, result{true, ""}, check(custom, "a"))
+	assert.Equal(t, result{false, ""}, check(custom, "b"))
+	assert.Equal(t, result{false, `invalid value "yes" for "c", expected bool`}, check(custom, "c"))
+}
+
+func TestTag_Lookup(t *testing.T) {
+	tag := MustParseWithName(`name,default,empty=,min=1,dup=,dup`)
+
+	type result struct {
+		value             string
+		hasValue, present bool
+	}
+	check := func(key string) result {
+		value, hasValue, present := tag.Lookup(key)
+
+		return result{value, hasValue, present}
+	}
+	assert.Equal(t, result{"", false, true}, check("default"))
+	assert.Equal(t, result{"", true, true}, check("empty"))
+	assert.Equal(t, result{"1", true, true}, check("min"))
+	assert.Equal(t, result{"", false, true}, check("dup"))
+	assert.Equal(t, result{"", false, false}, check("missing"))
+
+	// Copies and renames keep the distinction, Set writes a flag
+	only := tag.Only("empty")
+	_, hasValue, _ := only.Lookup("empty")
+	assert.True(t, hasValue)
+	tag.Rename("empty", "blank")
+	assert.Equal(t, result{"", true, true}, check("blank"))
+	tag.Set("blank", "")
+	assert.Equal(t, result{"", false, true}, check("blank"))
+}
+
+func TestTag_Mutations(t *testing.T) {
+	tag := MustParseWithName(`name,omitempty,min=1`)
+
+	tag.Set("max", "10")
+	tag.Set("min", "2")
+	tag.SetFlag("required")
+	tag.Delete("omitempty")
+	tag.Delete("missing")
+	assert.True(t, tag.Rename("max", "lte"))
+	assert.False(t, tag.Rename("missing", "other"))
+
+	assert.Equal(t, "name", tag.Name)
+	assert.Equal(t, M{"min": "2", "lte": "10", "required": ""}, tag.Options)
+
+	// Renaming onto an existing key replaces its value
+	assert.True(t, tag.Rename("min", "lte"))
+	assert.Equal(t, M{"lte": "2", "required": ""}, tag.Options)
+
+	// The zero Tag is usable
+	var zero Tag
+	zero.Delete("a")
+	zero.SetFlag("a")
+	assert.Equal(t, M{"a": ""}, zero.Options)
+}
+
+func TestTag_Filter(t *testing.T) {
+	tag := MustParseWithName(`name,omitempty,x-internal=1,x-debug,min=5`)
+
+	public := tag.Filter(func(key, _ string) bool {
+		return !strings.HasPrefix(key, "x-")
+	})
+	assert.Equal(t, Tag{Name: "name", Options: M{"omitempty": "", "min": "5"}}, public)
+
+	withValue := tag.Filter(func(_, value string) bool { return value != "" })
+	assert.Equal(t, M{"x-internal": "1", "min": "5"}, withValue.Options)
+
+	// The original is untouched
+	assert.Len(t, tag.Options, 4)
+}
+
+func TestTag_Only(t *testing.T) {
+	tag := MustParseWithName(`name,omitempty,min=5,max=10`)
+
+	assert.Equal(t, Tag{Name: "name", Options: M{"min": "5", "omitempty": ""}}, tag.Only("min", "omitempty", "missing"))
+	assert.Equal(t, Tag{Name: "name", Options: M{}}, tag.Only())
+	assert.Len(t, tag.Options, 3)
+
+	// List separators carry over
+	list, err := New(WithListSeparator(';')).Parse(`oneof=a\;b;c,x=1`)
+	require.NoError(t, err)
+	only := list.Only("oneof")
+	assert.Equal(t, []string{"a;b", "c"}, only.GetSlice("oneof"))
+}
+
+func TestTag_Keys(t *testing.T) {
+	tag := MustParseWithName(`name,omitempty,min=5,b,a=1`)
+	assert.Equal(t, []string{"a", "b", "min", "omitempty"}, tag.Keys())
+	assert.Equal(t, 4, tag.Len())
+	assert.False(t, tag.IsEmpty())
+
+	var pairs []string
+	for key, value := range tag.All() {
+		pairs = append(pairs, key+"="+value)
+		if key == "min" {
+			break
+		}
+	}
+	assert.Equal(t, []string{"a=1", "b=", "min=5"}, pairs)
+
+	var zero Tag
+	assert.Empty(t, zero.Keys())
+	assert.Equal(t, 0, zero.Len())
+	assert.True(t, zero.IsEmpty())
+	assert.True(t, MustParse(``).IsEmpty())
+	assert.False(t, MustParseWithName(`name`).IsEmpty())
+}
+
+func TestTag_Equal(t *testing.T) {
+	base := MustParseWithName(`name,a=1,b,c=`)
+	assert.True(t, base.Equal(MustParseWithName(`name,c=,b,a='1'`)))
+	assert.True(t, base.Equal(base))
+	assert.False(t, base.Equal(MustParseWithName(`other,a=1,b,c=`)))
+	assert.False(t, base.Equal(MustParseWithName(`name,a=1,b,c`)), "flag and empty value differ")
+	assert.False(t, base.Equal(MustParseWithName(`name,a=1,b=,c=`)), "flag and empty value differ")
+	assert.False(t, base.Equal(MustParseWithName(`name,a=2,b,c=`)))
+	assert.False(t, base.Equal(MustParseWithName(`name,a=1,b`)))
+	assert.False(t, base.Equal(MustParseWithName(`name,a=1,b,d=`)))
+	assert.True(t, (&Tag{}).Equal(&Tag{Options: M{}}))
+}
+
+func TestTag_WithoutIntersect(t *testing.T) {
+	tag := MustParseWithName(`name,a=1,b,c=`)
+	other := MustParseWithName(`other,b=2,c,d`)
+
+	without := tag.Without(*other)
+	assert.Equal(t, "name", without.Name)
+	assert.Equal(t, M{"a": "1"}, without.Options)
+
+	both := tag.Intersect(*other)
+	assert.Equal(t, "name", both.Name)
+	assert.Equal(t, M{"b": "", "c": ""}, both.Options)
+	_, hasValue, _ := both.Lookup("c")
+	assert.True(t, hasValue, "values come from t")
+
+	assert.Equal(t, M{"a": "1", "b": "", "c": ""}, tag.Without(Tag{}).Options)
+	assert.Empty(t, tag.Intersect(Tag{}).Options)
+	assert.Equal(t, M{"a": "1", "b": "", "c": ""}, tag.Options, "t is not modified")
+}
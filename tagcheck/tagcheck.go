@@ -0,0 +1,156 @@
+// Package tagcheck defines an Analyzer that reports malformed struct tags.
+//
+// Every struct tag in the analyzed package is split into its key:"value"
+// pairs and each value is parsed with tagparser: with its Schema if one is
+// given, or else with the tagparser dialect registered under the namespace,
+// such as gorm, or validator for validate. Other namespaces are only checked
+// when named with -keys, with the default grammar. Syntax errors and schema
+// violations are reported at the exact position of the problem in the
+// source.
+//
+// The analyzer can be run standalone through singlechecker or multichecker,
+// with go vet -vettool, or from golangci-lint as a plugin.
+package tagcheck
+
+import (
+	"cmp"
+	"errors"
+	"go/ast"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+
+	"github.com/talav/tagparser"
+	"github.com/talav/tagparser/source"
+)
+
+const doc = `check struct tags with tagparser
+
+The tagcheck analyzer parses the value of every struct tag namespace with a
+tagparser dialect or schema, such as gorm or validate, and reports
+malformed values. Use -keys to restrict the check to a comma-separated list
+of namespaces; namespaces named there without a dialect are parsed with the
+default grammar.`
+
+// Analyzer checks the syntax of struct tags, without schemas.
+var Analyzer = NewAnalyzer(nil)
+
+// NewAnalyzer returns an Analyzer that additionally validates the namespaces
+// present in schemas, such as "validate", against their Schema. Schemas are
+// typically declared with WithName set, since struct tag values usually start
+// with a name.
+func NewAnalyzer(schemas map[string]*tagparser.Schema) *analysis.Analyzer {
+	c := &checker{schemas: schemas}
+	a := &analysis.Analyzer{
+		Name:     "tagcheck",
+		Doc:      doc,
+		URL:      "https://pkg.go.dev/github.com/talav/tagparser/tagcheck",
+		Requires: []*analysis.Analyzer{inspect.Analyzer},
+		Run:      c.run,
+	}
+	a.Flags.StringVar(&c.keys, "keys", "", "comma-separated struct tag keys to check (default all)")
+
+	return a
+}
+
+type checker struct {
+	schemas map[string]*tagparser.Schema
+	keys    string // -keys flag
+}
+
+func (c *checker) run(pass *analysis.Pass) (any, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector) //nolint:forcetypeassert // guaranteed by Requires
+
+	var namespaces []string
+	if c.keys != "" {
+		namespaces = strings.Split(c.keys, ",")
+	}
+
+	insp.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		for _, field := range n.(*ast.StructType).Fields.List { //nolint:forcetypeassert // filtered by Preorder
+			if field.Tag != nil {
+				c.checkTag(pass, field.Tag, namespaces)
+			}
+		}
+	})
+
+	return nil, nil //nolint:nilnil // the analyzer has no result
+}
+
+// checkTag reports the problems in the struct tag literal lit.
+func (c *checker) checkTag(pass *analysis.Pass, lit *ast.BasicLit, namespaces []string) {
+	tag, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return // not valid Go, reported by the compiler
+	}
+
+	err = tagparser.SplitStructTag(tag, func(key, value string, valuePos int) error {
+		if namespaces != nil && !slices.Contains(namespaces, key) {
+			return nil
+		}
+		for _, e := range c.check(key, value, namespaces != nil) {
+			pass.Reportf(source.ValuePos(lit, valuePos, e.Pos), "%s tag: %s", key, message(e))
+		}
+
+		return nil
+	})
+
+	var parseErr *tagparser.Error
+	if errors.As(err, &parseErr) {
+		pass.Reportf(source.Pos(lit, parseErr.Pos), "%s", message(parseErr))
+	}
+}
+
+// dialectNames are the dialects of the namespaces whose name differs from
+// the name of their dialect.
+var dialectNames = map[string]string{"validate": "validator"}
+
+// check parses a namespace value and returns the problems found in it.
+// Namespaces without a schema or a dialect are parsed with the default
+// grammar if named, and skipped otherwise.
+func (c *checker) check(key, value string, named bool) []*tagparser.Error {
+	dialect := cmp.Or(dialectNames[key], key)
+	_, hasDialect := tagparser.LookupDialect(dialect)
+	schema, hasSchema := c.schemas[key]
+
+	var err error
+	switch {
+	case hasSchema:
+		err = schema.Validate(value)
+	case hasDialect:
+		_, err = tagparser.ParseDialect(dialect, value)
+	case !named:
+		return nil
+	default:
+		if _, errs := tagparser.ParseAllWithName(value); errs != nil {
+			err = errs
+		}
+	}
+
+	var errs *tagparser.Errors
+	if errors.As(err, &errs) {
+		return errs.List
+	}
+	var parseErr *tagparser.Error
+	if errors.As(err, &parseErr) {
+		return []*tagparser.Error{parseErr}
+	}
+
+	return nil
+}
+
+// message returns the text of e without its position.
+func message(e *tagparser.Error) string {
+	switch {
+	case e.Cause != nil && e.Msg != "":
+		return e.Msg + ": " + e.Cause.Error()
+	case e.Cause != nil:
+		return e.Cause.Error()
+	default:
+		return e.Msg
+	}
+}
@@ -0,0 +1,59 @@
+package tagcheck_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/talav/tagparser"
+	"github.com/talav/tagparser/tagcheck"
+)
+
+func TestAnalyzer(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), tagcheck.Analyzer, "a")
+	assert.Equal(t, []string{"9:35", "10:36", "11:30", "12:22", "18:31"}, positions(results))
+}
+
+func TestAnalyzer_Keys(t *testing.T) {
+	a := tagcheck.NewAnalyzer(nil)
+	if err := a.Flags.Set("keys", "db"); err != nil {
+		t.Fatal(err)
+	}
+	results := analysistest.Run(t, analysistest.TestData(), a, "c")
+
+	// Diagnostics point at the offending byte, through Go escapes
+	assert.Equal(t, []string{"5:26", "6:35", "7:30", "8:23"}, positions(results))
+}
+
+// positions returns the line:column positions of the diagnostics.
+func positions(results []*analysistest.Result) []string {
+	var got []string
+	for _, r := range results {
+		for _, d := range r.Diagnostics {
+			p := r.Pass.Fset.Position(d.Pos)
+			got = append(got, fmt.Sprintf("%d:%d", p.Line, p.Column))
+		}
+	}
+
+	return got
+}
+
+func TestNewAnalyzer_Schemas(t *testing.T) {
+	a := tagcheck.NewAnalyzer(map[string]*tagparser.Schema{
+		"validate": {
+			Keys: map[string]tagparser.KeySpec{
+				"required": {Flag: true},
+				"email":    {Flag: true},
+				"min":      {Type: tagparser.TypeInt},
+			},
+		},
+	})
+	if err := a.Flags.Set("keys", "validate"); err != nil {
+		t.Fatal(err)
+	}
+
+	analysistest.Run(t, analysistest.TestData(), a, "b")
+}
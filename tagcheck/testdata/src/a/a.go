@@ -0,0 +1,20 @@
+package a
+
+type User struct {
+	ID     int    `json:"id" db:"user_id,pk"`
+	Email  string `json:"email,omitempty" validate:"required,email"`
+	Name   string `json:"name,=x" db:"c='d"`
+	Column string `gorm:"type:varchar(100);default:'abc'"`
+	Code   string `validate:"regexp=^\\d+$,oneof='a b' 'c'"`
+	Size   int    `gorm:"column:size;:x"`      // want `gorm tag: empty key`
+	Rules  string `validate:"required,,min=1"` // want `validate tag: empty key`
+	Escape string "gorm:\"size:1\\\\\""        // want `gorm tag: unterminated escape sequence`
+	Broken string `json:"broken`               // want `bad syntax for struct tag value`
+	Plain  string
+}
+
+type Nested struct {
+	Inner struct {
+		Value []int `validate:"dive,keys,min=1"` // want `validate tag: 'keys' without 'endkeys'`
+	}
+}
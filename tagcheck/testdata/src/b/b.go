@@ -0,0 +1,7 @@
+package b
+
+type Form struct {
+	Email string `json:"email,=x" validate:"required,email"`
+	Age   int    `validate:"min=abc,omitempy"` // want `validate tag: invalid value "abc" for "min", expected int` `validate tag: unknown key "omitempy"`
+	Name  string `validate:"required=yes"`     // want `validate tag: unexpected value for "required"`
+}
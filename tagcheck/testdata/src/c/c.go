@@ -0,0 +1,10 @@
+package c
+
+type User struct {
+	ID     int    `db:"user_id,pk" json:"id,=x"`
+	Name   string `db:"name,=x"`            // want `db tag: empty key`
+	Note   string `db:"note,default=x'b'"`  // want `db tag: quotes must enclose the entire value`
+	Escape string "db:\"esc,\\\\q\""        // want `db tag: invalid escape character`
+	Both   string `db:"a,=1" opts:"b,c='d"` // want `db tag: empty key`
+	Plain  string
+}
@@ -40,86 +40,49 @@
 package tagparser
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
-	"strconv"
+	"slices"
 	"strings"
+	"unicode/utf8"
 )
 
-// MaxTagLength is the maximum allowed tag length to prevent DoS attacks.
-// This limit of 64KB is far larger than any realistic struct tag.
+// MaxTagLength is the default maximum tag length, preventing DoS attacks.
+// This limit of 64KB is far larger than any realistic struct tag; use
+// WithMaxTagLength to change it for a Parser.
 const MaxTagLength = 1 << 16 // 64KB
 
-// ErrTagTooLarge is returned when a tag exceeds MaxTagLength.
+// ErrTagTooLarge is returned when a tag exceeds MaxTagLength or the limit
+// set with WithMaxTagLength.
 var ErrTagTooLarge = errors.New("tag exceeds maximum length")
 
-const (
-	errQuotesMustEnclose  = "quotes must enclose the entire value"
-	errUnterminatedQuote  = "unterminated quote"
-	errEmptyKey           = "empty key"
-	errUnterminatedEscape = "unterminated escape sequence"
-	errInvalidEscape      = "invalid escape character"
-	errInvalidQuote       = "invalid quote"
-)
-
-// Error is the type of error returned by parse funcs in this package.
-type Error struct {
-	Tag   string // Original tag string
-	Pos   int    // 0-based position of error
-	Msg   string // Error message
-	Cause error  // Optional underlying error
-}
-
-func (e *Error) Error() string {
-	if e.Cause != nil {
-		if e.Msg != "" {
-			return fmt.Sprintf("%s: %v (at %d)", e.Msg, e.Cause, e.Pos+1)
-		}
-
-		return fmt.Sprintf("%v (at %d)", e.Cause, e.Pos+1)
-	}
-
-	return fmt.Sprintf("%s (at %d)", e.Msg, e.Pos+1)
-}
-
-func (e *Error) Unwrap() error { return e.Cause }
-
 // Tag represents a parsed struct tag.
 type Tag struct {
 	Name    string
 	Options map[string]string
+
+	listSep byte                 // see WithListSeparator
+	groups  *Parser              // Parser with WithGroups that produced the tag, see Group
+	empty   map[string]bool      // options written with an empty value, see Lookup
+	lists   map[string]listValue // values with escaped list separators, see GetSlice
+	bools   *boolValues          // see WithBoolValues
 }
 
 // unquoteError represents an error during unquoting.
 type unquoteError struct {
-	msg string
-	pos int
+	code ErrorCode
+	pos  int
 }
 
-func (e *unquoteError) Error() string { return e.msg }
+func (e *unquoteError) Error() string { return e.code.message() }
 
 // Parse parses a tag treating all items as options (default behavior).
 // Example: "foo,bar=baz" → Name="", Options={"foo": "", "bar": "baz"}.
 //
 // Returns ErrTagTooLarge if tag length exceeds MaxTagLength.
 func Parse(tag string) (*Tag, error) {
-	// Handle Go struct tag quoting convention - try to unquote if it looks quoted
-	if unquoted, err := strconv.Unquote(tag); err == nil {
-		tag = unquoted
-	}
-
-	result := &Tag{Options: make(map[string]string)}
-	err := ParseFunc(tag, func(key, value string) error {
-		// In options mode, key is never empty
-		result.Options[key] = value
-
-		return nil
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
+	return defaultParser.Parse(tag)
 }
 
 // ParseWithName parses a tag treating the first item without equals as a name.
@@ -128,27 +91,61 @@ func Parse(tag string) (*Tag, error) {
 //
 // Returns ErrTagTooLarge if tag length exceeds MaxTagLength.
 func ParseWithName(tag string) (*Tag, error) {
-	// Handle Go struct tag quoting convention - try to unquote if it looks quoted
-	if unquoted, err := strconv.Unquote(tag); err == nil {
-		tag = unquoted
-	}
+	return defaultParser.ParseWithName(tag)
+}
 
-	result := &Tag{Options: make(map[string]string)}
-	err := ParseFuncWithName(tag, func(key, value string) error {
-		if key == "" {
-			result.Name = value
-		} else {
-			// Allow duplicates, last value wins
-			result.Options[key] = value
-		}
+// ParseInto is like Parse but parses into dst, clearing and reusing its
+// Options map instead of allocating a new one, for loops parsing many tags
+// one after the other:
+//
+//	var tag tagparser.Tag
+//	for _, s := range tags {
+//	    if err := tagparser.ParseInto(&tag, s); err != nil {
+//	        return err
+//	    }
+//	    use(&tag)
+//	}
+//
+// On error dst holds the options parsed before the malformed item, or every
+// well-formed option with WithLenient.
+func ParseInto(dst *Tag, tag string) error {
+	return defaultParser.ParseInto(dst, tag)
+}
 
-		return nil
-	})
-	if err != nil {
-		return nil, err
-	}
+// ParseIntoWithName is like ParseInto but treats the first item without
+// equals as a name, like ParseWithName.
+func ParseIntoWithName(dst *Tag, tag string) error {
+	return defaultParser.ParseIntoWithName(dst, tag)
+}
+
+// Validate returns the error Parse would return for tag, or nil if it is
+// well-formed, without building a Tag. It is meant for linters and
+// admission checks, and does not allocate for well-formed tags without
+// escapes.
+func Validate(tag string) error {
+	return defaultParser.Validate(tag)
+}
+
+// ValidateWithName is like Validate but treats the first item without
+// equals as a name, like ParseWithName.
+func ValidateWithName(tag string) error {
+	return defaultParser.ValidateWithName(tag)
+}
+
+// ParseAll parses a tag like Parse but does not stop at the first malformed
+// item. It returns the options that parsed successfully together with an
+// *Errors listing every problem, or a nil *Errors if the tag is well-formed.
+//
+// Each reported *Error carries its position and the raw Segment of the
+// offending item, so tooling can show all diagnostics for a tag at once.
+func ParseAll(tag string) (*Tag, *Errors) {
+	return defaultParser.ParseAll(tag)
+}
 
-	return result, nil
+// ParseAllWithName is like ParseAll but treats the first item without equals
+// as a name, like ParseWithName.
+func ParseAllWithName(tag string) (*Tag, *Errors) {
+	return defaultParser.ParseAllWithName(tag)
 }
 
 // ParseFunc enumerates fields of a tag treating all items as options.
@@ -165,9 +162,22 @@ func ParseWithName(tag string) (*Tag, error) {
 //
 // Returns ErrTagTooLarge if tag length exceeds MaxTagLength.
 func ParseFunc(tag string, callback func(key, value string) error) error {
-	p := parser{tag: tag, callback: callback, treatFirstAsName: false}
+	return defaultParser.ParseFunc(tag, callback)
+}
+
+// ParseFuncPos is like ParseFunc but also passes the 0-based byte offsets of
+// the key and value within the tag, for precise diagnostics. Offsets point at
+// the text as written, after leading whitespace and including any opening
+// quote. valPos is -1 for items without a value.
+func ParseFuncPos(tag string, callback func(key, value string, keyPos, valPos int) error) error {
+	return defaultParser.ParseFuncPos(tag, callback)
+}
 
-	return p.parse()
+// ParseFuncWithNamePos is like ParseFuncWithName but also passes key and
+// value offsets like ParseFuncPos. The name is reported with an empty key,
+// keyPos -1 and its offset in valPos.
+func ParseFuncWithNamePos(tag string, callback func(key, value string, keyPos, valPos int) error) error {
+	return defaultParser.ParseFuncWithNamePos(tag, callback)
 }
 
 // ParseFuncWithName enumerates fields of a tag treating the first item as a name.
@@ -185,61 +195,355 @@ func ParseFunc(tag string, callback func(key, value string) error) error {
 //
 // Returns ErrTagTooLarge if tag length exceeds MaxTagLength.
 func ParseFuncWithName(tag string, callback func(key, value string) error) error {
-	p := parser{tag: tag, callback: callback, treatFirstAsName: true}
-
-	return p.parse()
+	return defaultParser.ParseFuncWithName(tag, callback)
 }
 
+// parser is the scanner behind all parse functions. It is pull-based: each
+// call to next scans up to the end of the next item, so callers decide what
+// to do with items without the parser holding on to a callback.
 type parser struct {
+	cfg              *Parser
 	tag              string
-	callback         func(key, value string) error
 	treatFirstAsName bool
 	pos              int
 	start            int
+	itemStart        int
 	keyStart         int
-	key              string
+	key              string // raw key of the current item
+	unquotedKey      string // key after trimming and unescaping
 	inValue          bool
 	inQuote          bool
+	alternative      bool // the current item follows '|', see WithAlternatives
+	group            bool // the current item is a group, see WithGroups
+	groupDepth       int  // parentheses open in the current group
+	groupStart       int  // position of the opening parenthesis
+	groupEnd         int  // position of the closing parenthesis
 	count            int
+	options          int      // options returned so far, for the option limit
+	deprecatedKey    string   // key of the current option as written, if deprecated
+	atSeparator      bool     // the last item ended at the separator at pos
+	done             bool     // the last item has been returned
+	partial          bool     // more input may follow the tag, see Scanner
+	split            bool     // items are found by nextSimple, see begin
+	skip             bool     // lenient mode: drop the current item
+	errs             []*Error // lenient mode: collected errors
+	buf              []byte   // unescaped values, see scratch
+	filter           bool     // only the value of option want is unescaped, see Parser.find
+	want             string   // with filter, the option whose value is returned
+	escapedSeps      []int    // offsets of escaped list separators in the value
 }
 
-func (p *parser) parse() error {
-	// Validate tag length at single entry point
-	if len(p.tag) > MaxTagLength {
-		return &Error{
-			Tag:   truncateForError(p.tag),
-			Pos:   0,
-			Msg:   "tag too large",
-			Cause: ErrTagTooLarge,
+// parse reports every item of the tag to callback.
+func (p *parser) parse(callback func(key, value string) error) error {
+	if err := p.begin(); err != nil {
+		return err
+	}
+
+	for {
+		key, value, ok, err := p.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if err := callback(key, value); err != nil {
+			if err := p.collect(p.callbackError(err)); err != nil {
+				return err
+			}
 		}
 	}
 
-	for p.pos < len(p.tag) {
-		c := p.tag[p.pos]
-		if p.inQuote {
-			if err := p.handleQuoted(c); err != nil {
+	return p.err()
+}
+
+// parsePos reports every item of the tag to callback along with the
+// positions of its key and value.
+func (p *parser) parsePos(callback func(key, value string, keyPos, valPos int) error) error {
+	if err := p.begin(); err != nil {
+		return err
+	}
+
+	for {
+		key, value, ok, err := p.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		keyPos, valPos := p.positions(key)
+		if err := callback(key, value, keyPos, valPos); err != nil {
+			if err := p.collect(p.callbackError(err)); err != nil {
 				return err
 			}
-		} else {
-			if err := p.handleUnquoted(c); err != nil {
+		}
+	}
+
+	return p.err()
+}
+
+// check reports every item of the tag to fn and collects the error fn
+// returns for it, as for a callback error. Problems found in lenient mode
+// are left for err.
+func (p *parser) check(fn func(key, value string) *Error) error {
+	if err := p.begin(); err != nil {
+		return err
+	}
+
+	for {
+		key, value, ok, err := p.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := fn(key, value); err != nil {
+			if err := p.collect(err); err != nil {
 				return err
 			}
 		}
-		p.pos++
+	}
+}
+
+// begin checks the length of a whole tag and picks how its items are found:
+// a tag fillSimple could split is split on its separators by nextSimple
+// rather than scanned byte by byte.
+func (p *parser) begin() error {
+	if err := p.checkLength(); err != nil {
+		return err
+	}
+	p.split = !p.partial && p.cfg.isSimple(p.tag)
+
+	return nil
+}
+
+// checkLength validates the tag length at the single entry point of every
+// parse.
+func (p *parser) checkLength() error {
+	if limit := p.cfg.maxTagLength(); limit >= 0 && len(p.tag) > limit {
+		return &Error{
+			Tag:    truncateForError(p.tag),
+			Pos:    0,
+			Msg:    errTagTooLarge,
+			Cause:  ErrTagTooLarge,
+			Code:   CodeTagTooLarge,
+			format: p.cfg.errorFormat,
+		}
+	}
+
+	return nil
+}
+
+// next scans the next item and returns its key and value. It returns
+// ok == false once the tag is exhausted. The parser state keeps describing
+// the returned item until next is called again.
+func (p *parser) next() (key, value string, ok bool, err error) {
+	if p.split {
+		return p.nextSimple()
 	}
 
+	for !p.done {
+		if p.atSeparator {
+			p.nextItem()
+		}
+
+		if p.pos >= len(p.tag) {
+			if p.partial {
+				return "", "", false, nil
+			}
+			p.done = true
+			if p.inQuote {
+				if err := p.fail(p.errorAt(p.start, CodeUnterminatedQuote)); err != nil {
+					return "", "", false, err
+				}
+			} else if p.groupDepth > 0 && !p.skip {
+				if err := p.fail(p.errorAt(p.groupStart, CodeUnterminatedGroup)); err != nil {
+					return "", "", false, err
+				}
+			}
+		} else if c := p.tag[p.pos]; !p.inQuote && p.groupDepth == 0 && p.cfg.isSep(c) {
+			p.atSeparator = true
+		} else {
+			if p.partial && c == p.cfg.escape && !p.cfg.noEscapes && p.pos+1 == len(p.tag) {
+				// The escaped byte is still to come
+				return "", "", false, nil
+			}
+			if err := p.scan(c); err != nil {
+				return "", "", false, err
+			}
+			p.pos++
+
+			continue
+		}
+
+		if key, value, ok, err = p.endItem(); ok || err != nil {
+			return key, value, ok, err
+		}
+	}
+
+	return "", "", false, nil
+}
+
+// nextItem moves past the separator ending the last item to start the
+// next one.
+func (p *parser) nextItem() {
+	p.atSeparator = false
+	p.alternative = p.tag[p.pos] == altSep && p.cfg.alternatives
+	p.pos++
+	p.start = p.pos
+	p.itemStart = p.pos
+	p.keyStart = p.pos
+	p.inValue = false
+	p.key = ""
+	p.group, p.groupDepth = false, 0
+	p.escapedSeps = nil
+}
+
+// positions returns the offsets of the key and value of the item last
+// returned by next, skipping leading whitespace unless it is preserved.
+// A missing key (the name) or value (a flag) is reported as -1.
+func (p *parser) positions(key string) (keyPos, valPos int) {
+	switch {
+	case key == "":
+		return -1, p.skipSpace(p.start)
+	case p.inValue:
+		return p.skipSpace(p.keyStart), p.skipSpace(p.start)
+	default:
+		return p.skipSpace(p.start), -1
+	}
+}
+
+// skipSpace returns the position of the first non-whitespace byte at or after
+// pos in the current item.
+func (p *parser) skipSpace(pos int) int {
+	if p.cfg.preserveWhitespace {
+		return pos
+	}
+	for pos < p.pos && asciiSpace[p.tag[pos]] != 0 {
+		pos++
+	}
+
+	return pos
+}
+
+// err returns the errors collected in lenient mode, if any.
+func (p *parser) err() error {
+	if len(p.errs) > 0 {
+		return &Errors{Tag: p.tag, List: p.sortedErrs()}
+	}
+
+	return nil
+}
+
+// sortedErrs returns the collected errors ordered by position. They are
+// collected in scan order, which differs when a quote left open is only
+// reported once the rest of the tag has been scanned.
+func (p *parser) sortedErrs() []*Error {
+	slices.SortStableFunc(p.errs, func(a, b *Error) int {
+		return cmp.Compare(a.Pos, b.Pos)
+	})
+
+	return p.errs
+}
+
+// fail reports an error in the current item. In lenient mode the error is
+// collected and the item is skipped up to the next separator; otherwise the
+// error is returned to stop parsing.
+func (p *parser) fail(err *Error) error {
+	if !p.cfg.lenient {
+		return err
+	}
+	p.skip = true
+
+	return p.record(err)
+}
+
+// collect records err in lenient mode and returns it unchanged otherwise.
+func (p *parser) collect(err *Error) error {
+	if !p.cfg.lenient {
+		return err
+	}
+
+	return p.record(err)
+}
+
+// record adds err to the errors of a lenient parse. Once there are as many
+// as WithMaxErrors allows, it stops the parse with all of them.
+func (p *parser) record(err *Error) error {
+	p.errs = append(p.errs, err)
+	limit := p.cfg.limits.maxErrors
+	if limit <= 0 || len(p.errs) < limit {
+		return nil
+	}
+
+	tooMany := p.errorAt(err.Pos, CodeTooManyErrors)
+	tooMany.Msg = fmt.Sprintf("%s, stopped after %d", errTooManyErrors, limit)
+
+	return &Errors{Tag: p.tag, List: append(p.sortedErrs(), tooMany)}
+}
+
+// errorAt creates an Error with the given code at pos within the current item.
+func (p *parser) errorAt(pos int, code ErrorCode) *Error {
+	end := p.itemEnd()
+
+	e := &Error{
+		Tag:     p.tag,
+		Pos:     pos,
+		Msg:     code.message(),
+		Segment: p.tag[p.itemStart:end],
+		Offset:  p.itemStart,
+		Len:     end - p.itemStart,
+		Code:    code,
+		format:  p.cfg.errorFormat,
+		lines:   p.cfg.continuation,
+	}
+
+	return e.locate()
+}
+
+// itemEnd returns the end of the current item: the first separator after
+// itemStart that is neither quoted nor escaped, or the end of the tag.
+func (p *parser) itemEnd() int {
+	inQuote, inValue, depth := false, false, 0
+	for i := p.itemStart; i < len(p.tag); i++ {
+		switch c := p.tag[i]; {
+		case c == p.cfg.escape && !p.cfg.noEscapes:
+			i++
+		case c == p.cfg.quote && !p.cfg.literalQuotes:
+			inQuote = !inQuote
+		case inQuote:
+		case depth > 0 && c == '(':
+			depth++
+		case depth > 0 && c == ')':
+			depth--
+		case c == '(' && p.cfg.groups && !inValue:
+			depth, inValue = 1, true
+		case c == p.cfg.kvSep && p.cfg.kvSep != 0:
+			inValue = true
+		case p.cfg.isSep(c) && depth == 0:
+			return i
+		}
+	}
+
+	return len(p.tag)
+}
+
+// scan processes a byte inside an item.
+func (p *parser) scan(c byte) error {
 	if p.inQuote {
-		return &Error{p.tag, p.start, errUnterminatedQuote, nil}
+		return p.handleQuoted(c)
 	}
 
-	return p.emitItem()
+	return p.handleUnquoted(c)
 }
 
 func (p *parser) handleQuoted(c byte) error {
-	switch c {
-	case '\'':
+	switch {
+	case c == p.cfg.quote:
 		p.inQuote = false
-	case '\\':
+	case c == p.cfg.escape && !p.cfg.noEscapes:
 		if err := p.consumeEscape(); err != nil {
 			return err
 		}
@@ -249,22 +553,17 @@ func (p *parser) handleQuoted(c byte) error {
 }
 
 func (p *parser) handleUnquoted(c byte) error {
-	switch c {
-	case '\'':
+	switch {
+	case p.group:
+		return p.scanGroup(c)
+	case c == '(' && p.cfg.groups && !p.inValue:
+		return p.openGroup()
+	case c == p.cfg.quote && !p.cfg.literalQuotes:
 		p.inQuote = true
-	case '\\':
+	case c == p.cfg.escape && !p.cfg.noEscapes:
 		return p.consumeEscape()
-	case '=':
-		if !p.inValue {
-			return p.setKey()
-		}
-	case ',':
-		if err := p.emitItem(); err != nil {
-			return err
-		}
-		p.start = p.pos + 1
-		p.inValue = false
-		p.key = ""
+	case c == p.cfg.kvSep && !p.inValue && p.cfg.kvSep != 0:
+		return p.setKey()
 	}
 
 	return nil
@@ -273,11 +572,11 @@ func (p *parser) handleUnquoted(c byte) error {
 func (p *parser) consumeEscape() error {
 	next := p.pos + 1
 	if next >= len(p.tag) {
-		return &Error{p.tag, p.pos, errUnterminatedEscape, nil}
+		return p.fail(p.errorAt(p.pos, CodeUnterminatedEscape))
 	}
 	c := p.tag[next]
-	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
-		return &Error{p.tag, next, errInvalidEscape, nil}
+	if !p.cfg.quotedPairs && isAlnum(c) {
+		return p.fail(p.errorAt(next, CodeInvalidEscape))
 	}
 	p.pos = next
 
@@ -286,14 +585,26 @@ func (p *parser) consumeEscape() error {
 
 func (p *parser) setKey() error {
 	keyStr := p.tag[p.start:p.pos]
-	key, err := unquoteTrim(keyStr)
-	if err != nil {
-		return p.wrapUnquoteError(err, p.start)
+	key, err := p.unquoteTrim(keyStr)
+	var keyErr *Error
+	switch {
+	case err != nil:
+		keyErr = p.wrapUnquoteError(err, p.start)
+	case key == "":
+		keyErr = p.errorAt(p.start, CodeEmptyKey)
+	case p.negationAt() >= 0:
+		keyErr = p.errorAt(p.pos, CodeUnexpectedValue)
+		keyErr.Msg = errNegatedValue
 	}
-	if key == "" {
-		return &Error{p.tag, p.start, errEmptyKey, nil}
+	if keyErr != nil {
+		// Treat the rest of the item as a value so that a lenient parse
+		// reports the malformed key only once.
+		p.inValue = true
+
+		return p.fail(keyErr)
 	}
 	p.key = keyStr
+	p.unquotedKey = p.foldKey(key)
 	p.keyStart = p.start
 	p.start = p.pos + 1
 	p.inValue = true
@@ -301,29 +612,215 @@ func (p *parser) setKey() error {
 	return nil
 }
 
-func (p *parser) emitItem() error {
+// endItem finishes the current item. It reports ok == false for items that
+// are skipped, either because they are empty or because they are malformed
+// and the parser is lenient.
+func (p *parser) endItem() (key, value string, ok bool, err error) {
 	p.count++
 
+	if p.skip {
+		p.skip = false
+
+		return "", "", false, nil
+	}
+
 	if p.shouldSkipEmptyItem() {
-		return nil
+		return "", "", false, nil
 	}
 
-	key, value, err := p.getKeyValue()
-	if err != nil {
-		return err
+	key, value, keyErr := p.getKeyValue()
+	if keyErr != nil {
+		return "", "", false, p.collect(keyErr)
 	}
 
 	if p.shouldSkipCompletelyEmpty(key, value) {
+		return "", "", false, nil
+	}
+
+	if p.cfg.simple {
+		if key != "" {
+			key = p.cfg.intern(key)
+		}
+
+		return key, value, true, nil
+	}
+
+	return p.checkItem(key, value)
+}
+
+// checkItem applies the per-item checks and rewrites of the configuration
+// to an item, see endItem.
+func (p *parser) checkItem(key, value string) (string, string, bool, error) {
+	if charErr := p.checkItemChars(); charErr != nil {
+		return "", "", false, p.collect(charErr)
+	}
+	if limitErr := p.checkItemLimits(key, value); limitErr != nil {
+		return "", "", false, p.collect(limitErr)
+	}
+	p.deprecatedKey = ""
+	if replacement, ok := p.cfg.deprecated[key]; ok && key != "" {
+		p.deprecatedKey = key
+		if replacement != "" {
+			key = replacement
+		}
+	}
+	if canonical, ok := p.cfg.aliases[key]; ok && key != "" {
+		key = canonical
+	}
+	if key != "" && p.cfg.knownKeys != nil && !p.cfg.knownKeys[key] {
+		if keep, err := p.unknownKey(key, value); !keep {
+			return "", "", false, err
+		}
+	}
+	if p.cfg.resolve != nil {
+		resolved, err := p.resolvePlaceholders(key, value)
+		if err != nil {
+			return "", "", false, p.collect(err)
+		}
+		p.setValue(&value, resolved)
+	}
+	if transform := p.cfg.transforms[key]; transform != nil && key != "" {
+		transformed, err := transform(value)
+		if err != nil {
+			return "", "", false, p.collect(p.invalidValue(key, value, err))
+		}
+		p.setValue(&value, transformed)
+	}
+	if validate := p.cfg.validators[key]; validate != nil && key != "" {
+		if err := validate(value); err != nil {
+			return "", "", false, p.collect(p.invalidValue(key, value, err))
+		}
+	}
+	if key != "" {
+		key = p.cfg.intern(key)
+		p.options++
+		if limit := p.cfg.limits.maxOptions; limit > 0 && p.options > limit {
+			// Like an oversized tag, too many options stop even a lenient parse
+			keyPos, _ := p.positions(key)
+
+			return "", "", false, p.errorAt(keyPos, CodeTooManyOptions)
+		}
+	}
+
+	return key, value, true, nil
+}
+
+// setValue replaces the value of the current option, forgetting its
+// escaped list separators if it changes.
+func (p *parser) setValue(value *string, s string) {
+	if s != *value {
+		*value, p.escapedSeps = s, nil
+	}
+}
+
+// unknownKey decides the fate of an option with an unknown key, see
+// WithUnknownKeyHandler. It reports whether the option is kept, and the
+// error rejecting it unless the parser is lenient.
+func (p *parser) unknownKey(key, value string) (keep bool, err error) {
+	keyPos, _ := p.positions(key)
+	var cause error
+	if p.cfg.unknownKey != nil {
+		cause = p.cfg.unknownKey(key, value, keyPos)
+		switch {
+		case cause == nil:
+			return false, nil
+		case errors.Is(cause, KeepUnknownKey):
+			return true, nil
+		}
+	}
+
+	unknownErr := p.errorAt(keyPos, CodeUnknownKey)
+	unknownErr.Msg = fmt.Sprintf("%s %q", errUnknownKey, key)
+	unknownErr.Cause = cause
+
+	return false, p.collect(unknownErr)
+}
+
+// invalidValue reports the value of the current option as rejected by a
+// validator or a transformer with err.
+func (p *parser) invalidValue(key, value string, err error) *Error {
+	keyPos, valPos := p.positions(key)
+	if valPos < 0 {
+		valPos = keyPos
+	}
+	valueErr := p.errorAt(valPos, CodeInvalidValue)
+	valueErr.Msg = fmt.Sprintf("%s %q for %q", errInvalidValue, value, key)
+	valueErr.Cause = err
+
+	return valueErr
+}
+
+// checkItemChars rejects invalid UTF-8 and control characters in the key
+// and value of the current item when the parser checks characters.
+func (p *parser) checkItemChars() *Error {
+	if !p.cfg.strictChars {
+		return nil
+	}
+	if p.inValue {
+		if err := p.checkChars(p.keyStart, p.start-1); err != nil {
+			return err
+		}
+	}
+
+	return p.checkChars(p.start, p.pos)
+}
+
+// checkChars checks the text between from and to, ignoring the whitespace
+// around it unless whitespace is preserved.
+func (p *parser) checkChars(from, to int) *Error {
+	if !p.cfg.preserveWhitespace {
+		start, end := trimWhitespace(p.tag[from:to], p.cfg.escape)
+		from, to = from+start, from+end
+	}
+
+	for i := from; i < to; {
+		c := p.tag[i]
+		if c < utf8.RuneSelf {
+			if (c < ' ' || c == 0x7f) && !(p.cfg.continuation && (c == '\n' || c == '\r' || c == '\t')) {
+				return p.errorAt(i, CodeControlChar)
+			}
+			i++
+
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(p.tag[i:to])
+		if r == utf8.RuneError && size == 1 {
+			return p.errorAt(i, CodeInvalidUTF8)
+		}
+		i += size
+	}
+
+	return nil
+}
+
+// checkItemLimits checks the unescaped key and value of the current item
+// against the configured length limits.
+func (p *parser) checkItemLimits(key, value string) *Error {
+	limits := &p.cfg.limits
+	if limits.maxKeyLength <= 0 && limits.maxValueLength <= 0 {
 		return nil
 	}
 
-	if err := p.callback(key, value); err != nil {
-		return &Error{p.tag, p.keyStart, p.key, err}
+	keyPos, valPos := p.positions(key)
+	if limits.maxKeyLength > 0 && len(key) > limits.maxKeyLength {
+		return p.errorAt(keyPos, CodeKeyTooLong)
+	}
+	if limits.maxValueLength > 0 && len(value) > limits.maxValueLength {
+		return p.errorAt(valPos, CodeValueTooLong)
 	}
 
 	return nil
 }
 
+// callbackError wraps an error returned by a callback for the current item.
+func (p *parser) callbackError(err error) *Error {
+	cbErr := p.errorAt(p.keyStart, CodeCallback)
+	cbErr.Msg = p.key
+	cbErr.Cause = err
+
+	return cbErr
+}
+
 // shouldSkipEmptyItem checks if empty items between commas should be skipped.
 func (p *parser) shouldSkipEmptyItem() bool {
 	return p.start >= p.pos && p.count > 1 && !p.inValue && p.key == ""
@@ -335,93 +832,184 @@ func (p *parser) shouldSkipCompletelyEmpty(key, value string) bool {
 }
 
 //nolint:cyclop // Complex switch statement for different parsing modes - acceptable complexity
-func (p *parser) getKeyValue() (string, string, error) {
+func (p *parser) getKeyValue() (string, string, *Error) {
 	switch {
 	case p.count == 1 && !p.inValue && p.treatFirstAsName:
 		// First item without equals becomes the name (only when treatFirstAsName is true)
-		value, err := unquoteTrim(p.tag[p.start:p.pos])
+		if p.filter {
+			if err := p.checkQuotes(p.tag[p.start:p.pos]); err != nil {
+				return "", "", p.wrapUnquoteError(err, p.start)
+			}
+
+			return "", "", nil
+		}
+		value, err := p.unquoteTrim(p.tag[p.start:p.pos])
 		if err != nil {
 			return "", "", p.wrapUnquoteError(err, p.start)
 		}
 
 		return "", value, nil
 
+	case p.count == 1 && p.inValue && p.treatFirstAsName && p.cfg.strictName:
+		// Strict mode rejects a key=value pair in place of the name
+		return "", "", p.errorAt(p.skipSpace(p.keyStart), CodeMissingName)
+
+	case p.group:
+		// Group; its text is kept as written, for Tag.Group
+		return p.unquotedKey, p.raw(p.start, p.groupEnd), nil
+
 	case p.inValue:
-		// Key-value pair
-		key, err := unquoteTrim(p.key)
-		if err != nil {
-			return "", "", p.wrapUnquoteError(err, p.keyStart)
+		// Key-value pair; the key was validated by setKey
+		if p.filter && p.unquotedKey != p.want {
+			if err := p.checkQuotes(p.tag[p.start:p.pos]); err != nil {
+				return "", "", p.wrapUnquoteError(err, p.start)
+			}
+
+			return p.unquotedKey, "", nil
 		}
-		value, err := unquoteTrim(p.tag[p.start:p.pos])
+		value, err := p.unquoteValue(p.tag[p.start:p.pos])
 		if err != nil {
 			return "", "", p.wrapUnquoteError(err, p.start)
 		}
 
-		return key, value, nil
+		return p.unquotedKey, value, nil
 
 	case p.start < p.pos:
 		// Key-only item (flag without value)
-		key, err := unquoteTrim(p.tag[p.start:p.pos])
+		start, value := p.start, ""
+		if neg := p.negationAt(); neg >= 0 {
+			start, value = neg+1, "false"
+		}
+		key, err := p.unquoteTrim(p.tag[start:p.pos])
 		if err != nil {
-			return "", "", p.wrapUnquoteError(err, p.start)
+			return "", "", p.wrapUnquoteError(err, start)
 		}
 		if key == "" {
-			return "", "", &Error{p.tag, p.start, errEmptyKey, nil}
+			return "", "", p.errorAt(p.start, CodeEmptyKey)
 		}
 
-		return key, "", nil
+		return p.foldKey(key), value, nil
 	}
 
 	return "", "", nil
 }
 
-func (p *parser) wrapUnquoteError(err error, offset int) error {
+// foldKey lower-cases key when keys are case-insensitive.
+func (p *parser) foldKey(key string) string {
+	if p.cfg.foldKeys {
+		return strings.ToLower(key)
+	}
+
+	return key
+}
+
+// negationAt returns the position of the '!' starting the current item when
+// the parser accepts negated flags, or -1.
+func (p *parser) negationAt() int {
+	if !p.cfg.negation {
+		return -1
+	}
+	if i := p.skipSpace(p.start); i < p.pos && p.tag[i] == '!' {
+		return i
+	}
+
+	return -1
+}
+
+func (p *parser) wrapUnquoteError(err error, offset int) *Error {
 	var ue *unquoteError
 	if errors.As(err, &ue) {
-		return &Error{p.tag, offset + ue.pos, ue.msg, nil}
+		return p.errorAt(offset+ue.pos, ue.code)
 	}
 
-	return &Error{p.tag, offset, err.Error(), nil}
+	unknown := p.errorAt(offset, CodeUnknown)
+	unknown.Msg = err.Error()
+
+	return unknown
 }
 
 var asciiSpace = [256]uint8{'\t': 1, '\n': 1, '\v': 1, '\f': 1, '\r': 1, ' ': 1}
 
 // unquoteTrim trims whitespace, processes escapes, and removes quotes.
-func unquoteTrim(s string) (string, error) {
-	start, end := trimWhitespace(s)
+// Whitespace is left intact when the parser preserves it.
+func (p *parser) unquoteTrim(s string) (string, error) {
+	return p.unquote(s, 0)
+}
+
+// unquoteValue is like unquoteTrim for option values, recording the
+// offsets of escaped list separators in escapedSeps when the parser has a
+// list separator.
+func (p *parser) unquoteValue(s string) (string, error) {
+	return p.unquote(s, p.cfg.listSep)
+}
+
+// unquote implements unquoteTrim, recording escaped listSep separators
+// unless listSep is 0.
+func (p *parser) unquote(s string, listSep byte) (string, error) {
+	start, end := 0, len(s)
+	if !p.cfg.preserveWhitespace {
+		start, end = trimWhitespace(s, p.cfg.escape)
+	}
 	if start >= end {
 		return "", nil
 	}
 
 	// Fast path: no escapes or quotes
-	if strings.IndexByte(s, '\\') < 0 && strings.IndexByte(s, '\'') < 0 {
+	if p.split || !p.cfg.hasEscapes(s) && (p.cfg.literalQuotes || strings.IndexByte(s, p.cfg.quote) < 0) {
 		return s[start:end], nil
 	}
 
-	return processQuotedString(s, start, end)
+	return p.processQuotedString(s, start, end, listSep)
 }
 
-func processQuotedString(s string, start, end int) (string, error) {
-	hasQuotes := s[start] == '\'' && s[end-1] == '\''
-	b := make([]byte, 0, end-start)
+func (p *parser) processQuotedString(s string, start, end int, listSep byte) (string, error) {
+	quote, escape := p.cfg.quote, p.cfg.escape
+	hasQuotes := !p.cfg.literalQuotes && s[start] == quote && s[end-1] == quote
+
+	// Quoted value without escapes: the text between the quotes is the value
+	if hasQuotes && end-start >= 2 {
+		inner := s[start+1 : end-1]
+		if !p.cfg.hasEscapes(inner) && strings.IndexByte(inner, quote) < 0 {
+			return inner, nil
+		}
+	}
+
+	b := p.scratch(end - start)
+	mark := len(b)
 	quoteCount := 0
 	firstQuotePos := -1
 
 	for i := start; i < end; i++ {
 		c := s[i]
-		switch c {
-		case '\\':
+		switch {
+		case c == escape && !p.cfg.noEscapes:
+			if n := p.lineBreak(s[i+1 : end]); n > 0 {
+				i += n
+				for i+1 < end && (s[i+1] == ' ' || s[i+1] == '\t') {
+					i++
+				}
+
+				continue
+			}
 			if i+1 < end {
+				if s[i+1] == listSep && listSep != 0 {
+					p.escapedSeps = append(p.escapedSeps, len(b)-mark)
+				}
 				b = append(b, s[i+1])
 				i++
 			}
-		case '\'':
+		case c == quote:
+			if p.cfg.literalQuotes {
+				b = append(b, c)
+
+				continue
+			}
 			quoteCount++
 			if firstQuotePos < 0 {
 				firstQuotePos = i
 			}
 			if err := validateQuoteAt(quoteCount, i, start, end); err != nil {
-				return string(b), err
+				return "", err
 			}
 		default:
 			b = append(b, c)
@@ -429,24 +1017,89 @@ func processQuotedString(s string, start, end int) (string, error) {
 	}
 
 	if err := validateFinalQuotes(hasQuotes, quoteCount, firstQuotePos); err != nil {
-		return string(b), err
+		return "", err
+	}
+
+	p.buf = b
+
+	return bytesView(b[mark:]), nil
+}
+
+// checkQuotes reports the errors unquoteTrim would for s without unescaping
+// it, for values that are not returned.
+func (p *parser) checkQuotes(s string) error {
+	start, end := 0, len(s)
+	if !p.cfg.preserveWhitespace {
+		start, end = trimWhitespace(s, p.cfg.escape)
+	}
+	if start >= end || p.split || p.cfg.literalQuotes {
+		return nil
+	}
+
+	quote := p.cfg.quote
+	hasQuotes := s[start] == quote && s[end-1] == quote
+	quoteCount := 0
+	firstQuotePos := -1
+	for i := start; i < end; i++ {
+		switch c := s[i]; {
+		case c == p.cfg.escape && !p.cfg.noEscapes:
+			i++
+		case c == quote:
+			quoteCount++
+			if firstQuotePos < 0 {
+				firstQuotePos = i
+			}
+			if err := validateQuoteAt(quoteCount, i, start, end); err != nil {
+				return err
+			}
+		}
+	}
+
+	return validateFinalQuotes(hasQuotes, quoteCount, firstQuotePos)
+}
+
+// lineBreak returns the length of the line break starting s, LF or CRLF,
+// when the parser joins continued lines, and 0 otherwise.
+func (p *parser) lineBreak(s string) int {
+	switch {
+	case !p.cfg.continuation:
+		return 0
+	case strings.HasPrefix(s, "\n"):
+		return 1
+	case strings.HasPrefix(s, "\r\n"):
+		return 2
+	default:
+		return 0
+	}
+}
+
+// scratch returns the unescaping buffer with room for at least n more bytes.
+//
+// Unescaped values are views into a single buffer allocated once per parse
+// and sized to the whole tag, which always fits every value, unless Parse
+// passed the buffer of a bufferedTag. Bytes are only ever appended, so views
+// handed out earlier stay valid. The buffer cannot be pooled across parses
+// because returned values keep referring to it.
+func (p *parser) scratch(n int) []byte {
+	if cap(p.buf)-len(p.buf) < n {
+		p.buf = make([]byte, 0, max(len(p.tag), n))
 	}
 
-	return string(b), nil
+	return p.buf
 }
 
 func validateQuoteAt(quoteCount, pos, start, end int) error {
 	switch quoteCount {
 	case 1:
 		if pos != start {
-			return &unquoteError{errQuotesMustEnclose, pos}
+			return &unquoteError{CodeQuoteInMiddle, pos}
 		}
 	case 2:
 		if pos != end-1 {
-			return &unquoteError{errQuotesMustEnclose, pos}
+			return &unquoteError{CodeQuoteInMiddle, pos}
 		}
 	default:
-		return &unquoteError{errInvalidQuote, pos}
+		return &unquoteError{CodeInvalidQuote, pos}
 	}
 
 	return nil
@@ -454,16 +1107,18 @@ func validateQuoteAt(quoteCount, pos, start, end int) error {
 
 func validateFinalQuotes(hasQuotes bool, quoteCount, firstQuotePos int) error {
 	if hasQuotes && quoteCount != 2 {
-		return &unquoteError{errQuotesMustEnclose, firstQuotePos}
+		return &unquoteError{CodeQuoteInMiddle, firstQuotePos}
 	}
 	if !hasQuotes && quoteCount > 0 {
-		return &unquoteError{errQuotesMustEnclose, firstQuotePos}
+		return &unquoteError{CodeQuoteInMiddle, firstQuotePos}
 	}
 
 	return nil
 }
 
-func trimWhitespace(s string) (start, end int) {
+// trimWhitespace returns the bounds of s without the whitespace around it,
+// keeping trailing whitespace preceded by the escape character.
+func trimWhitespace(s string, escape byte) (start, end int) {
 	n := len(s)
 	for start < n && asciiSpace[s[start]] != 0 {
 		start++
@@ -472,7 +1127,7 @@ func trimWhitespace(s string) (start, end int) {
 	for end > start && asciiSpace[s[end-1]] != 0 {
 		// Check if space is escaped
 		backslashes := 0
-		for j := end - 2; j >= start && s[j] == '\\'; j-- {
+		for j := end - 2; j >= start && s[j] == escape; j-- {
 			backslashes++
 		}
 		if backslashes%2 == 1 {
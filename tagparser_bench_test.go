@@ -144,3 +144,66 @@ func BenchmarkParse_LongValues(b *testing.B) {
 		_, _ = Parse(tag)
 	}
 }
+
+// Benchmark byte slice input.
+func BenchmarkParseFuncBytes_ZeroAlloc(b *testing.B) {
+	tag := []byte(benchTagSimple)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ParseFuncBytes(tag, func(k, v string) error { return nil })
+	}
+}
+
+// Benchmark iterator API.
+func BenchmarkOptions_ZeroAlloc(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for k, v := range Options(benchTagSimple) {
+			_, _ = k, v
+		}
+	}
+}
+
+// Benchmark cached parsing of a repeated tag.
+func BenchmarkCachedParser_Parse(b *testing.B) {
+	c := NewCachedParser(16)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.Parse(benchTagSimple)
+	}
+}
+
+// Benchmark parsing a large batch concurrently.
+func BenchmarkParseBatch(b *testing.B) {
+	tags := make([]string, 10000)
+	for i := range tags {
+		tags[i] = benchTagSimpleLong
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = ParseBatch(tags)
+	}
+}
+
+// Benchmark parsing into a reused Tag.
+func BenchmarkParseInto_Simple(b *testing.B) {
+	var tag Tag
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ParseInto(&tag, benchTagSimple)
+	}
+}
+
+// Benchmark asking a single question of a tag.
+func BenchmarkHasOption(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = HasOption(benchTagSimple, "omitempty")
+	}
+}
@@ -1,6 +1,7 @@
 package tagparser
 
 import (
+	"maps"
 	"testing"
 )
 
@@ -78,3 +79,133 @@ func FuzzParseFuncWithName(f *testing.F) {
 		})
 	})
 }
+
+func FuzzConvert(f *testing.F) {
+	// Seed corpus
+	f.Add(`name,omitempty,min=5`)
+	f.Add(`'a, b',x=' y ',z=\'q\'`)
+	f.Add(`a=b\;c,d=e:f`)
+	f.Add(` \ x\  ,k=\\`)
+
+	named := DialectSpec{Name: true}
+	gorm := DialectSpec{Syntax: New(WithSeparator(';'), WithKeyValueSeparator(':')), Name: true}
+	f.Fuzz(func(t *testing.T, input string) {
+		want, err := parseDialect(named, input)
+		if err != nil {
+			return
+		}
+
+		// Converting to another syntax and back keeps the tag
+		converted, err := Convert(input, named, gorm)
+		if err != nil {
+			t.Fatalf("Convert(%q) to gorm: %v", input, err)
+		}
+		back, err := Convert(converted, gorm, named)
+		if err != nil {
+			t.Fatalf("Convert(%q) from gorm: %v", converted, err)
+		}
+		got, err := parseDialect(named, back)
+		if err != nil {
+			t.Fatalf("parse %q: %v", back, err)
+		}
+		if got.Name != want.Name || !maps.Equal(got.Options, want.Options) {
+			t.Fatalf("%q -> %q -> %q: got %v, want %v", input, converted, back, got, want)
+		}
+	})
+}
+
+func FuzzParseCST(f *testing.F) {
+	// Seed corpus
+	f.Add(`name , min = 5,,msg='a, b' ,`)
+	f.Add(` k= ,\,x\ ,'q'`)
+	f.Add(`,`)
+	f.Add(``)
+	f.Add(`a( b,'c)' ) ,d(e(f))`)
+	f.Add(`a | b,,c|`)
+
+	parsers := []*Parser{defaultParser, jsonParser, gormParser, New(WithGroups(), WithAlternatives())}
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, p := range parsers {
+			for _, withName := range []bool{false, true} {
+				cst, err := p.parseCST(input, withName)
+				if err != nil {
+					continue
+				}
+
+				// Rendering gives the input back
+				if got := cst.Render(); got != input {
+					t.Fatalf("Render(%q) = %q", input, got)
+				}
+			}
+		}
+	})
+}
+
+func FuzzFillSimple(f *testing.F) {
+	f.Add(`name , min = 5,,msg=x ,`)
+	f.Add(`a=b=c,=d`)
+	f.Add(`Col:ID;NOT NULL`)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, p := range simpleParsers {
+			checkSimple(t, p, input, false)
+			checkSimple(t, p, input, true)
+			checkNextSimple(t, p, input, false)
+			checkNextSimple(t, p, input, true)
+		}
+	})
+}
+
+func FuzzScanner(f *testing.F) {
+	f.Add(`name,key='a,b',x=\,y`)
+	f.Add(`a=(x,'y)'),b`)
+	f.Add(`a='b`)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		if len(input) > 64 {
+			return
+		}
+		for _, p := range scannerParsers {
+			checkScanner(t, p, input, false)
+			checkScanner(t, p, input, true)
+		}
+	})
+}
+
+func FuzzFormatReparse(f *testing.F) {
+	f.Add(`name,min=5,omitempty`)
+	f.Add(`\,`)
+	f.Add("\\\t")
+	f.Add(`\"a,k=b"`)
+	f.Add(` 'a, b' ,k='x y',default=`)
+	f.Add(`oneof=a\;b;c,path=c:\\dir`)
+
+	parsers := []*Parser{defaultParser, New(WithListSeparator(';'))}
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, p := range parsers {
+			tag, err := p.ParseWithName(input)
+			if err != nil {
+				continue
+			}
+			// Written tags read back as the same tag; Canonicalize writes
+			// empty values as flags
+			for i, write := range []func(string) (string, error){p.Minify, p.Normalize, p.Canonicalize} {
+				out, err := write(input)
+				if err != nil {
+					t.Fatalf("%q: %v", input, err)
+				}
+				back, err := p.ParseWithName(out)
+				if err != nil {
+					t.Fatalf("%q written as %q: %v", input, out, err)
+				}
+				same := back.Equal(tag)
+				if i == 2 {
+					same = back.Name == tag.Name && maps.Equal(back.Options, tag.Options)
+				}
+				if !same {
+					t.Fatalf("%q written as %q reads back as %v", input, out, back)
+				}
+			}
+		}
+	})
+}
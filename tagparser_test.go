@@ -2,6 +2,7 @@ package tagparser
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -52,6 +53,72 @@ func TestParseFunc_OptionsMode(t *testing.T) {
 	assert.Equal(t, M{"foo": "", "bar": "baz"}, opts)
 }
 
+func TestParse_SizesOptionsMap(t *testing.T) {
+	tag := `opt1,opt2,opt3,opt4,opt5,opt6,opt7,opt8,opt9,opt10,opt11,opt12,opt13,opt14,opt15`
+	assert.Equal(t, 15, defaultParser.countItems(tag))
+	assert.Equal(t, 3, defaultParser.countItems(`a,b='x,y'`))
+
+	sized := testing.AllocsPerRun(100, func() {
+		_, _ = Parse(tag)
+	})
+	grown := testing.AllocsPerRun(100, func() {
+		_ = ParseInto(&Tag{}, tag)
+	})
+	assert.Less(t, sized, grown)
+}
+
+func TestParseInto(t *testing.T) {
+	var tag Tag
+	require.NoError(t, ParseIntoWithName(&tag, `name,a=1,b=`))
+	assert.Equal(t, "name", tag.Name)
+	assert.Equal(t, M{"a": "1", "b": ""}, tag.Options)
+	options := tag.Options
+
+	// The map is cleared and reused
+	require.NoError(t, ParseInto(&tag, `"b,c=2"`))
+	assert.Empty(t, tag.Name)
+	assert.Equal(t, M{"b": "", "c": "2"}, tag.Options)
+	_, hasValue, _ := tag.Lookup("b")
+	assert.False(t, hasValue)
+	options["x"] = "y"
+	assert.Equal(t, "y", tag.Options["x"])
+
+	require.Error(t, ParseInto(&tag, `d,=e,f`))
+	assert.Equal(t, M{"d": ""}, tag.Options)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = ParseInto(&tag, benchTagSimple)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(`"json,omitempty,msg='a, b'"`))
+	require.NoError(t, ValidateWithName(`name,a\,b=1`))
+
+	err := Validate(`a,=b`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeEmptyKey, parseErr.Code)
+	assert.Equal(t, 2, parseErr.Pos)
+
+	err = New(WithStrictName()).ValidateWithName(`a=b`)
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeMissingName, parseErr.Code)
+
+	// Checks of the Parser apply, and lenient parsers report every error
+	p := New(WithKnownKeys("a"), WithLenient())
+	err = p.Validate(`a,b,=c`)
+	var errs *Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs.List, 2)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = Validate(benchTagSimpleLong)
+	})
+	assert.Zero(t, allocs)
+}
+
 func TestParseWithName(t *testing.T) {
 	tests := []struct {
 		testName string
@@ -368,3 +435,84 @@ func TestExactlyMaxTagLength(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFuncPos(t *testing.T) {
+	type item struct {
+		key, value     string
+		keyPos, valPos int
+	}
+	tests := []struct {
+		name     string
+		tag      string
+		withName bool
+		items    []item
+	}{
+		{"options", `alfa,bravo=charlie`, false, []item{{"alfa", "", 0, -1}, {"bravo", "charlie", 5, 11}}},
+		{"whitespace", ` alfa , bravo = charlie `, false, []item{{"alfa", "", 1, -1}, {"bravo", "charlie", 8, 16}}},
+		{"quoted", `alfa='bravo, charlie',delta`, false, []item{{"alfa", "bravo, charlie", 0, 5}, {"delta", "", 22, -1}}},
+		{"empty value", `alfa=,bravo`, false, []item{{"alfa", "", 0, 5}, {"bravo", "", 6, -1}}},
+		{"name", `alfa,bravo=charlie`, true, []item{{"", "alfa", -1, 0}, {"bravo", "charlie", 5, 11}}},
+		{"no name", `,bravo`, true, []item{{"bravo", "", 1, -1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []item
+			fn := ParseFuncPos
+			if tt.withName {
+				fn = ParseFuncWithNamePos
+			}
+			err := fn(tt.tag, func(key, value string, keyPos, valPos int) error {
+				items = append(items, item{key, value, keyPos, valPos})
+
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.items, items)
+		})
+	}
+}
+
+func TestParseFuncPos_PreserveWhitespace(t *testing.T) {
+	var keyPos, valPos int
+	err := New(WithPreserveWhitespace()).ParseFuncPos(` alfa = bravo`, func(key, value string, kp, vp int) error {
+		keyPos, valPos = kp, vp
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, keyPos)
+	assert.Equal(t, 7, valPos)
+}
+
+func TestParse_UnescapedValuesIndependent(t *testing.T) {
+	// Unescaped keys and values share one buffer per parse
+	tag, err := Parse(`a\,b='c\'d',e\=f=g\,h,'i,j'=k\ l,m`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"a,b": "c'd", "e=f": "g,h", "i,j": "k l", "m": ""}, tag.Options)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = ParseFunc(`a\,b='c\'d',e\=f=g\,h`, func(key, value string) error { return nil })
+	})
+	assert.Equal(t, 1.0, allocs, "unescaping should allocate once per parse")
+}
+
+func TestParse_EscapesShareTagAllocation(t *testing.T) {
+	// The Tag and its unescaping buffer take a single allocation, so that
+	// a tag with escapes allocates no more than one without
+	escaped := `name='complex\'quoted',key=val\,ue,flag\=test`
+	plain := `name='complex quoted',key=value,flag`
+	assert.Equal(t,
+		testing.AllocsPerRun(100, func() { _, _ = Parse(plain) }),
+		testing.AllocsPerRun(100, func() { _, _ = Parse(escaped) }))
+
+	tag, err := Parse(escaped)
+	require.NoError(t, err)
+	assert.Equal(t, M{"name": "complex'quoted", "key": "val,ue", "flag=test": ""}, tag.Options)
+
+	// Longer tags get a buffer of their own
+	long := `a=` + strings.Repeat(`x\,`, inlineBuffer) + `,b=\'`
+	tag, err = Parse(long)
+	require.NoError(t, err)
+	assert.Equal(t, M{"a": strings.Repeat("x,", inlineBuffer), "b": "'"}, tag.Options)
+}
@@ -0,0 +1,337 @@
+package tagparser
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnsupportedType is returned by Unmarshal when the destination struct has
+// a field whose type cannot hold option values.
+var ErrUnsupportedType = errors.New("unsupported field type")
+
+// metaTagKey is the struct tag key read by Unmarshal on destination fields.
+const metaTagKey = "tagparser"
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// Unmarshal parses tag and stores its options in the struct pointed to by
+// dst, so that a tag dialect can be described by a plain struct:
+//
+//	type MinMax struct {
+//	    Min      int
+//	    Max      int
+//	    Required bool
+//	}
+//
+//	var mm MinMax
+//	err := tagparser.Unmarshal(`required,min=5,max=10`, &mm)
+//
+// Each exported field receives the option whose key matches its name,
+// ignoring case. A `tagparser` tag on the field changes that:
+//
+//	Min   int      `tagparser:"min_len"`  // option key
+//	Scope []string `tagparser:",sep=;"`   // list separator, see below
+//	Name  string   `tagparser:",name"`    // the tag name, see below
+//	Cache any      `tagparser:"-"`        // ignored
+//
+// When dst has a field marked with the name option, tag is parsed as with
+// ParseWithName and the field receives the name; otherwise all items are
+// options. Fields may be strings, bools, integers, floats, time.Duration,
+// types implementing encoding.TextUnmarshaler, pointers to those, or slices
+// of them. Slice elements are separated by the separator declared with
+// WithListSeparator, or '|'. A flag sets a bool field to true.
+//
+// Options without a matching field and values that do not convert to the
+// field type are reported as *Error with Code CodeUnknownKey and
+// CodeInvalidValue. Fields without an option keep their value.
+func Unmarshal(tag string, dst any) error {
+	return defaultParser.Unmarshal(tag, dst)
+}
+
+// Unmarshal parses tag and stores its options in the struct pointed to by
+// dst, like the package-level Unmarshal. A lenient Parser sets every option
+// it can and reports all problems as *Errors.
+func (p *Parser) Unmarshal(tag string, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrNotStruct, dst)
+	}
+	v = v.Elem()
+
+	dec, err := cachedDecoder(v.Type())
+	if err != nil {
+		return err
+	}
+
+	return dec.decode(p, tag, v)
+}
+
+// Decode parses tag into a new value of the struct type T, like Unmarshal:
+//
+//	opts, err := tagparser.Decode[MinMax](`required,min=5,max=10`)
+//
+// On error the returned value holds whatever was decoded before it.
+func Decode[T any](tag string) (T, error) {
+	return DecodeWith[T](defaultParser, tag)
+}
+
+// DecodeWith is like Decode but parses tag with p.
+func DecodeWith[T any](p *Parser, tag string) (T, error) {
+	var dst T
+	err := p.Unmarshal(tag, &dst)
+
+	return dst, err
+}
+
+type decoderCacheEntry struct {
+	dec *structDecoder
+	err error
+}
+
+// decoderCache holds the field mapping of Unmarshal destinations per type.
+var decoderCache sync.Map // map[reflect.Type]*decoderCacheEntry
+
+func cachedDecoder(t reflect.Type) (*structDecoder, error) {
+	if cached, ok := decoderCache.Load(t); ok {
+		entry := cached.(*decoderCacheEntry) //nolint:forcetypeassert // only *decoderCacheEntry is stored
+
+		return entry.dec, entry.err
+	}
+
+	dec, err := newStructDecoder(t)
+	cached, _ := decoderCache.LoadOrStore(t, &decoderCacheEntry{dec: dec, err: err})
+	entry := cached.(*decoderCacheEntry) //nolint:forcetypeassert // only *decoderCacheEntry is stored
+
+	return entry.dec, entry.err
+}
+
+// structDecoder maps the options of a tag to the fields of a struct type.
+type structDecoder struct {
+	fields []fieldDecoder
+	name   []int // index of the field receiving the tag name, if any
+}
+
+type fieldDecoder struct {
+	key   string
+	index []int
+	sep   string // list separator, empty for the Parser's
+}
+
+func newStructDecoder(t reflect.Type) (*structDecoder, error) {
+	dec := &structDecoder{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		meta, err := ParseWithName(field.Tag.Get(metaTagKey))
+		if err != nil {
+			return nil, &FieldError{Struct: t.Name(), Field: field.Name, Key: metaTagKey, Err: err}
+		}
+		if meta.Name == "-" {
+			continue
+		}
+		if !decodable(field.Type) {
+			return nil, &FieldError{Struct: t.Name(), Field: field.Name, Err: fmt.Errorf("%w %v", ErrUnsupportedType, field.Type)}
+		}
+
+		if _, ok := meta.Options["name"]; ok {
+			if field.Type.Kind() != reflect.String {
+				return nil, &FieldError{Struct: t.Name(), Field: field.Name, Err: fmt.Errorf("%w %v for the tag name", ErrUnsupportedType, field.Type)}
+			}
+			dec.name = field.Index
+
+			continue
+		}
+
+		fd := fieldDecoder{key: meta.Name, index: field.Index, sep: meta.Options["sep"]}
+		if fd.key == "" {
+			fd.key = field.Name
+		}
+		dec.fields = append(dec.fields, fd)
+	}
+
+	return dec, nil
+}
+
+// decodable reports whether option values can be stored in a field of type t.
+func decodable(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() { //nolint:exhaustive // remaining kinds cannot hold option values
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Pointer:
+		return decodable(t.Elem())
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && decodable(t.Elem())
+	default:
+		return false
+	}
+}
+
+// field returns the decoder of the field receiving key: the one with the
+// exact key if any, otherwise the first one matching it regardless of case.
+func (d *structDecoder) field(key string) *fieldDecoder {
+	var fold *fieldDecoder
+	for i := range d.fields {
+		fd := &d.fields[i]
+		if fd.key == key {
+			return fd
+		}
+		if fold == nil && strings.EqualFold(fd.key, key) {
+			fold = fd
+		}
+	}
+
+	return fold
+}
+
+func (d *structDecoder) decode(cfg *Parser, tag string, v reflect.Value) error {
+	tag = cfg.unquoteGo(tag)
+
+	ps := parser{cfg: cfg, tag: tag, treatFirstAsName: d.name != nil}
+	if err := ps.check(func(key, value string) *Error {
+		return d.set(&ps, v, key, value)
+	}); err != nil {
+		return err
+	}
+
+	return ps.err()
+}
+
+// set stores the item last returned by ps.next in v.
+func (d *structDecoder) set(ps *parser, v reflect.Value, key, value string) *Error {
+	keyPos, valPos := ps.positions(key)
+	if key == "" {
+		v.FieldByIndex(d.name).SetString(value)
+
+		return nil
+	}
+
+	fd := d.field(key)
+	if fd == nil {
+		err := ps.errorAt(keyPos, CodeUnknownKey)
+		err.Msg = fmt.Sprintf("%s %q", errUnknownKey, key)
+
+		return err
+	}
+
+	list := newListFormat(ps.cfg.listSep)
+	list.escaped = ps.escapedSeps
+	if fd.sep != "" {
+		list = listFormat{sep: fd.sep}
+	}
+
+	if err := setValue(v.FieldByIndex(fd.index), value, list); err != nil {
+		if valPos < 0 {
+			valPos = keyPos
+		}
+		invalid := ps.errorAt(valPos, CodeInvalidValue)
+		invalid.Msg = fmt.Sprintf("%s for %q", errInvalidValue, key)
+		invalid.Cause = err
+
+		return invalid
+	}
+
+	return nil
+}
+
+// setValue converts s to the type of v and stores it. Slice elements are
+// separated as described by list.
+func setValue(v reflect.Value, s string, list listFormat) error {
+	if v.Kind() == reflect.Slice && !(v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType)) {
+		return setSlice(v, s, list)
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		u := v.Addr().Interface().(encoding.TextUnmarshaler) //nolint:forcetypeassert // checked above
+
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() { //nolint:exhaustive // other kinds are rejected by decodable
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			v.SetBool(true)
+
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), s, list); err != nil {
+			return err
+		}
+		v.Set(elem)
+	}
+
+	return nil
+}
+
+func setSlice(v reflect.Value, s string, list listFormat) error {
+	if s == "" {
+		v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+
+		return nil
+	}
+
+	parts := list.split(s)
+	slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		if err := setValue(slice.Index(i), part, listFormat{sep: list.sep}); err != nil {
+			return err
+		}
+	}
+	v.Set(slice)
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package tagparser
+
+import (
+	"net/netip"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type minMax struct {
+	Min      int
+	Max      int
+	Required bool
+}
+
+type unmarshalAll struct {
+	Name     string `tagparser:",name"`
+	Label    string
+	MinLen   int           `tagparser:"min_len"`
+	Ratio    float64       `tagparser:"ratio"`
+	Size     uint8         `tagparser:"size"`
+	Timeout  time.Duration `tagparser:"timeout"`
+	Limit    *int          `tagparser:"limit"`
+	OneOf    []string      `tagparser:"oneof"`
+	Scopes   []string      `tagparser:"scopes,sep=;"`
+	Ports    []int         `tagparser:"ports"`
+	Addr     netip.Addr    `tagparser:"addr"`
+	Internal string        `tagparser:"-"`
+	hidden   string        //nolint:unused // verifies unexported fields are skipped
+}
+
+func TestUnmarshal(t *testing.T) {
+	var mm minMax
+	require.NoError(t, Unmarshal(`required,min=5,max=10`, &mm))
+	assert.Equal(t, minMax{Min: 5, Max: 10, Required: true}, mm)
+}
+
+func TestUnmarshal_Types(t *testing.T) {
+	var dst unmarshalAll
+	err := Unmarshal(`email,label='a, b',min_len=0x10,ratio=0.5,size=255,timeout=1m30s,limit=3,`+
+		`oneof=red|green|blue,scopes='read;write',ports=80|443,addr=127.0.0.1`, &dst)
+	require.NoError(t, err)
+
+	limit := 3
+	assert.Equal(t, unmarshalAll{
+		Name:    "email",
+		Label:   "a, b",
+		MinLen:  16,
+		Ratio:   0.5,
+		Size:    255,
+		Timeout: 90 * time.Second,
+		Limit:   &limit,
+		OneOf:   []string{"red", "green", "blue"},
+		Scopes:  []string{"read", "write"},
+		Ports:   []int{80, 443},
+		Addr:    netip.MustParseAddr("127.0.0.1"),
+	}, dst)
+}
+
+func TestUnmarshal_KeepsUnsetFields(t *testing.T) {
+	mm := minMax{Min: 1, Max: 2}
+	require.NoError(t, Unmarshal(`max=7`, &mm))
+	assert.Equal(t, minMax{Min: 1, Max: 7}, mm)
+}
+
+func TestUnmarshal_CaseInsensitiveKeys(t *testing.T) {
+	var mm minMax
+	require.NoError(t, Unmarshal(`MIN=1,Max=2,required=false`, &mm))
+	assert.Equal(t, minMax{Min: 1, Max: 2}, mm)
+}
+
+func TestUnmarshal_UnknownKey(t *testing.T) {
+	var mm minMax
+	err := Unmarshal(`min=1,requird`, &mm)
+	require.Error(t, err)
+
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeUnknownKey, parseErr.Code)
+	assert.Equal(t, 6, parseErr.Pos)
+	assert.Equal(t, "requird", parseErr.Segment)
+	assert.Equal(t, `unknown key "requird" (at 7)`, err.Error())
+}
+
+func TestUnmarshal_InvalidValue(t *testing.T) {
+	var mm minMax
+	err := Unmarshal(`min=five`, &mm)
+	require.Error(t, err)
+
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeInvalidValue, parseErr.Code)
+	assert.Equal(t, 4, parseErr.Pos)
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+
+	// A flag has no value to convert
+	err = Unmarshal(`min`, &mm)
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeInvalidValue, parseErr.Code)
+	assert.Equal(t, 0, parseErr.Pos)
+}
+
+func TestUnmarshal_Lenient(t *testing.T) {
+	var mm minMax
+	err := New(WithLenient()).Unmarshal(`min=x,max=3,foo,required`, &mm)
+
+	var errs *Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs.List, 2)
+	assert.Equal(t, CodeInvalidValue, errs.List[0].Code)
+	assert.Equal(t, CodeUnknownKey, errs.List[1].Code)
+	assert.Equal(t, minMax{Max: 3, Required: true}, mm)
+}
+
+func TestUnmarshal_SyntaxError(t *testing.T) {
+	var mm minMax
+	err := Unmarshal(`min='1`, &mm)
+
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeUnterminatedQuote, parseErr.Code)
+}
+
+func TestUnmarshal_InvalidDestination(t *testing.T) {
+	var n int
+	var mm *minMax
+	for _, dst := range []any{nil, minMax{}, &n, mm} {
+		require.ErrorIs(t, Unmarshal(`min=1`, dst), ErrNotStruct)
+	}
+
+	var unsupported struct {
+		Ch chan int
+	}
+	require.ErrorIs(t, Unmarshal(`ch=1`, &unsupported), ErrUnsupportedType)
+
+	var badName struct {
+		N int `tagparser:",name"`
+	}
+	require.ErrorIs(t, Unmarshal(`x`, &badName), ErrUnsupportedType)
+}
+
+func TestDecode(t *testing.T) {
+	mm, err := Decode[minMax](`required,min=5,max=10`)
+	require.NoError(t, err)
+	assert.Equal(t, minMax{Min: 5, Max: 10, Required: true}, mm)
+
+	mm, err = Decode[minMax](`min=1,max=x`)
+	require.Error(t, err)
+	assert.Equal(t, minMax{Min: 1}, mm)
+
+	_, err = Decode[int](`min=1`)
+	require.ErrorIs(t, err, ErrNotStruct)
+}
+
+func TestDecodeWith(t *testing.T) {
+	mm, err := DecodeWith[minMax](New(WithLenient()), `min=1,bogus,max=2`)
+	require.Error(t, err)
+	assert.Equal(t, minMax{Min: 1, Max: 2}, mm)
+}
+
+func TestDecode_CachesDecoder(t *testing.T) {
+	typ := reflect.TypeFor[minMax]()
+	_, err := Decode[minMax](`min=1`)
+	require.NoError(t, err)
+
+	cached, ok := decoderCache.Load(typ)
+	require.True(t, ok)
+
+	_, err = Decode[minMax](`max=1`)
+	require.NoError(t, err)
+	again, _ := decoderCache.Load(typ)
+	assert.Same(t, cached, again)
+}
@@ -0,0 +1,44 @@
+package tagparser
+
+import "net/url"
+
+// URLValues returns the options of t as query parameters, for HTTP APIs
+// that accept the options of a tag in a query string:
+//
+//	tag, _ := tagparser.Parse(`min=5,omitempty`)
+//	tag.URLValues().Encode() // "min=5&omitempty="
+//
+// Each key has one value. Flags and empty values are both empty strings, as
+// query strings cannot tell them apart. The name is not an option and is
+// left out.
+func (t *Tag) URLValues() url.Values {
+	v := make(url.Values, len(t.Options))
+	for key, value := range t.Options {
+		v[key] = []string{value}
+	}
+
+	return v
+}
+
+// FromURLValues returns the Tag holding the query parameters v as options,
+// as in
+//
+//	q, _ := url.ParseQuery("min=5&omitempty")
+//	tag := tagparser.FromURLValues(q)
+//	// tag.Options == map[string]string{"min": "5", "omitempty": ""}
+//
+// A parameter without a value is a flag, and a repeated parameter keeps its
+// last value, as a repeated key of a tag does. Values are stored as they
+// are, as by Set.
+func FromURLValues(v url.Values) Tag {
+	t := Tag{Options: make(map[string]string, len(v))}
+	for key, values := range v {
+		if len(values) == 0 {
+			continue
+		}
+		value := values[len(values)-1]
+		t.setOption(key, value, false)
+	}
+
+	return t
+}
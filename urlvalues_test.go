@@ -0,0 +1,43 @@
+package tagparser
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTag_URLValues(t *testing.T) {
+	tag := MustParseWithName(`id,omitempty,min=5,default=,label='a, b&c'`)
+	v := tag.URLValues()
+	assert.Equal(t, url.Values{
+		"omitempty": {""},
+		"min":       {"5"},
+		"default":   {""},
+		"label":     {"a, b&c"},
+	}, v)
+	assert.Equal(t, "default=&label=a%2C+b%26c&min=5&omitempty=", v.Encode())
+
+	lists := New(WithListSeparator(';'))
+	tag, err := lists.Parse(`oneof='a\;b;c'`)
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"oneof": {"a;b;c"}}, tag.URLValues())
+
+	assert.Empty(t, (&Tag{}).URLValues())
+}
+
+func TestFromURLValues(t *testing.T) {
+	q, err := url.ParseQuery("min=5&omitempty&label=a%2C+b&max=1&max=2&empty")
+	require.NoError(t, err)
+	q["none"] = nil
+
+	tag := FromURLValues(q)
+	assert.Equal(t, map[string]string{"min": "5", "omitempty": "", "label": "a, b", "max": "2", "empty": ""}, tag.Options)
+	_, hasValue, _ := tag.Lookup("omitempty")
+	assert.False(t, hasValue)
+	assert.Empty(t, tag.Name)
+
+	back := FromURLValues(tag.URLValues())
+	assert.True(t, tag.Equal(&back))
+}
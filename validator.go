@@ -0,0 +1,144 @@
+package tagparser
+
+import "strings"
+
+// DialectValidator is the dialect of validator tags such as
+// `required,min=1,oneof=a b`, the syntax of ParseValidator: comma-separated
+// items taken literally, with key=value pairs. It rejects the tags
+// ParseValidator rejects. A Tag keeps neither the order of the rules nor
+// repeated rules, and the '|'-separated alternatives of a rule stay in a
+// single option, so engines should use ParseValidator; the dialect,
+// registered as "validator", serves ParseDialect, Convert and tools working
+// on any registered syntax.
+var DialectValidator = DialectSpec{Syntax: validatorParser, PostProcess: finishValidator}
+
+// validatorParser is the parser of DialectValidator.
+var validatorParser = New(withStdlibSyntax(), WithKeyValueSeparator('='))
+
+// validatorRules reads the rules of validator tags for ParseValidator.
+var validatorRules = New(withStdlibSyntax(), WithKeyValueSeparator('='), WithAlternatives())
+
+// ParseValidator parses a tag in the syntax of github.com/go-playground/validator,
+// such as `required,dive,keys,min=1,endkeys,oneof=a b c|len=0`, into its
+// ordered list of rules, like ParseRules with WithAlternatives. It is meant
+// as the front end of validation engines compatible with that syntax.
+//
+// The syntax differs from the default tag syntax: there is no quoting or
+// escaping and whitespace is significant. Rules are separated by commas and
+// their alternatives, any of which may pass, by '|'; a rule following a '|'
+// has Alt set. The parameter of a rule is the text after the first '=', kept
+// whole as its single Params element, see ValidatorParams. It cannot contain
+// commas or pipes, which are written 0x2C and 0x7C instead. The markers
+// `dive`, `keys` and `endkeys` are rules without parameters.
+//
+// Empty rules are reported with CodeEmptyKey, and `keys` not immediately
+// following `dive` or not closed by `endkeys`, as well as `endkeys` without
+// `keys`, with CodeInvalidRule.
+func ParseValidator(tag string) ([]Rule, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	if pos := emptyValidatorRule(tag); pos >= 0 {
+		return nil, validatorError(tag, pos, CodeEmptyKey, errEmptyKey)
+	}
+	alts, err := validatorRules.ParseAlternatives(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	keysPos := -1 // position of the open `keys` rule
+	afterDive := false
+	for _, alt := range alts {
+		// Markers are rules without parameters or alternatives
+		marker := ""
+		if len(alt) == 1 && alt[0].Params == nil {
+			marker = alt[0].Name
+		}
+		switch marker {
+		case "keys":
+			if !afterDive {
+				return nil, validatorError(tag, alt[0].Pos, CodeInvalidRule, "'keys' must immediately follow 'dive'")
+			}
+			keysPos = alt[0].Pos
+		case "endkeys":
+			if keysPos < 0 {
+				return nil, validatorError(tag, alt[0].Pos, CodeInvalidRule, "'endkeys' without 'keys'")
+			}
+			keysPos = -1
+		}
+		afterDive = marker == "dive"
+		for _, rule := range alt {
+			if len(rule.Params) == 1 && strings.Contains(rule.Params[0], "0x") {
+				rule.Params[0] = strings.NewReplacer("0x2C", ",", "0x7C", "|").Replace(rule.Params[0])
+			}
+			rules = append(rules, rule)
+		}
+	}
+	if keysPos >= 0 {
+		return nil, validatorError(tag, keysPos, CodeInvalidRule, "'keys' without 'endkeys'")
+	}
+
+	return rules, nil
+}
+
+// ValidatorParams splits the parameter of a rule returned by ParseValidator
+// on spaces, as the oneof check does, keeping single-quoted parameters such
+// as 'red green' together without their quotes.
+func ValidatorParams(param string) []string {
+	var params []string
+	s := param
+	for {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			return params
+		}
+		if s[0] == '\'' {
+			if end := strings.IndexByte(s[1:], '\''); end >= 0 {
+				params = append(params, s[1:1+end])
+				s = s[2+end:]
+
+				continue
+			}
+		}
+		end := strings.IndexByte(s, ' ')
+		if end < 0 {
+			end = len(s)
+		}
+		params = append(params, s[:end])
+		s = s[end:]
+	}
+}
+
+// emptyValidatorRule returns the position of the first empty rule of tag,
+// which ParseRules would skip, or -1.
+func emptyValidatorRule(tag string) int {
+	isSep := func(i int) bool { return tag[i] == ',' || tag[i] == altSep }
+	for i := 0; i <= len(tag); i++ {
+		if (i == len(tag) || isSep(i)) && (i == 0 || isSep(i-1)) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// validatorError reports a problem at pos in the comma-separated rule
+// around it.
+func validatorError(tag string, pos int, code ErrorCode, msg string) *Error {
+	start := strings.LastIndexByte(tag[:pos], ',') + 1
+	end := len(tag)
+	if i := strings.IndexByte(tag[pos:], ','); i >= 0 {
+		end = pos + i
+	}
+
+	return (&Error{
+		Tag:     tag,
+		Pos:     pos,
+		Msg:     msg,
+		Segment: tag[start:end],
+		Offset:  start,
+		Len:     end - start,
+		Code:    code,
+	}).locate()
+}
@@ -0,0 +1,100 @@
+package tagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValidator(t *testing.T) {
+	rules, err := ParseValidator(`required,dive,keys,min=1,endkeys,oneof=a b|len=0,excludesall=0x2C0x7C`)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Name: "required", Pos: 0},
+		{Name: "dive", Pos: 9},
+		{Name: "keys", Pos: 14},
+		{Name: "min", Params: []string{"1"}, Pos: 19},
+		{Name: "endkeys", Pos: 25},
+		{Name: "oneof", Params: []string{"a b"}, Pos: 33},
+		{Name: "len", Params: []string{"0"}, Pos: 43, Alt: true},
+		{Name: "excludesall", Params: []string{",|"}, Pos: 49},
+	}, rules)
+
+	rules, err = ParseValidator(``)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	// Only the first '=' separates the parameter
+	rules, err = ParseValidator(`eq=a=b`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a=b"}, rules[0].Params)
+
+	// Markers with alternatives are ordinary rules
+	_, err = ParseValidator(`dive|x,keys,endkeys`)
+	require.Error(t, err)
+}
+
+func TestParseValidator_Errors(t *testing.T) {
+	tests := []struct {
+		tag  string
+		code ErrorCode
+		pos  int
+		msg  string
+	}{
+		{`required,,min=1`, CodeEmptyKey, 9, "empty key"},
+		{`required,`, CodeEmptyKey, 9, "empty key"},
+		{`min=1|=2`, CodeEmptyKey, 6, "empty key"},
+		{`min=1||max=2`, CodeEmptyKey, 6, "empty key"},
+		{`|min=1`, CodeEmptyKey, 0, "empty key"},
+		{`required,keys,endkeys`, CodeInvalidRule, 9, "'keys' must immediately follow 'dive'"},
+		{`dive,endkeys`, CodeInvalidRule, 5, "'endkeys' without 'keys'"},
+		{`dive,keys,min=1`, CodeInvalidRule, 5, "'keys' without 'endkeys'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			_, err := ParseValidator(tt.tag)
+			var parseErr *Error
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tt.code, parseErr.Code)
+			assert.Equal(t, tt.pos, parseErr.Pos)
+			assert.Equal(t, tt.msg, parseErr.Msg)
+			assert.Equal(t, tt.tag[parseErr.Offset:parseErr.Offset+parseErr.Len], parseErr.Segment)
+		})
+	}
+}
+
+func TestValidatorParams(t *testing.T) {
+	tests := []struct {
+		param string
+		want  []string
+	}{
+		{``, nil},
+		{`red green  blue`, []string{"red", "green", "blue"}},
+		{`'light blue' red`, []string{"light blue", "red"}},
+		{`'open`, []string{"'open"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			assert.Equal(t, tt.want, ValidatorParams(tt.param))
+		})
+	}
+}
+
+func TestDialectValidator(t *testing.T) {
+	tag, err := ParseDialect("validator", `required,dive,oneof='a b' c|len=0`)
+	require.NoError(t, err)
+	assert.Equal(t, M{"required": "", "dive": "", "oneof": "'a b' c|len=0"}, tag.Options)
+
+	_, err = ParseDialect("validator", `dive,keys,min=1`)
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, CodeInvalidRule, parseErr.Code)
+
+	validator, ok := LookupDialect("validator")
+	require.True(t, ok)
+	assert.Same(t, DialectValidator.Parser(), validator.Parser())
+	s, err := Convert(`min=1,max=5`, DialectSpec{}, validator)
+	require.NoError(t, err)
+	assert.Equal(t, "min=1,max=5", s)
+}